@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 	"github.com/labstack/echo/v4"
 	"gopkg.in/yaml.v3"
 	"log"
@@ -15,10 +16,14 @@ import (
 	"os/signal"
 	"sacred/internal/api"
 	"sacred/internal/db"
+	"sacred/internal/mail"
 	"sacred/internal/middleware"
 	"sacred/internal/s3"
 	"syscall"
 	"time"
+
+	// Embedded zone data for users' timezones; the runtime image has none.
+	_ "time/tzdata"
 )
 
 type Config struct {
@@ -29,6 +34,10 @@ type Config struct {
 	JWTSecret        string `yaml:"jwt_secret"`
 	MetaFetchURL     string `yaml:"meta_fetch_url"`
 	WebhookURL       string `yaml:"webhook_url"`
+	WebAppURL        string `yaml:"web_app_url"`
+	MiniAppURL       string `yaml:"mini_app_url"`
+	TelegramAPIURL   string `yaml:"telegram_api_url"`
+	BotMode          string `yaml:"bot_mode" validate:"omitempty,oneof=webhook polling"`
 	AWS              struct {
 		AccessKeyID     string `yaml:"access_key_id"`
 		SecretAccessKey string `yaml:"secret_access_key"`
@@ -36,6 +45,14 @@ type Config struct {
 		Bucket          string `yaml:"bucket"`
 	} `yaml:"aws"`
 	AssetsURL string `yaml:"assets_url"`
+	// SMTP is optional; without a host, email login links are not sent.
+	SMTP struct {
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		From     string `yaml:"from"`
+	} `yaml:"smtp"`
 }
 
 func ReadConfig(filePath string) (*Config, error) {
@@ -70,12 +87,18 @@ func (cv *customValidator) Validate(i interface{}) error {
 	return nil
 }
 
-func gracefulShutdown(e *echo.Echo, logr *slog.Logger) {
+// botModePolling receives Telegram updates with getUpdates instead of a webhook,
+// so the bot can run locally without a public HTTPS URL.
+const botModePolling = "polling"
+
+func gracefulShutdown(e *echo.Echo, logr *slog.Logger, stopBot context.CancelFunc) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logr.Info("Shutting down server...")
 
+	stopBot()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -133,6 +156,9 @@ func main() {
 		MetaFetchURL:     cfg.MetaFetchURL,
 		AssetsURL:        cfg.AssetsURL,
 		WebhookURL:       cfg.WebhookURL,
+		WebAppURL:        cfg.WebAppURL,
+		MiniAppURL:       cfg.MiniAppURL,
+		TelegramAPIURL:   cfg.TelegramAPIURL,
 	}
 
 	s3Client, err := s3.NewS3Client(
@@ -142,22 +168,54 @@ func main() {
 		log.Fatalf("Failed to initialize AWS S3 client: %v", err)
 	}
 
-	bot, err := telegram.New(cfg.TelegramBotToken)
+	var a *api.API
+
+	var botOpts []telegram.Option
+	if cfg.TelegramAPIURL != "" {
+		botOpts = append(botOpts, telegram.WithServerURL(cfg.TelegramAPIURL))
+	}
+	if cfg.BotMode == botModePolling {
+		// Polling only starts once a is assigned below, so the handler never sees a nil API.
+		botOpts = append(botOpts, telegram.WithDefaultHandler(func(ctx context.Context, _ *telegram.Bot, update *models.Update) {
+			a.ProcessUpdate(ctx, update)
+		}))
+	}
+
+	bot, err := telegram.New(cfg.TelegramBotToken, botOpts...)
 	if err != nil {
 		log.Fatalf("failed to create telegram bot: %v", err)
 	}
 
-	a := api.New(storage, aConfig, s3Client, bot)
+	var mailClient *mail.Client
+	if cfg.SMTP.Host != "" {
+		mailClient = mail.NewClient(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From)
+	}
+
+	a = api.New(storage, aConfig, s3Client, bot, mailClient)
 
-	if err := a.SetupWebhook(context.Background()); err != nil {
+	botCtx, stopBot := context.WithCancel(context.Background())
+	defer stopBot()
+
+	if cfg.BotMode == botModePolling {
+		go func() {
+			if err := a.StartPolling(botCtx); err != nil {
+				logr.Error("Telegram polling stopped", "error", err)
+			}
+		}()
+	} else if err := a.SetupWebhook(context.Background()); err != nil {
 		log.Fatalf("failed to setup webhook: %v", err)
 	}
 
+	go a.RunNotificationDelivery(botCtx, api.NotificationDeliveryInterval)
+	go a.RunReminders(botCtx, api.ReminderCheckInterval)
+	go a.RunDigests(botCtx, api.DigestCheckInterval)
+	go a.RunAccountDeletions(botCtx, api.AccountDeletionCheckInterval)
+
 	a.SetupRoutes(e)
 
 	// TODO: e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	go gracefulShutdown(e, logr)
+	go gracefulShutdown(e, logr, stopBot)
 
 	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	logr.Info("Starting server", "address", address)
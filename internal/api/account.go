@@ -0,0 +1,273 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"log"
+	"net/http"
+	"path"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"strings"
+	"time"
+)
+
+const (
+	// AccountDeletionCheckInterval is how often accounts past their cooling-off
+	// period are purged.
+	AccountDeletionCheckInterval = time.Hour
+
+	// accountDeletionGracePeriod is how long a user can change their mind
+	// after asking to delete their account.
+	accountDeletionGracePeriod = 30 * 24 * time.Hour
+
+	blobDeletionBatchSize = 100
+)
+
+// GetAccountDeletion tells whether the account is scheduled for deletion.
+func (a *API) GetAccountDeletion(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	requested, err := a.storage.GetAccountDeletion(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not get account deletion").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, accountDeletionResponse(requested))
+}
+
+// RequestAccountDeletion schedules the account for deletion once the
+// cooling-off period is over.
+func (a *API) RequestAccountDeletion(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	requested, err := a.storage.RequestAccountDeletion(c.Request().Context(), uid, time.Now())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not request account deletion").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, accountDeletionResponse(&requested))
+}
+
+func (a *API) CancelAccountDeletion(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	err = a.storage.CancelAccountDeletion(c.Request().Context(), uid)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "account deletion was not requested").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not cancel account deletion").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+// isPendingDeletion reports whether the user asked to delete their account.
+// Such profiles are hidden from everyone but their owner during the
+// cooling-off period.
+func (a *API) isPendingDeletion(ctx context.Context, user db.User, viewerID string) (bool, error) {
+	if user.ID == viewerID {
+		return false, nil
+	}
+
+	requested, err := a.storage.GetAccountDeletion(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return requested != nil, nil
+}
+
+func accountDeletionResponse(requested *time.Time) contract.AccountDeletionResponse {
+	if requested == nil {
+		return contract.AccountDeletionResponse{}
+	}
+
+	deleteAt := requested.Add(accountDeletionGracePeriod)
+	return contract.AccountDeletionResponse{RequestedAt: requested, DeleteAt: &deleteAt}
+}
+
+// ExportAccount streams a ZIP with the user's data as data.json and, when
+// storage is configured, the images of their wishes.
+func (a *API) ExportAccount(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	export, err := a.storage.ExportUserData(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not export account").WithInternal(err)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not export account").WithInternal(err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="sacred-%s.zip"`, export.Profile.Username))
+	res.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(res)
+
+	w, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	if a.s3 != nil {
+		for _, wish := range export.Wishes {
+			for _, img := range wish.Images {
+				a.exportImage(c.Request().Context(), zw, img.URL)
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+// exportImage adds a stored image to the archive under images/. Missing
+// files are skipped; the export still lists their keys.
+func (a *API) exportImage(ctx context.Context, zw *zip.Writer, key string) {
+	if strings.Contains(key, "://") {
+		return
+	}
+
+	data, err := a.s3.DownloadFile(ctx, key)
+	if err != nil {
+		log.Printf("Failed to export image %s: %v", key, err)
+		return
+	}
+
+	w, err := zw.Create(path.Join("images", key))
+	if err != nil {
+		log.Printf("Failed to export image %s: %v", key, err)
+		return
+	}
+
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Failed to export image %s: %v", key, err)
+	}
+}
+
+// RunAccountDeletions purges accounts past their cooling-off period and
+// removes their files every interval until ctx is cancelled.
+func (a *API) RunAccountDeletions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := a.PurgeDeletedAccounts(ctx, now); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Failed to purge deleted accounts: %v", err)
+			}
+			if err := a.DeleteQueuedBlobs(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Failed to delete queued files: %v", err)
+			}
+		}
+	}
+}
+
+// PurgeDeletedAccounts erases every account whose deletion was requested
+// more than the cooling-off period before now.
+func (a *API) PurgeDeletedAccounts(ctx context.Context, now time.Time) error {
+	ids, err := a.storage.ListAccountsDueForDeletion(ctx, now.Add(-accountDeletionGracePeriod))
+	if err != nil {
+		return fmt.Errorf("failed to list accounts due for deletion: %w", err)
+	}
+
+	for _, uid := range ids {
+		user, err := a.storage.GetUserByID(uid)
+		if err != nil {
+			log.Printf("Failed to get user %s for deletion: %v", uid, err)
+			continue
+		}
+
+		var blobs []string
+		if key, ok := a.uploadedAvatarKey(user); ok {
+			blobs = append(blobs, key)
+		}
+
+		wishIDs, wishlistIDs, err := a.storage.ListOwnedContentIDs(ctx, uid)
+		if err != nil {
+			log.Printf("Failed to list content of user %s for deletion: %v", uid, err)
+			continue
+		}
+
+		blobs = append(blobs, ogCardObjects("profiles/"+uid)...)
+		for _, id := range wishIDs {
+			blobs = append(blobs, ogCardObjects("wishes/"+id)...)
+		}
+		for _, id := range wishlistIDs {
+			blobs = append(blobs, ogCardObjects("wishlists/"+id)...)
+		}
+
+		if err := a.storage.PurgeUser(ctx, uid, blobs...); err != nil {
+			log.Printf("Failed to purge user %s: %v", uid, err)
+		}
+	}
+
+	return nil
+}
+
+// uploadedAvatarKey returns the storage key of an avatar copied from
+// Telegram. The stock avatars are shared and never deleted.
+func (a *API) uploadedAvatarKey(user db.User) (string, bool) {
+	if user.AvatarURL == nil {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(*user.AvatarURL, a.cfg.AssetsURL+"/")
+	if !strings.HasPrefix(key, "fb/users/") {
+		return "", false
+	}
+
+	return key, true
+}
+
+// DeleteQueuedBlobs removes files queued by account deletions from storage.
+// Keys that fail stay queued for the next run.
+func (a *API) DeleteQueuedBlobs(ctx context.Context) error {
+	if a.s3 == nil {
+		return nil
+	}
+
+	keys, err := a.storage.ListBlobDeletions(ctx, blobDeletionBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list queued files: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := a.s3.DeleteFile(ctx, key); err != nil {
+			log.Printf("Failed to delete file %s: %v", key, err)
+			continue
+		}
+
+		if err := a.storage.CompleteBlobDeletion(ctx, key); err != nil {
+			return fmt.Errorf("failed to forget deleted file %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
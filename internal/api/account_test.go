@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountExport(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	user, err := testutils.AuthHelper(t, ts.Echo, 8601, "exporter", "Exporter")
+	require.NoError(t, err)
+	friend, err := testutils.AuthHelper(t, ts.Echo, 8602, "export_friend", "Friend")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name := "Exported wish"
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_export", UserID: user.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, nil))
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+user.User.ID+`"}`, friend.Token, http.StatusOK)
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/export", "", user.Token, http.StatusOK)
+	assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	require.Equal(t, "data.json", zr.File[0].Name)
+
+	f, err := zr.File[0].Open()
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+
+	var export db.UserExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, user.User.ID, export.Profile.ID)
+	require.Len(t, export.Wishes, 1)
+	assert.Equal(t, "wish_export", export.Wishes[0].ID)
+	require.Len(t, export.Followers, 1)
+	assert.Equal(t, friend.User.ID, export.Followers[0].ID)
+	assert.Empty(t, export.Following)
+}
+
+func TestAccountDeletion(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	user, err := testutils.AuthHelper(t, ts.Echo, 8701, "leaving", "Leaving")
+	require.NoError(t, err)
+	friend, err := testutils.AuthHelper(t, ts.Echo, 8702, "staying", "Staying")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name := "Doomed wish"
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_doomed", UserID: user.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, nil))
+	_, err = ts.Storage.CreateWishImage(ctx, db.WishImage{ID: "img_doomed", WishID: "wish_doomed", URL: "wishes/wish_doomed-1.jpg", CreatedAt: now})
+	require.NoError(t, err)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+user.User.ID+`"}`, friend.Token, http.StatusOK)
+
+	status := func() contract.AccountDeletionResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/deletion", "", user.Token, http.StatusOK)
+		return testutils.ParseResponse[contract.AccountDeletionResponse](t, rec)
+	}
+
+	t.Run("Cooling-off period can be cancelled", func(t *testing.T) {
+		assert.Nil(t, status().RequestedAt)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/deletion", "", user.Token, http.StatusOK)
+		scheduled := testutils.ParseResponse[contract.AccountDeletionResponse](t, rec)
+		require.NotNil(t, scheduled.DeleteAt)
+		assert.WithinDuration(t, now.Add(30*24*time.Hour), *scheduled.DeleteAt, time.Minute)
+
+		require.NoError(t, ts.API.PurgeDeletedAccounts(ctx, now.Add(24*time.Hour)))
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+user.User.ID, "", user.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_doomed", "", user.Token, http.StatusOK)
+
+		// Others no longer see the account while it waits to be deleted.
+		for _, token := range []string{friend.Token, ""} {
+			testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+user.User.ID, "", token, http.StatusNotFound)
+			testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_doomed", "", token, http.StatusNotFound)
+		}
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/u/leaving", "", "", http.StatusNotFound)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/deletion", "", user.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/deletion", "", user.Token, http.StatusNotFound)
+		assert.Nil(t, status().RequestedAt)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+user.User.ID, "", friend.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_doomed", "", friend.Token, http.StatusOK)
+	})
+
+	t.Run("Account is purged after the cooling-off period", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/deletion", "", user.Token, http.StatusOK)
+		require.NoError(t, ts.API.PurgeDeletedAccounts(ctx, now.Add(31*24*time.Hour)))
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+user.User.ID, "", "", http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_doomed", "", "", http.StatusNotFound)
+
+		followers, following, err := ts.Storage.CountFollows(ctx, friend.User.ID, friend.User.ID)
+		require.NoError(t, err)
+		assert.Zero(t, followers)
+		assert.Zero(t, following)
+
+		keys, err := ts.Storage.ListBlobDeletions(ctx, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			"wishes/wish_doomed-1.jpg",
+			strings.TrimPrefix(*user.User.AvatarURL, ts.Config.AssetsURL+"/"),
+			"og/profiles/" + user.User.ID + "-en.png",
+			"og/profiles/" + user.User.ID + "-ru.png",
+			"og/wishes/wish_doomed-en.png",
+			"og/wishes/wish_doomed-ru.png",
+		}, keys)
+
+		again, err := testutils.AuthHelper(t, ts.Echo, 8701, "leaving", "Leaving")
+		require.NoError(t, err)
+		assert.NotEqual(t, user.User.ID, again.User.ID, "signing in again starts a new account")
+		assert.Equal(t, "leaving", again.User.Username)
+	})
+}
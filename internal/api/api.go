@@ -5,14 +5,14 @@ import (
 	"errors"
 	"fmt"
 	telegram "github.com/go-telegram/bot"
-	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
 	"net/http"
-	"sacred/internal/contract"
 	"sacred/internal/db"
 	"sacred/internal/middleware"
 	"sacred/internal/s3"
+	"time"
 )
 
 // storager interface for database operations
@@ -25,13 +25,41 @@ type storager interface {
 	CreateWish(ctx context.Context, wish db.Wish, categories []string) error
 	CreateWishlist(ctx context.Context, list db.Wishlist) (db.Wishlist, error)
 	GetWishByID(ctx context.Context, uid, id string) (db.Wish, error)
+	GetWishByShareToken(ctx context.Context, uid, token string) (db.Wish, error)
 	GetWishlistByID(ctx context.Context, id string) (db.Wishlist, error)
+	ListUserWishlists(ctx context.Context, uid string) ([]db.Wishlist, error)
+	UpdateWishlist(ctx context.Context, list db.Wishlist) error
+	DeleteWishlist(ctx context.Context, uid, id string) error
 	UpdateUser(ctx context.Context, user db.User, interests []string) error
 	ListCategories(ctx context.Context) ([]db.Category, error)
 	ListUsers(ctx context.Context, uid string) ([]db.User, error)
-	GetWishesByUserID(ctx context.Context, userID string) ([]db.Wish, error)
+	GetWishesByUserID(ctx context.Context, viewerID, userID string) ([]db.Wish, error)
 	FollowUser(ctx context.Context, uid, followID string) error
 	UnfollowUser(ctx context.Context, uid, UnfollowID string) error
+	CountFollows(ctx context.Context, viewerID, uid string) (int, int, error)
+	ListFollowers(ctx context.Context, viewerID, uid string, limit, offset int) ([]db.User, int, error)
+	ListFollowing(ctx context.Context, viewerID, uid string, limit, offset int) ([]db.User, int, error)
+	SearchUsers(ctx context.Context, viewerID, search string, limit, offset int) ([]db.User, error)
+	SuggestUsers(ctx context.Context, uid string, limit int) ([]db.User, error)
+	ExportUserData(ctx context.Context, uid string) (db.UserExport, error)
+	GetAccountDeletion(ctx context.Context, uid string) (*time.Time, error)
+	RequestAccountDeletion(ctx context.Context, uid string, at time.Time) (time.Time, error)
+	CancelAccountDeletion(ctx context.Context, uid string) error
+	ListAccountsDueForDeletion(ctx context.Context, before time.Time) ([]string, error)
+	PurgeUser(ctx context.Context, uid string, extraBlobs ...string) error
+	ListOwnedContentIDs(ctx context.Context, uid string) ([]string, []string, error)
+	ListBlobDeletions(ctx context.Context, limit int) ([]string, error)
+	CompleteBlobDeletion(ctx context.Context, key string) error
+	CreateComment(ctx context.Context, c db.Comment) error
+	GetComment(ctx context.Context, id string) (db.Comment, error)
+	ListComments(ctx context.Context, viewerID, wishID string) ([]db.Comment, error)
+	DeleteComment(ctx context.Context, id string) error
+	ToggleReaction(ctx context.Context, uid, wishID, reaction string) (*string, error)
+	RemoveReaction(ctx context.Context, uid, wishID string) error
+	GetReactionCounts(ctx context.Context, wishID string) (map[string]int, error)
+	FulfillWish(ctx context.Context, uid string, f db.Fulfillment) (db.Fulfillment, error)
+	UnfulfillWish(ctx context.Context, uid, wishID string) error
+	ListReceivedGifts(ctx context.Context, viewerID, uid string, limit, offset int) ([]db.ReceivedGift, int, error)
 	IsFollowing(ctx context.Context, followerID, followingID string) (bool, error)
 	CreateWishImage(ctx context.Context, image db.WishImage) (db.WishImage, error)
 	DeleteWishImages(ctx context.Context, wishID string, photoIDs []string) error
@@ -41,13 +69,86 @@ type storager interface {
 	DeleteWish(ctx context.Context, uid, id string) error
 	GetPublicWishesFeed(ctx context.Context, uid *string, search string) ([]db.Wish, error)
 	GetWishAutocomplete(ctx context.Context, prefix string, limit int) ([]db.AutocompleteSuggestion, error)
-	GetUsersWhoSavedWish(ctx context.Context, wishID string, limit int, offset int) ([]db.User, int, error)
+	GetUsersWhoSavedWish(ctx context.Context, viewerID, wishID string, limit int, offset int) ([]db.User, int, error)
+	ReserveWish(ctx context.Context, uid, wishID string) error
+	UnreserveWish(ctx context.Context, uid, wishID string) error
+	ListReservedWishes(ctx context.Context, uid string) ([]db.Wish, error)
+	SearchUserWishes(ctx context.Context, uid, searchQuery string, includePublic bool, limit, offset int) ([]db.Wish, error)
+	GetUserByReferralCode(code string) (db.User, error)
+	CreateReferral(ctx context.Context, ref db.Referral) error
+	ListReferrals(ctx context.Context, uid string) ([]db.ReferredUser, error)
+	CreateNotification(ctx context.Context, n db.Notification, push bool) error
+	ListNotifications(ctx context.Context, uid string, limit, offset int) ([]db.Notification, error)
+	CountUnreadNotifications(ctx context.Context, uid string) (int, error)
+	MarkNotificationsRead(ctx context.Context, uid string, ids []string) error
+	ListPendingNotifications(ctx context.Context, now time.Time, limit int) ([]db.PendingNotification, error)
+	MarkNotificationsPushed(ctx context.Context, ids []string) error
+	RetryNotifications(ctx context.Context, ids []string, retryAt time.Time) error
+	GetNotificationPreferences(ctx context.Context, uid string) ([]db.NotificationPreference, error)
+	UpdateNotificationPreferences(ctx context.Context, uid string, prefs []db.NotificationPreference) error
+	ListUpcomingEvents(ctx context.Context, monthDays []string, from, to string) ([]db.UpcomingEvent, error)
+	MarkReminderSent(ctx context.Context, uid, kind, eventID, occursOn string) (bool, error)
+	UnmarkReminderSent(ctx context.Context, uid, kind, eventID, occursOn string) error
+	ListGiftIdeas(ctx context.Context, viewerID, uid string, limit int) ([]db.Wish, error)
+	ListDigestRecipients(ctx context.Context, notSince time.Time) ([]db.DigestRecipient, error)
+	MarkDigestSent(ctx context.Context, uid, period string, sentAt time.Time) (bool, error)
+	ListNewFollowedWishes(ctx context.Context, uid string, since time.Time, limit int) ([]db.DigestWish, int, error)
+	ListFollowedPriceDrops(ctx context.Context, uid string, since time.Time, limit int) ([]db.DigestWish, error)
+	ListNewSaves(ctx context.Context, uid string, since time.Time) ([]db.WishSaves, error)
+	CreateSession(ctx context.Context, session db.Session, tokenHash string) error
+	RotateSession(ctx context.Context, tokenHash, newTokenHash string, userAgent, ip *string, expiresAt time.Time) (db.Session, error)
+	IsSessionActive(ctx context.Context, id string) (bool, error)
+	ListSessions(ctx context.Context, uid string) ([]db.Session, error)
+	RevokeSession(ctx context.Context, uid, id string) error
+	RevokeSessionByToken(ctx context.Context, tokenHash string) error
+	CreateAPIToken(ctx context.Context, token db.APIToken, tokenHash string) (db.APIToken, error)
+	UseAPIToken(ctx context.Context, tokenHash string) (db.APIToken, error)
+	ListAPITokens(ctx context.Context, uid string) ([]db.APIToken, error)
+	CountAPITokens(ctx context.Context, uid string) (int, error)
+	DeleteAPIToken(ctx context.Context, uid, id string) error
+	FindUserIDByEmail(ctx context.Context, email string) (string, error)
+	CreateLoginToken(ctx context.Context, uid, tokenHash string, expiresAt time.Time) error
+	CountLoginTokensSince(ctx context.Context, uid string, since time.Time) (int, error)
+	ConsumeLoginToken(ctx context.Context, tokenHash string) (string, error)
+	CreateEmailVerification(ctx context.Context, uid, email, tokenHash string, expiresAt time.Time) error
+	CountEmailVerificationsSince(ctx context.Context, uid string, since time.Time) (int, error)
+	ConfirmEmail(ctx context.Context, tokenHash string) (string, error)
+	GetUserByUsername(username string) (db.User, error)
+	GetUserByPreviousUsername(username string) (db.User, error)
+	IsUsernameTaken(ctx context.Context, username, uid string) (bool, error)
+	IsWishShareable(ctx context.Context, id string) (bool, error)
+	ListShareableWishes(ctx context.Context, uid string, limit int) ([]db.Wish, error)
+	ListShareableWishlistWishes(ctx context.Context, listID string, limit int) ([]db.Wish, error)
+	BlockUser(ctx context.Context, uid, blockedID string) error
+	UnblockUser(ctx context.Context, uid, blockedID string) error
+	IsBlocked(ctx context.Context, uid, otherID string) (bool, error)
+	HasBlocked(ctx context.Context, uid, blockedID string) (bool, error)
+	ListBlockedUsers(ctx context.Context, uid string) ([]db.User, error)
+	MuteUser(ctx context.Context, uid, mutedID string) error
+	UnmuteUser(ctx context.Context, uid, mutedID string) error
+	IsMuted(ctx context.Context, uid, mutedID string) (bool, error)
+	ListMutedUsers(ctx context.Context, uid string) ([]db.User, error)
+	CreateFollowRequest(ctx context.Context, uid, targetID string) error
+	HasFollowRequest(ctx context.Context, uid, targetID string) (bool, error)
+	ListFollowRequests(ctx context.Context, uid string) ([]db.User, error)
+	AcceptFollowRequest(ctx context.Context, uid, requesterID string) error
+	AcceptAllFollowRequests(ctx context.Context, uid string) error
+	DeleteFollowRequest(ctx context.Context, uid, targetID string) error
+}
+
+// mailer sends transactional emails such as login links.
+type mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
 }
 
 type API struct {
 	storage storager
 	s3      *s3.Client
 	bot     *telegram.Bot
+	mail    mailer
+
+	// sendLimiter keeps outgoing notification messages under Telegram's rate limit.
+	sendLimiter *rate.Limiter
 
 	cfg Config
 }
@@ -59,38 +160,31 @@ type Config struct {
 	WebAppURL        string
 	AssetsURL        string
 	WebhookURL       string
+	MiniAppURL       string
+	TelegramAPIURL   string
 }
 
-func New(storage storager, cfg Config, s3 *s3.Client, bot *telegram.Bot) *API {
+func New(storage storager, cfg Config, s3 *s3.Client, bot *telegram.Bot, mail mailer) *API {
 	return &API{
 		storage: storage,
 		cfg:     cfg,
 		s3:      s3,
 		bot:     bot,
+		mail:    mail,
+
+		sendLimiter: rate.NewLimiter(notificationSendRate, 1),
 	}
 }
 
+// getUserID returns the signed-in user, or 401 for anonymous requests. Routes
+// in the optional group may ignore the error to serve guests.
 func getUserID(c echo.Context) (string, error) {
-	user := c.Get("user")
-	if user == nil {
+	p, ok := middleware.GetPrincipal(c)
+	if !ok || p.UserID == "" {
 		return "", echo.NewHTTPError(http.StatusUnauthorized, "no authentication token")
 	}
 
-	token, ok := user.(*jwt.Token)
-	if !ok {
-		return "", echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
-	}
-
-	claims, ok := token.Claims.(*contract.JWTClaims)
-	if !ok {
-		return "", echo.NewHTTPError(http.StatusUnauthorized, "invalid claims")
-	}
-
-	if claims.UID == "" {
-		return "", echo.NewHTTPError(http.StatusUnauthorized, "user ID not found in token")
-	}
-
-	return claims.UID, nil
+	return p.UserID, nil
 }
 
 func (a *API) SetupWebhook(ctx context.Context) error {
@@ -119,32 +213,120 @@ func (a *API) SetupWebhook(ctx context.Context) error {
 	return nil
 }
 
+// StartPolling removes any registered webhook and receives updates with
+// getUpdates until ctx is cancelled. The bot must be created with a default
+// handler that forwards to ProcessUpdate.
+func (a *API) StartPolling(ctx context.Context) error {
+	if a.bot == nil {
+		return errors.New("bot is not initialized")
+	}
+
+	if _, err := a.bot.DeleteWebhook(ctx, &telegram.DeleteWebhookParams{}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	fmt.Println("telegram bot started in polling mode")
+	a.bot.Start(ctx)
+	return nil
+}
+
+func (a *API) telegramAPIURL() string {
+	if a.cfg.TelegramAPIURL != "" {
+		return a.cfg.TelegramAPIURL
+	}
+	return "https://api.telegram.org"
+}
+
 func (a *API) SetupRoutes(e *echo.Echo) {
 
 	e.POST("/auth/telegram", a.AuthTelegram)
+	e.POST("/auth/telegram/widget", a.AuthTelegramWidget)
+	e.POST("/auth/email", a.RequestEmailLogin)
+	e.POST("/auth/email/verify", a.VerifyEmailLogin)
+	e.POST("/auth/email/confirm", a.ConfirmEmail)
+	e.POST("/auth/refresh", a.RefreshToken)
+	e.POST("/auth/logout", a.Logout)
 	e.POST("/webhook", a.HandleWebhook)
 
-	// Regular API routes (require JWT auth)
+	// Share pages for links opened outside Telegram
+	e.GET("/w/:id", a.ShareWishPage)
+	e.GET("/w/:id/og.png", a.ShareWishCard)
+	e.GET("/u/:username", a.ShareProfilePage, a.followUsernameChanges)
+	e.GET("/u/:username/og.png", a.ShareProfileCard, a.followUsernameChanges)
+	e.GET("/l/:id/og.png", a.ShareWishlistCard)
+
+	// Every /v1 request is authenticated when it carries a JWT or an API token.
 	v1 := e.Group("/v1")
 	v1.Use(echojwt.WithConfig(middleware.GetUserAuthConfig(a.cfg.JWTSecret)))
+	v1.Use(a.authenticateAPIToken)
+	v1.Use(a.requireActiveSession)
+
+	// Public content that guests can see too.
+	optional := v1.Group("")
+	optional.GET("/categories", a.ListCategories)
+	optional.GET("/feed", a.GetWishesFeed)
+	optional.GET("/feed/autocomplete", a.SearchFeed)
+	optional.GET("/wishes/:id", a.GetWishHandler)
+	optional.GET("/wishes/:id/savers", a.GetWishSaversHandler)
+	optional.GET("/wishes/:id/comments", a.ListWishComments)
+	optional.GET("/shared/:token", a.GetSharedWishHandler)
+	optional.GET("/profiles/:id", a.GetUserProfile)
+	optional.GET("/profiles/by-username/:username", a.GetUserProfileByUsername)
+	optional.GET("/profiles/:id/followers", a.ListFollowers)
+	optional.GET("/profiles/:id/following", a.ListFollowing)
+	optional.GET("/profiles/:id/received", a.ListReceivedGifts)
 
-	v1.PUT("/wishes/:id", a.UpdateWishHandler)
-	v1.POST("/wishes", a.CreateWishHandler)
-	v1.GET("/wishes/:id", a.GetWishHandler)
-	v1.PUT("/user/settings", a.UpdateUserPreferences)
-	v1.PUT("/user/interests", a.UpdateUserInterests)
-	v1.GET("/categories", a.ListCategories)
-	v1.GET("/user/wishes", a.ListUserWishes)
-	v1.GET("/feed", a.GetWishesFeed)
-	v1.GET("/feed/autocomplete", a.SearchFeed)
-	v1.GET("/profiles", a.ListProfiles)
-	v1.GET("/profiles/:id", a.GetUserProfile)
-	v1.POST("/users/follow", a.FollowUser)
-	v1.POST("/users/unfollow", a.UnfollowUser)
-	v1.POST("/wishes/:id/bookmark", a.SaveWishToBookmarks)
-	v1.DELETE("/wishes/:id/bookmark", a.RemoveWishFromBookmarks)
-	v1.GET("/bookmarks", a.ListBookmarkedWishes)
-	v1.POST("/wishes/:id/copy", a.CopyWishHandler)
-	v1.GET("/wishes/:id/savers", a.GetWishSaversHandler)
-	v1.DELETE("/wishes/:id", a.DeleteWishHandler)
+	authed := v1.Group("", middleware.RequireAuth)
+	authed.PUT("/wishes/:id", a.UpdateWishHandler)
+	authed.POST("/wishes", a.CreateWishHandler)
+	authed.PUT("/user/settings", a.UpdateUserPreferences)
+	authed.PUT("/user/interests", a.UpdateUserInterests)
+	authed.GET("/user/wishes", a.ListUserWishes)
+	authed.GET("/profiles", a.ListProfiles)
+	authed.GET("/users/search", a.SearchUsers)
+	authed.GET("/users/suggestions", a.SuggestUsers)
+	authed.POST("/users/follow", a.FollowUser)
+	authed.POST("/users/unfollow", a.UnfollowUser)
+	authed.POST("/users/block", a.BlockUser)
+	authed.POST("/users/unblock", a.UnblockUser)
+	authed.POST("/users/mute", a.MuteUser)
+	authed.POST("/users/unmute", a.UnmuteUser)
+	authed.GET("/user/blocked", a.ListBlockedUsers)
+	authed.GET("/user/muted", a.ListMutedUsers)
+	authed.GET("/user/follow-requests", a.ListFollowRequests)
+	authed.POST("/user/follow-requests/:id/accept", a.AcceptFollowRequest)
+	authed.POST("/user/follow-requests/:id/decline", a.DeclineFollowRequest)
+	authed.POST("/wishes/:id/bookmark", a.SaveWishToBookmarks)
+	authed.DELETE("/wishes/:id/bookmark", a.RemoveWishFromBookmarks)
+	authed.GET("/bookmarks", a.ListBookmarkedWishes)
+	authed.POST("/wishes/:id/copy", a.CopyWishHandler)
+	authed.DELETE("/wishes/:id", a.DeleteWishHandler)
+	authed.POST("/wishes/:id/comments", a.CreateWishComment)
+	authed.DELETE("/wishes/:id/comments/:comment_id", a.DeleteWishComment)
+	authed.POST("/wishes/:id/reaction", a.ToggleWishReaction)
+	authed.DELETE("/wishes/:id/reaction", a.RemoveWishReaction)
+	authed.GET("/user/wishlists", a.ListWishlists)
+	authed.POST("/wishlists", a.CreateWishlist)
+	authed.PUT("/wishlists/:id", a.UpdateWishlist)
+	authed.DELETE("/wishlists/:id", a.DeleteWishlist)
+	authed.POST("/wishes/:id/fulfill", a.FulfillWish)
+	authed.DELETE("/wishes/:id/fulfill", a.UnfulfillWish)
+	authed.POST("/wishes/:id/reserve", a.ReserveWishHandler)
+	authed.DELETE("/wishes/:id/reserve", a.UnreserveWishHandler)
+	authed.GET("/user/reserved", a.ListReservedWishes)
+	authed.GET("/user/referrals", a.ListReferrals)
+	authed.GET("/user/sessions", a.ListSessions, requireSignedIn)
+	authed.DELETE("/user/sessions/:id", a.RevokeSession, requireSignedIn)
+	authed.GET("/user/tokens", a.ListAPITokens, requireSignedIn)
+	authed.POST("/user/tokens", a.CreateAPIToken, requireSignedIn)
+	authed.DELETE("/user/tokens/:id", a.DeleteAPIToken, requireSignedIn)
+	authed.GET("/user/export", a.ExportAccount, requireSignedIn)
+	authed.POST("/user/email/confirm", a.ResendEmailConfirmation, requireSignedIn)
+	authed.GET("/user/deletion", a.GetAccountDeletion, requireSignedIn)
+	authed.POST("/user/deletion", a.RequestAccountDeletion, requireSignedIn)
+	authed.DELETE("/user/deletion", a.CancelAccountDeletion, requireSignedIn)
+	authed.GET("/notifications", a.ListNotifications)
+	authed.POST("/notifications/read", a.MarkNotificationsRead)
+	authed.GET("/notifications/preferences", a.GetNotificationPreferences)
+	authed.PUT("/notifications/preferences", a.UpdateNotificationPreferences)
 }
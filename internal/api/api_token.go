@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/middleware"
+)
+
+const (
+	apiTokenHeader = "X-API-Token"
+
+	// apiTokenPrefix makes leaked tokens easy to recognize in logs and code.
+	apiTokenPrefix = "sacred_"
+
+	maxAPITokens = 20
+
+	ErrInvalidAPIToken  = "invalid api token"
+	ErrAPITokenReadOnly = "api token is read-only"
+)
+
+// authenticateAPIToken accepts a personal API token in the X-API-Token header
+// when the request carries no JWT.
+func (a *API) authenticateAPIToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		raw := c.Request().Header.Get(apiTokenHeader)
+		if _, ok := middleware.GetPrincipal(c); raw == "" || ok {
+			return next(c)
+		}
+
+		token, err := a.storage.UseAPIToken(c.Request().Context(), hashToken(raw))
+		if errors.Is(err, db.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidAPIToken)
+		} else if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to check api token").WithInternal(err)
+		}
+
+		user, err := a.storage.GetUserByID(token.UserID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidAPIToken).WithInternal(err)
+		}
+
+		if token.Scope != db.APITokenScopeWrite && !isReadMethod(c.Request().Method) {
+			return echo.NewHTTPError(http.StatusForbidden, ErrAPITokenReadOnly)
+		}
+
+		middleware.SetPrincipal(c, &middleware.Principal{
+			UserID:     user.ID,
+			ChatID:     user.ChatID,
+			Lang:       user.LanguageCode,
+			APITokenID: token.ID,
+			Scope:      token.Scope,
+		})
+
+		return next(c)
+	}
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// requireSignedIn keeps API tokens away from endpoints that manage the
+// account's credentials, so a leaked token cannot mint or revoke others.
+func requireSignedIn(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if p, ok := middleware.GetPrincipal(c); ok && p.APITokenID != "" {
+			return echo.NewHTTPError(http.StatusForbidden, "not allowed with an api token")
+		}
+
+		return next(c)
+	}
+}
+
+func (a *API) ListAPITokens(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := a.storage.ListAPITokens(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list api tokens").WithInternal(err)
+	}
+
+	resp := make([]contract.APITokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		resp = append(resp, contract.ToAPITokenResponse(t))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (a *API) CreateAPIToken(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req contract.CreateAPITokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	count, err := a.storage.CountAPITokens(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create api token").WithInternal(err)
+	}
+
+	if count >= maxAPITokens {
+		return echo.NewHTTPError(http.StatusBadRequest, "too many api tokens")
+	}
+
+	secret, err := newSecretToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create api token").WithInternal(err)
+	}
+	raw := apiTokenPrefix + secret
+
+	token, err := a.storage.CreateAPIToken(c.Request().Context(), db.APIToken{
+		ID:     nanoid.Must(),
+		UserID: uid,
+		Name:   req.Name,
+		Scope:  req.Scope,
+	}, hashToken(raw))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create api token").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, contract.CreateAPITokenResponse{
+		APITokenResponse: contract.ToAPITokenResponse(token),
+		Token:            raw,
+	})
+}
+
+func (a *API) DeleteAPIToken(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	err = a.storage.DeleteAPIToken(c.Request().Context(), uid, c.Param("id"))
+	if errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "api token not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not delete api token").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
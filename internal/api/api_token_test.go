@@ -0,0 +1,82 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sacred/internal/contract"
+	"sacred/internal/testutils"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func performWithAPIToken(e *echo.Echo, method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-API-Token", token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPITokens(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	auth, err := testutils.AuthHelper(t, ts.Echo, 7501, "token_user", "Token")
+	require.NoError(t, err)
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/tokens", `{"name":"Script","scope":"admin"}`, auth.Token, http.StatusBadRequest)
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/tokens", `{"name":"Script","scope":"read"}`, auth.Token, http.StatusCreated)
+	readToken := testutils.ParseResponse[contract.CreateAPITokenResponse](t, rec)
+	require.True(t, strings.HasPrefix(readToken.Token, "sacred_"))
+
+	rec = testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/tokens", `{"name":"Extension","scope":"write"}`, auth.Token, http.StatusCreated)
+	writeToken := testutils.ParseResponse[contract.CreateAPITokenResponse](t, rec)
+
+	t.Run("Read scope", func(t *testing.T) {
+		rec := performWithAPIToken(ts.Echo, http.MethodGet, "/v1/user/wishes", "", readToken.Token)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = performWithAPIToken(ts.Echo, http.MethodPost, "/v1/notifications/read", `{}`, readToken.Token)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Write scope", func(t *testing.T) {
+		rec := performWithAPIToken(ts.Echo, http.MethodPost, "/v1/notifications/read", `{}`, writeToken.Token)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+
+	t.Run("Cannot manage tokens", func(t *testing.T) {
+		rec := performWithAPIToken(ts.Echo, http.MethodPost, "/v1/user/tokens", `{"name":"Other","scope":"write"}`, writeToken.Token)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = performWithAPIToken(ts.Echo, http.MethodGet, "/v1/user/sessions", "", writeToken.Token)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = performWithAPIToken(ts.Echo, http.MethodGet, "/v1/user/deletion", "", writeToken.Token)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("List records usage", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/tokens", "", auth.Token, http.StatusOK)
+		tokens := testutils.ParseResponse[[]contract.APITokenResponse](t, rec)
+		require.Len(t, tokens, 2)
+		for _, tok := range tokens {
+			assert.NotNil(t, tok.LastUsedAt, tok.Name)
+		}
+		assert.NotContains(t, rec.Body.String(), readToken.Token)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/tokens/"+readToken.ID, "", auth.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/tokens/"+readToken.ID, "", auth.Token, http.StatusNotFound)
+
+		rec := performWithAPIToken(ts.Echo, http.MethodGet, "/v1/user/wishes", "", readToken.Token)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"sacred/internal/contract"
 	"sacred/internal/db"
+	"sacred/internal/i18n"
 	"time"
 )
 
@@ -46,103 +47,161 @@ func (a *API) AuthTelegram(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidInitData).WithInternal(err)
 	}
 
-	user, err := a.storage.GetUserByChatID(data.User.ID)
-	if err != nil && errors.Is(err, db.ErrNotFound) {
-		username := data.User.Username
-		if username == "" {
-			username = "user_" + fmt.Sprintf("%d", data.User.ID)
+	user, err := a.findOrCreateTelegramUser(c, data.User, data.StartParam, db.ReferralSourceMiniApp)
+	if err != nil {
+		return err
+	}
+
+	return a.respondWithSession(c, user, data.StartParam)
+}
+
+// registrationUsername keeps the Telegram username of a new user if it
+// follows the username policy and nobody picked it as their handle yet,
+// falling back to user_<telegram id>, numbered when that is taken too.
+func (a *API) registrationUsername(ctx context.Context, username string, telegramID int64) string {
+	if username != "" && contract.ValidateUsername(username) == nil {
+		taken, err := a.storage.IsUsernameTaken(ctx, username, "")
+		if err != nil {
+			log.Printf("failed to check username %s: %v", username, err)
+		} else if !taken {
+			return username
 		}
+	}
 
-		var name *string
-		if data.User.FirstName != "" {
-			name = &data.User.FirstName
-			if data.User.LastName != "" {
-				nameWithLast := fmt.Sprintf("%s %s", data.User.FirstName, data.User.LastName)
-				name = &nameWithLast
-			}
+	fallback := fmt.Sprintf("user_%d", telegramID)
+	candidate := fallback
+	for n := 2; ; n++ {
+		taken, err := a.storage.IsUsernameTaken(ctx, candidate, "")
+		if err != nil {
+			log.Printf("failed to check username %s: %v", candidate, err)
+			return candidate
 		}
+		if !taken {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", fallback, n)
+	}
+}
+
+// findOrCreateTelegramUser returns the user for a Telegram account, registering
+// them on first login.
+func (a *API) findOrCreateTelegramUser(c echo.Context, tgUser initdata.User, rawStartParam, source string) (db.User, error) {
+	user, err := a.storage.GetUserByChatID(tgUser.ID)
+	if err == nil {
+		return user, nil
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to get user").WithInternal(err)
+	}
 
-		lang := "ru"
+	username := a.registrationUsername(c.Request().Context(), tgUser.Username, tgUser.ID)
 
-		if data.User.LanguageCode != "ru" {
-			lang = "en"
+	var name *string
+	if tgUser.FirstName != "" {
+		name = &tgUser.FirstName
+		if tgUser.LastName != "" {
+			nameWithLast := fmt.Sprintf("%s %s", tgUser.FirstName, tgUser.LastName)
+			name = &nameWithLast
 		}
+	}
 
-		imgUrl := fmt.Sprintf("%s/avatars/%d.svg", a.cfg.AssetsURL, rand.Intn(30)+1)
+	lang := i18n.Normalize(tgUser.LanguageCode)
 
-		if data.User.PhotoURL != "" {
-			imgFile := fmt.Sprintf("fb/users/%s.jpg", nanoid.Must())
-			imgUrl = fmt.Sprintf("%s/%s", a.cfg.AssetsURL, imgFile)
-			go func() {
-				if err = a.uploadImageToS3(data.User.PhotoURL, imgFile); err != nil {
-					log.Printf("failed to upload user avatar to S3: %v", err)
-				}
-			}()
-		}
+	imgUrl := fmt.Sprintf("%s/avatars/%d.svg", a.cfg.AssetsURL, rand.Intn(30)+1)
 
-		create := db.User{
-			ID:           nanoid.Must(),
-			Username:     username,
-			ChatID:       data.User.ID,
-			ReferralCode: nanoid.Must(),
-			Name:         name,
-			LanguageCode: lang,
-			AvatarURL:    &imgUrl,
-		}
+	if tgUser.PhotoURL != "" {
+		imgFile := fmt.Sprintf("fb/users/%s.jpg", nanoid.Must())
+		imgUrl = fmt.Sprintf("%s/%s", a.cfg.AssetsURL, imgFile)
+		go func() {
+			if err := a.uploadImageToS3(tgUser.PhotoURL, imgFile); err != nil {
+				log.Printf("failed to upload user avatar to S3: %v", err)
+			}
+		}()
+	}
 
-		if err = a.storage.CreateUser(context.Background(), &create); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "failed to create user").WithInternal(err)
-		}
+	create := db.User{
+		ID:           nanoid.Must(),
+		Username:     username,
+		ChatID:       tgUser.ID,
+		ReferralCode: nanoid.Must(),
+		Name:         name,
+		LanguageCode: lang,
+		AvatarURL:    &imgUrl,
+	}
 
-		user, err = a.storage.GetUserByChatID(data.User.ID)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user").WithInternal(err)
-		}
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user").WithInternal(err)
+	var referrer *db.User
+	sp, hasStartParam := parseStartParam(rawStartParam)
+	if hasStartParam {
+		referrer = a.attachReferrer(c.Request().Context(), &create, sp)
+	}
+
+	if err = a.storage.CreateUser(context.Background(), &create); err != nil {
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to create user").WithInternal(err)
 	}
 
-	token, err := generateJWT(user.ID, user.ChatID, a.cfg.JWTSecret)
+	a.recordReferral(c.Request().Context(), referrer, create, source, sp)
 
+	user, err = a.storage.GetUserByChatID(tgUser.ID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "jwt library error").WithInternal(err)
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to get user").WithInternal(err)
+	}
+
+	return user, nil
+}
+
+// respondWithSession starts a session for the user and replies with the same
+// payload for every login method.
+func (a *API) respondWithSession(c echo.Context, user db.User, rawStartParam string) error {
+	tokens, err := a.startSession(c, user)
+	if err != nil {
+		return err
 	}
 
 	uresp := contract.UserResponse{
-		ID:           user.ID,
-		Name:         user.Name,
-		Username:     user.Username,
-		ChatID:       user.ChatID,
-		LanguageCode: user.LanguageCode,
-		CreatedAt:    user.CreatedAt,
-		Email:        user.Email,
-		ReferralCode: user.ReferralCode,
-		ReferredBy:   user.ReferredBy,
-		Interests:    user.Interests,
-		AvatarURL:    user.AvatarURL,
-	}
-
-	wishes, err := a.storage.GetWishesByUserID(context.Background(), user.ID)
+		ID:            user.ID,
+		Name:          user.Name,
+		Username:      user.Username,
+		ChatID:        user.ChatID,
+		LanguageCode:  user.LanguageCode,
+		CreatedAt:     user.CreatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		ReferralCode:  user.ReferralCode,
+		ReferredBy:    user.ReferredBy,
+		Interests:     user.Interests,
+		AvatarURL:     user.AvatarURL,
+		Birthday:      user.Birthday,
+		Timezone:      user.Timezone,
+	}
+
+	wishes, err := a.storage.GetWishesByUserID(context.Background(), user.ID, user.ID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user's wishlists").WithInternal(err)
 	}
 
 	resp := &contract.AuthResponse{
-		Token:  token,
-		User:   uresp,
-		Wishes: wishes,
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         uresp,
+		Wishes:       wishes,
+	}
+
+	if sp, ok := parseStartParam(rawStartParam); ok {
+		resp.StartTarget = sp.target()
 	}
 
 	return c.JSON(http.StatusOK, resp)
 }
 
-func generateJWT(userID string, chatID int64, secretKey string) (string, error) {
+func generateJWT(userID string, chatID int64, lang, sessionID string, secretKey string) (string, error) {
 	claims := &contract.JWTClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
 		},
-		UID:    userID,
-		ChatID: chatID,
+		UID:       userID,
+		ChatID:    chatID,
+		Lang:      lang,
+		SessionID: sessionID,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
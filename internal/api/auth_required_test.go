@@ -0,0 +1,104 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publicRoutes are the /v1 routes guests may call; every other one must
+// answer 401 without credentials.
+var publicRoutes = map[string]bool{
+	"GET /v1/categories":                     true,
+	"GET /v1/feed":                           true,
+	"GET /v1/feed/autocomplete":              true,
+	"GET /v1/wishes/:id":                     true,
+	"GET /v1/wishes/:id/savers":              true,
+	"GET /v1/wishes/:id/comments":            true,
+	"GET /v1/profiles/by-username/:username": true,
+	"GET /v1/profiles/:id":                   true,
+	"GET /v1/profiles/:id/followers":         true,
+	"GET /v1/profiles/:id/following":         true,
+	"GET /v1/profiles/:id/received":          true,
+	"GET /v1/shared/:token":                  true,
+}
+
+func TestProtectedRoutesRequireAuth(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	checked := 0
+	for _, r := range ts.Echo.Routes() {
+		if !strings.HasPrefix(r.Path, "/v1/") || strings.HasSuffix(r.Path, "*") {
+			continue
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+		default:
+			continue
+		}
+
+		key := r.Method + " " + r.Path
+		if publicRoutes[key] {
+			continue
+		}
+
+		t.Run(key, func(t *testing.T) {
+			path := strings.ReplaceAll(r.Path, ":id", "some_id")
+			req := httptest.NewRequest(r.Method, path, strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			ts.Echo.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+		})
+		checked++
+	}
+
+	assert.Greater(t, checked, 20)
+}
+
+func TestPublicRoutesAllowGuests(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 7701, "public_owner", "Owner")
+	require.NoError(t, err)
+
+	now := time.Now()
+	name := "Public Lamp"
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: "cat_public", Name: "Public Cat", ImageURL: "url"}))
+	require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+		ID: "wish_public", UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{"cat_public"}))
+
+	for _, path := range []string{
+		"/v1/categories",
+		"/v1/feed",
+		"/v1/feed/autocomplete?search=lamp",
+		"/v1/wishes/wish_public",
+		"/v1/wishes/wish_public/savers",
+		"/v1/profiles/" + owner.User.ID,
+	} {
+		t.Run(path, func(t *testing.T) {
+			testutils.PerformRequest(t, ts.Echo, http.MethodGet, path, "", "", http.StatusOK)
+		})
+	}
+
+	t.Run("Invalid token is still rejected", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", "not-a-jwt", http.StatusUnauthorized)
+	})
+
+	t.Run("Missing profile", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/missing", "", "", http.StatusNotFound)
+	})
+}
@@ -2,10 +2,14 @@ package api_test
 
 import (
 	"encoding/json"
+	"github.com/labstack/echo/v4"
 	"net/http"
+	"net/http/httptest"
 	"sacred/internal/api"
 	"sacred/internal/contract"
+	"sacred/internal/i18n"
 	"sacred/internal/testutils"
+	"strings"
 	"testing"
 )
 
@@ -66,3 +70,72 @@ func TestTelegramAuth_MissingQuery(t *testing.T) {
 		t.Errorf("Expected error '%s', got '%s'", api.ErrInvalidRequest, resp.Error)
 	}
 }
+
+func TestTelegramAuth_ReferralStartParam(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	referrer, err := testutils.AuthHelper(t, ts.Echo, 8001, "referrer", "Referrer")
+	if err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	invited, err := testutils.AuthHelperWithStartParam(t, ts.Echo, 8002, "invited", "Invited", "r_"+referrer.User.ReferralCode)
+	if err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	if invited.User.ReferredBy == nil || *invited.User.ReferredBy != referrer.User.ReferralCode {
+		t.Errorf("Expected ReferredBy '%s', got '%v'", referrer.User.ReferralCode, invited.User.ReferredBy)
+	}
+
+	viaProfile, err := testutils.AuthHelperWithStartParam(t, ts.Echo, 8003, "via_profile", "Via Profile", "u_"+referrer.User.ID)
+	if err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	if viaProfile.StartTarget == nil || viaProfile.StartTarget.Type != contract.StartTargetProfile || viaProfile.StartTarget.ID != referrer.User.ID {
+		t.Errorf("Expected profile start target for '%s', got '%v'", referrer.User.ID, viaProfile.StartTarget)
+	}
+
+	// Logging in again with a start param must not re-attribute an existing user.
+	if _, err := testutils.AuthHelperWithStartParam(t, ts.Echo, 8001, "referrer", "Referrer", "u_"+viaProfile.User.ID); err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/referrals", "", referrer.Token, http.StatusOK)
+	referrals := testutils.ParseResponse[[]contract.ReferralResponse](t, rec)
+
+	if len(referrals) != 2 {
+		t.Fatalf("Expected 2 referrals, got %d", len(referrals))
+	}
+
+	rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/referrals", "", viaProfile.Token, http.StatusOK)
+	referrals = testutils.ParseResponse[[]contract.ReferralResponse](t, rec)
+
+	if len(referrals) != 0 {
+		t.Errorf("Expected no referrals for existing user login, got %d", len(referrals))
+	}
+}
+
+func TestTelegramAuth_LocalizedError(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	body, _ := json.Marshal(contract.AuthTelegramRequest{Query: "invalid-init-data"})
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/telegram", strings.NewReader(string(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+	rec := httptest.NewRecorder()
+	ts.Echo.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	resp := testutils.ParseResponse[contract.ErrorResponse](t, rec)
+	if want := i18n.Error("ru", api.ErrInvalidInitData); resp.Error != want || want == api.ErrInvalidInitData {
+		t.Errorf("Expected localized error '%s', got '%s'", want, resp.Error)
+	}
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+	"log"
+	"net/http"
+	"net/url"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// loginWidgetMaxAge bounds how old a Login Widget signature may be, like
+	// the expiry used for mini app init data.
+	loginWidgetMaxAge = 24 * time.Hour
+
+	// loginWidgetClockSkew tolerates a Telegram clock slightly ahead of ours;
+	// anything further in the future is rejected.
+	loginWidgetClockSkew = time.Minute
+
+	loginTokenTTL = 15 * time.Minute
+
+	// At most loginTokenLimit links are sent per address within loginTokenTTL.
+	loginTokenLimit = 3
+
+	emailVerificationTTL = 24 * time.Hour
+
+	// At most emailVerificationLimit confirmation links are sent per user
+	// within loginTokenTTL.
+	emailVerificationLimit = 3
+
+	ErrInvalidLoginWidget      = "invalid telegram login data"
+	ErrInvalidLoginToken       = "invalid or expired login link"
+	ErrInvalidEmailVerifyToken = "invalid or expired confirmation link"
+)
+
+// AuthTelegramWidget logs in from a regular browser with the Telegram Login Widget.
+func (a *API) AuthTelegramWidget(c echo.Context) error {
+	var req contract.AuthTelegramWidgetRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	if err := verifyLoginWidget(req, a.cfg.TelegramBotToken, time.Now()); err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidLoginWidget).WithInternal(err)
+	}
+
+	tgUser := initdata.User{
+		ID:           req.ID,
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Username:     req.Username,
+		PhotoURL:     req.PhotoURL,
+		LanguageCode: i18n.FromAcceptLanguage(c.Request().Header.Get("Accept-Language")),
+	}
+
+	user, err := a.findOrCreateTelegramUser(c, tgUser, req.StartParam, db.ReferralSourceWeb)
+	if err != nil {
+		return err
+	}
+
+	return a.respondWithSession(c, user, req.StartParam)
+}
+
+// verifyLoginWidget checks the widget signature as described in
+// https://core.telegram.org/widgets/login#checking-authorization.
+func verifyLoginWidget(req contract.AuthTelegramWidgetRequest, botToken string, now time.Time) error {
+	fields := map[string]string{
+		"id":         strconv.FormatInt(req.ID, 10),
+		"first_name": req.FirstName,
+		"last_name":  req.LastName,
+		"username":   req.Username,
+		"photo_url":  req.PhotoURL,
+		"auth_date":  strconv.FormatInt(req.AuthDate, 10),
+	}
+
+	pairs := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if v != "" {
+			pairs = append(pairs, k+"="+v)
+		}
+	}
+	sort.Strings(pairs)
+
+	secret := sha256.Sum256([]byte(botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+
+	expected, err := hex.DecodeString(req.Hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+		return errors.New("hash mismatch")
+	}
+
+	authDate := time.Unix(req.AuthDate, 0)
+	if now.Sub(authDate) > loginWidgetMaxAge {
+		return errors.New("login data expired")
+	}
+
+	if authDate.After(now.Add(loginWidgetClockSkew)) {
+		return errors.New("login data from the future")
+	}
+
+	return nil
+}
+
+// RequestEmailLogin emails a one-time login link to the user with that
+// address. It always succeeds so it cannot be used to probe for accounts.
+func (a *API) RequestEmailLogin(c echo.Context) error {
+	var req contract.AuthEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	ctx := c.Request().Context()
+	ok := echo.Map{"message": "OK"}
+
+	uid, err := a.storage.FindUserIDByEmail(ctx, req.Email)
+	if errors.Is(err, db.ErrNotFound) {
+		return c.JSON(http.StatusOK, ok)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send login link").WithInternal(err)
+	}
+
+	recent, err := a.storage.CountLoginTokensSince(ctx, uid, time.Now().Add(-loginTokenTTL))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send login link").WithInternal(err)
+	}
+
+	if recent >= loginTokenLimit {
+		return c.JSON(http.StatusOK, ok)
+	}
+
+	user, err := a.storage.GetUserByID(uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send login link").WithInternal(err)
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send login link").WithInternal(err)
+	}
+
+	if err := a.storage.CreateLoginToken(ctx, uid, hashToken(token), time.Now().Add(loginTokenTTL)); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send login link").WithInternal(err)
+	}
+
+	link := fmt.Sprintf("%s/login?token=%s", a.cfg.WebAppURL, url.QueryEscape(token))
+	lang := user.LanguageCode
+
+	if err := a.mail.Send(ctx, req.Email, i18n.T(lang, i18n.EmailLoginSubject), i18n.T(lang, i18n.EmailLoginBody, link)); err != nil {
+		log.Printf("Failed to send login link to user %s: %v", uid, err)
+	}
+
+	return c.JSON(http.StatusOK, ok)
+}
+
+// VerifyEmailLogin exchanges a login link token for a session.
+func (a *API) VerifyEmailLogin(c echo.Context) error {
+	var req contract.AuthEmailVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	uid, err := a.storage.ConsumeLoginToken(c.Request().Context(), hashToken(req.Token))
+	if errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidLoginToken).WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user").WithInternal(err)
+	}
+
+	user, err := a.storage.GetUserByID(uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user").WithInternal(err)
+	}
+
+	return a.respondWithSession(c, user, "")
+}
+
+// sendEmailConfirmation mails a link that confirms the user's current email.
+// Only confirmed addresses can be used for email login.
+func (a *API) sendEmailConfirmation(ctx context.Context, user db.User) error {
+	if user.Email == nil || *user.Email == "" {
+		return nil
+	}
+
+	recent, err := a.storage.CountEmailVerificationsSince(ctx, user.ID, time.Now().Add(-loginTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	if recent >= emailVerificationLimit {
+		return nil
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	if err := a.storage.CreateEmailVerification(ctx, user.ID, *user.Email, hashToken(token), time.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/confirm-email?token=%s", a.cfg.WebAppURL, url.QueryEscape(token))
+	lang := user.LanguageCode
+
+	if err := a.mail.Send(ctx, *user.Email, i18n.T(lang, i18n.EmailConfirmSubject), i18n.T(lang, i18n.EmailConfirmBody, link)); err != nil {
+		log.Printf("Failed to send email confirmation to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResendEmailConfirmation mails a new confirmation link for the user's
+// current email, e.g. when the first one expired.
+func (a *API) ResendEmailConfirmation(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.storage.GetUserByID(uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	if user.EmailVerified {
+		return echo.NewHTTPError(http.StatusConflict, "email is already confirmed")
+	}
+
+	if err := a.sendEmailConfirmation(c.Request().Context(), user); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot send email confirmation").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+// ConfirmEmail marks the address a confirmation link was sent to as
+// confirmed, provided the user still has it in their settings.
+func (a *API) ConfirmEmail(c echo.Context) error {
+	var req contract.AuthEmailVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	_, err := a.storage.ConfirmEmail(c.Request().Context(), hashToken(req.Token))
+	if errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidEmailVerifyToken).WithInternal(err)
+	} else if errors.Is(err, db.ErrAlreadyExists) {
+		return echo.NewHTTPError(http.StatusConflict, "email is already in use").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to confirm email").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
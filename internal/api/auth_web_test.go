@@ -0,0 +1,180 @@
+package api_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signWidget(id int64, firstName, username string, authDate int64) string {
+	check := fmt.Sprintf("auth_date=%d\nfirst_name=%s\nid=%d\nusername=%s", authDate, firstName, id, username)
+	secret := sha256.Sum256([]byte(testutils.TestBotToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(check))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthTelegramWidget(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	miniApp, err := testutils.AuthHelper(t, ts.Echo, 7601, "widget_user", "Widget")
+	require.NoError(t, err)
+
+	authDate := time.Now().Unix()
+	body := func(hash string, authDate int64) string {
+		return fmt.Sprintf(`{"id":7601,"first_name":"Widget","username":"widget_user","auth_date":%d,"hash":"%s"}`, authDate, hash)
+	}
+
+	t.Run("Valid signature", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/telegram/widget",
+			body(signWidget(7601, "Widget", "widget_user", authDate), authDate), "", http.StatusOK)
+		resp := testutils.ParseResponse[contract.AuthResponse](t, rec)
+
+		assert.Equal(t, miniApp.User.ID, resp.User.ID)
+		assert.NotEmpty(t, resp.RefreshToken)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/wishes", "", resp.Token, http.StatusOK)
+	})
+
+	t.Run("Tampered data", func(t *testing.T) {
+		hash := signWidget(7601, "Widget", "someone_else", authDate)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/telegram/widget", body(hash, authDate), "", http.StatusUnauthorized)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		old := time.Now().Add(-48 * time.Hour).Unix()
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/telegram/widget",
+			body(signWidget(7601, "Widget", "widget_user", old), old), "", http.StatusUnauthorized)
+	})
+
+	t.Run("From the future", func(t *testing.T) {
+		future := time.Now().Add(time.Hour).Unix()
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/telegram/widget",
+			body(signWidget(7601, "Widget", "widget_user", future), future), "", http.StatusUnauthorized)
+	})
+}
+
+// mailedToken extracts the token from the link in an email body.
+func mailedToken(t *testing.T, body, path string) string {
+	_, rest, found := strings.Cut(body, path+"?token=")
+	require.True(t, found, body)
+	token, err := url.QueryUnescape(strings.Fields(rest)[0])
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestEmailLogin(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	auth, err := testutils.AuthHelper(t, ts.Echo, 7602, "email_user", "Email")
+	require.NoError(t, err)
+
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: "cat_email", Name: "Email Cat", ImageURL: "url"}))
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings",
+		`{"interests":["cat_email"],"email":"Email.User@example.com"}`, auth.Token, http.StatusOK)
+	assert.False(t, testutils.ParseResponse[db.User](t, rec).EmailVerified)
+
+	sent := ts.Mailer.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "Email.User@example.com", sent[0].To)
+	confirmToken := mailedToken(t, sent[0].Body, "/confirm-email")
+
+	t.Run("Unconfirmed address", func(t *testing.T) {
+		ts.Mailer.Reset()
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email", `{"email":"email.user@example.com"}`, "", http.StatusOK)
+		assert.Empty(t, ts.Mailer.Sent())
+	})
+
+	t.Run("Confirm address", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email/confirm", `{"token":"`+confirmToken+`"}`, "", http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email/confirm", `{"token":"`+confirmToken+`"}`, "", http.StatusBadRequest)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/email/confirm", "", auth.Token, http.StatusConflict)
+
+		user, err := ts.Storage.GetUserByID(auth.User.ID)
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+	})
+
+	t.Run("Unknown address", func(t *testing.T) {
+		ts.Mailer.Reset()
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email", `{"email":"nobody@example.com"}`, "", http.StatusOK)
+		assert.Empty(t, ts.Mailer.Sent())
+	})
+
+	t.Run("Login with link", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email", `{"email":"email.user@example.com"}`, "", http.StatusOK)
+
+		sent := ts.Mailer.Sent()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "email.user@example.com", sent[0].To)
+
+		token := mailedToken(t, sent[0].Body, "/login")
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email/verify", `{"token":"`+token+`"}`, "", http.StatusOK)
+		resp := testutils.ParseResponse[contract.AuthResponse](t, rec)
+		assert.Equal(t, auth.User.ID, resp.User.ID)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email/verify", `{"token":"`+token+`"}`, "", http.StatusUnauthorized)
+	})
+
+	t.Run("Rate limited", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email", `{"email":"email.user@example.com"}`, "", http.StatusOK)
+		}
+		assert.Len(t, ts.Mailer.Sent(), 3)
+	})
+
+	t.Run("Someone else claims the address", func(t *testing.T) {
+		other, err := testutils.AuthHelper(t, ts.Echo, 7603, "email_thief", "Thief")
+		require.NoError(t, err)
+
+		ts.Mailer.Reset()
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings",
+			`{"interests":["cat_email"],"email":"EMAIL.USER@example.com"}`, other.Token, http.StatusOK)
+
+		sent := ts.Mailer.Sent()
+		require.Len(t, sent, 1)
+		token := mailedToken(t, sent[0].Body, "/confirm-email")
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/email/confirm", `{"token":"`+token+`"}`, "", http.StatusConflict)
+
+		uid, err := ts.Storage.FindUserIDByEmail(context.Background(), "email.user@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, auth.User.ID, uid)
+	})
+
+	t.Run("Changing the address", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/tokens", `{"name":"Script","scope":"write"}`, auth.Token, http.StatusCreated)
+		apiToken := testutils.ParseResponse[contract.CreateAPITokenResponse](t, rec)
+
+		rec = performWithAPIToken(ts.Echo, http.MethodPut, "/v1/user/settings",
+			`{"interests":["cat_email"],"email":"new@example.com"}`, apiToken.Token)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = performWithAPIToken(ts.Echo, http.MethodPut, "/v1/user/settings",
+			`{"interests":["cat_email"],"email":"email.user@example.com","name":"Renamed"}`, apiToken.Token)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.True(t, testutils.ParseResponse[db.User](t, rec).EmailVerified)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings",
+			`{"interests":["cat_email"],"email":"new@example.com"}`, auth.Token, http.StatusOK)
+		assert.False(t, testutils.ParseResponse[db.User](t, rec).EmailVerified)
+
+		_, err := ts.Storage.FindUserIDByEmail(context.Background(), "email.user@example.com")
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+}
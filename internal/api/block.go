@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+)
+
+// BlockUser stops another user from following, reserving from or seeing the
+// current user, and hides them in return.
+func (a *API) BlockUser(c echo.Context) error {
+	uid, target, err := a.bindUserRelation(c)
+	if err != nil {
+		return err
+	}
+
+	if target == uid {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot block yourself")
+	}
+
+	if err := a.storage.BlockUser(c.Request().Context(), uid, target); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not block user").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) UnblockUser(c echo.Context) error {
+	uid, target, err := a.bindUserRelation(c)
+	if err != nil {
+		return err
+	}
+
+	if err := a.storage.UnblockUser(c.Request().Context(), uid, target); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not unblock user").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+// MuteUser hides another user's wishes from the current user's feed without
+// them knowing.
+func (a *API) MuteUser(c echo.Context) error {
+	uid, target, err := a.bindUserRelation(c)
+	if err != nil {
+		return err
+	}
+
+	if target == uid {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot mute yourself")
+	}
+
+	if err := a.storage.MuteUser(c.Request().Context(), uid, target); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not mute user").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) UnmuteUser(c echo.Context) error {
+	uid, target, err := a.bindUserRelation(c)
+	if err != nil {
+		return err
+	}
+
+	if err := a.storage.UnmuteUser(c.Request().Context(), uid, target); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not unmute user").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) ListBlockedUsers(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	users, err := a.storage.ListBlockedUsers(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list blocked users").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toShortUserProfiles(users))
+}
+
+func (a *API) ListMutedUsers(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	users, err := a.storage.ListMutedUsers(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list muted users").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toShortUserProfiles(users))
+}
+
+// bindUserRelation reads a UserRelationRequest and checks that the target
+// user exists.
+func (a *API) bindUserRelation(c echo.Context) (string, string, error) {
+	uid, err := getUserID(c)
+	if err != nil {
+		return "", "", err
+	}
+
+	var req contract.UserRelationRequest
+	if err := c.Bind(&req); err != nil {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return "", "", echo.NewHTTPError(http.StatusBadRequest, "failed to validate request")
+	}
+
+	if _, err := a.storage.GetUserByID(req.UserID); errors.Is(err, db.ErrNotFound) {
+		return "", "", echo.NewHTTPError(http.StatusNotFound, "user not found").WithInternal(err)
+	} else if err != nil {
+		return "", "", echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	return uid, req.UserID, nil
+}
+
+func toShortUserProfiles(users []db.User) []contract.ShortUserProfile {
+	resp := make([]contract.ShortUserProfile, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, contract.ToShortUserProfile(u))
+	}
+
+	return resp
+}
@@ -0,0 +1,126 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockAndMute(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	alice, err := testutils.AuthHelper(t, ts.Echo, 7901, "block_alice", "Alice")
+	require.NoError(t, err)
+	bob, err := testutils.AuthHelper(t, ts.Echo, 7902, "block_bob", "Bob")
+	require.NoError(t, err)
+	carol, err := testutils.AuthHelper(t, ts.Echo, 7903, "block_carol", "Carol")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_block", Name: "Block Cat", ImageURL: "url"}))
+	for _, w := range []struct{ id, owner string }{
+		{"wish_alice", alice.User.ID},
+		{"wish_bob", bob.User.ID},
+		{"wish_carol", carol.User.ID},
+	} {
+		name := w.id
+		testutils.CreateListedWish(t, ts.Storage, db.Wish{
+			ID: w.id, UserID: w.owner, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+		}, "cat_block")
+	}
+
+	bobCopy := "wish_alice copy"
+	sourceID := "wish_alice"
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_bob_copy", UserID: bob.User.ID, Name: &bobCopy, SourceID: &sourceID, CreatedAt: now, UpdatedAt: now,
+	}, []string{"cat_block"}))
+
+	feed := func(token string) []string {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", token, http.StatusOK)
+		var ids []string
+		for _, item := range testutils.ParseResponse[[]contract.FeedItem](t, rec) {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+alice.User.ID+`"}`, bob.Token, http.StatusOK)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_alice/reserve", "", bob.Token, http.StatusOK)
+
+	t.Run("Block", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/block", `{"user_id":"`+alice.User.ID+`"}`, alice.Token, http.StatusBadRequest)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/block", `{"user_id":"missing"}`, alice.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/block", `{"user_id":"`+bob.User.ID+`"}`, alice.Token, http.StatusOK)
+
+		following, err := ts.Storage.IsFollowing(ctx, bob.User.ID, alice.User.ID)
+		require.NoError(t, err)
+		assert.False(t, following, "block removes the follow")
+
+		wish, err := ts.Storage.GetWishByID(ctx, alice.User.ID, "wish_alice")
+		require.NoError(t, err)
+		assert.Nil(t, wish.ReservedBy, "block releases the reservation")
+		assert.Nil(t, wish.ReservedAt)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/blocked", "", alice.Token, http.StatusOK)
+		blocked := testutils.ParseResponse[[]contract.ShortUserProfile](t, rec)
+		require.Len(t, blocked, 1)
+		assert.Equal(t, bob.User.ID, blocked[0].ID)
+	})
+
+	t.Run("Blocked user is kept away", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+alice.User.ID+`"}`, bob.Token, http.StatusForbidden)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+bob.User.ID+`"}`, alice.Token, http.StatusForbidden)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_alice/reserve", "", bob.Token, http.StatusForbidden)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+alice.User.ID, "", bob.Token, http.StatusNotFound)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+bob.User.ID, "", alice.Token, http.StatusOK)
+		profile := testutils.ParseResponse[contract.UserProfileResponse](t, rec)
+		assert.True(t, profile.IsBlocked)
+		assert.Empty(t, profile.SavedItems)
+
+		assert.NotContains(t, feed(alice.Token), "wish_bob")
+		assert.NotContains(t, feed(bob.Token), "wish_alice")
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_alice/savers", "", alice.Token, http.StatusOK)
+		savers := testutils.ParseResponse[contract.WishSaversResponse](t, rec)
+		assert.Equal(t, 1, savers.Total)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_alice/savers", "", "", http.StatusOK)
+		savers = testutils.ParseResponse[contract.WishSaversResponse](t, rec)
+		assert.Equal(t, 2, savers.Total)
+	})
+
+	t.Run("Unblock", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/unblock", `{"user_id":"`+bob.User.ID+`"}`, alice.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+alice.User.ID, "", bob.Token, http.StatusOK)
+		assert.Contains(t, feed(alice.Token), "wish_bob")
+	})
+
+	t.Run("Mute", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/mute", `{"user_id":"`+carol.User.ID+`"}`, alice.Token, http.StatusOK)
+
+		assert.NotContains(t, feed(alice.Token), "wish_carol")
+		assert.Contains(t, feed(bob.Token), "wish_carol")
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+carol.User.ID, "", alice.Token, http.StatusOK)
+		assert.True(t, testutils.ParseResponse[contract.UserProfileResponse](t, rec).IsMuted)
+
+		// Muting is silent: the muted user can still follow.
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+alice.User.ID+`"}`, carol.Token, http.StatusOK)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/muted", "", alice.Token, http.StatusOK)
+		assert.Len(t, testutils.ParseResponse[[]contract.ShortUserProfile](t, rec), 1)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/unmute", `{"user_id":"`+carol.User.ID+`"}`, alice.Token, http.StatusOK)
+		assert.Contains(t, feed(alice.Token), "wish_carol")
+	})
+}
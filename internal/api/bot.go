@@ -13,6 +13,7 @@ import (
 	"math/rand"
 	"net/http"
 	"sacred/internal/db"
+	"sacred/internal/i18n"
 )
 
 func (a *API) HandleWebhook(c echo.Context) error {
@@ -22,18 +23,27 @@ func (a *API) HandleWebhook(c echo.Context) error {
 		return c.NoContent(400)
 	}
 
-	if update.Message == nil && update.CallbackQuery == nil {
-		return c.NoContent(200)
-	}
+	a.ProcessUpdate(context.Background(), &update)
 
-	resp := a.handleUpdate(&update)
-	if resp != nil {
-		if _, err := a.bot.SendMessage(context.Background(), resp); err != nil {
-			log.Printf("Failed to send message: %v", err)
+	return c.NoContent(200)
+}
+
+// ProcessUpdate handles a single Telegram update, whether it arrived through
+// the webhook or through long polling.
+func (a *API) ProcessUpdate(ctx context.Context, update *models.Update) {
+	switch {
+	case update.InlineQuery != nil:
+		a.handleInlineQuery(ctx, update.InlineQuery)
+	case update.CallbackQuery != nil:
+		a.handleCallbackQuery(ctx, update.CallbackQuery)
+	case update.Message != nil:
+		resp := a.handleUpdate(update)
+		if resp != nil {
+			if _, err := a.bot.SendMessage(ctx, resp); err != nil {
+				log.Printf("Failed to send message: %v", err)
+			}
 		}
 	}
-
-	return c.NoContent(200)
 }
 
 func (a *API) handleUpdate(update *models.Update) (msg *telegram.SendMessageParams) {
@@ -41,7 +51,7 @@ func (a *API) handleUpdate(update *models.Update) (msg *telegram.SendMessagePara
 	var telegramUserID int64
 	var name *string
 	var username string
-	languageCode := "en" // Default language code
+	languageCode := i18n.DefaultLanguage
 	if update.Message != nil && update.Message.From != nil {
 		chatID = update.Message.From.ID
 		telegramUserID = update.Message.From.ID
@@ -56,14 +66,7 @@ func (a *API) handleUpdate(update *models.Update) (msg *telegram.SendMessagePara
 			}
 		}
 
-		if update.Message.From.LanguageCode == "ru" {
-			languageCode = "ru"
-		}
-	}
-
-	if username == "" {
-		usernameFromID := fmt.Sprintf("user_%d", chatID)
-		username = usernameFromID
+		languageCode = i18n.Normalize(update.Message.From.LanguageCode)
 	}
 
 	user, err := a.storage.GetUserByChatID(chatID)
@@ -85,55 +88,78 @@ func (a *API) handleUpdate(update *models.Update) (msg *telegram.SendMessagePara
 		newUser := &db.User{
 			ID:           nanoid.Must(),
 			ChatID:       chatID,
-			Username:     username,
+			Username:     a.registrationUsername(context.Background(), username, telegramUserID),
 			Name:         name,
 			AvatarURL:    avatarURL,
 			LanguageCode: languageCode,
+			ReferralCode: nanoid.Must(),
+		}
+
+		var referrer *db.User
+		var sp startParam
+		if update.Message != nil {
+			if command, args := parseCommand(update.Message.Text); command == "start" {
+				if parsed, ok := parseStartParam(args); ok {
+					sp = parsed
+					referrer = a.attachReferrer(context.Background(), newUser, sp)
+				}
+			}
 		}
 
 		if err := a.storage.CreateUser(context.Background(), newUser); err != nil {
 			log.Printf("Failed to save user: %v", err)
-			msg.Text = "Ошибка при регистрации пользователя. Попробуй позже."
+			msg.Text = i18n.T(languageCode, i18n.BotRegistrationFailed)
 		} else {
-			msg.Text = "Добро пожаловать! Используй /start для начала работы с ботом."
+			a.recordReferral(context.Background(), referrer, *newUser, db.ReferralSourceBot, sp)
+			msg.Text = i18n.T(languageCode, i18n.BotWelcome)
 			// Set menu button for new user
-			a.setMenuButton(chatID)
+			a.setMenuButton(chatID, languageCode)
 		}
 
 		user, err = a.storage.GetUserByChatID(chatID)
 		if err != nil {
 			log.Printf("Failed to get user after saving: %v", err)
-			msg.Text = "Ошибка при получении пользователя. Попробуй позже."
+			msg.Text = i18n.T(languageCode, i18n.BotUserFetchFailed)
 		}
 	} else if err != nil {
 		log.Printf("Failed to get user: %v", err)
-		msg.Text = "Ошибка при получении пользователя. Попробуй позже."
+		msg.Text = i18n.T(languageCode, i18n.BotUserFetchFailed)
 	}
 
 	if update.Message == nil || user.ID == "" {
 		return msg
 	}
 
+	lang := user.LanguageCode
+
 	// Check if it's a command
 	if update.Message.Text != "" && len(update.Message.Text) > 0 && update.Message.Text[0] == '/' {
-		command := update.Message.Text[1:]
-		// Extract command without args
-		for i, r := range command {
-			if r == ' ' || r == '@' {
-				command = command[:i]
-				break
-			}
-		}
+		command, args := parseCommand(update.Message.Text)
 
 		switch command {
 		case "start":
-			msg.Text = "Привет\\!"
+			msg.Text = i18n.T(lang, i18n.BotStart)
 			msg.ParseMode = models.ParseModeMarkdown
 			// Set menu button on start
-			a.setMenuButton(chatID)
+			a.setMenuButton(chatID, lang)
+
+			if sp, ok := parseStartParam(args); ok && sp.target() != nil {
+				msg.ReplyMarkup = &models.InlineKeyboardMarkup{
+					InlineKeyboard: [][]models.InlineKeyboardButton{
+						{{Text: i18n.T(lang, i18n.BotOpen), URL: a.miniAppLink(sp.Raw)}},
+					},
+				}
+			}
 		case "help":
-			msg.Text = "TODO: Справка по командам\\!"
-			msg.ParseMode = models.ParseModeMarkdown
+			msg.Text = i18n.T(lang, i18n.BotHelp)
+		case "list":
+			a.commandList(context.Background(), user, msg)
+		case "add":
+			a.commandAdd(context.Background(), user, args, msg)
+		case "share":
+			a.commandShare(user, msg)
+		case "reserved":
+			a.commandReserved(context.Background(), user, msg)
 		case "test":
 			webAppInfo := &models.WebAppInfo{
 				URL: "https://127.0.0.1:3000",
@@ -151,13 +177,13 @@ func (a *API) handleUpdate(update *models.Update) (msg *telegram.SendMessagePara
 			msg.Text = "for local dev"
 			msg.ReplyMarkup = replyMarkup
 		default:
-			msg.Text = "Неизвестная команда. Используй /help для получения справки."
+			msg.Text = i18n.T(lang, i18n.BotUnknownCommand)
 		}
 		return msg
 	}
 
 	if msg.Text == "" {
-		msg.Text = "Send me a photo of your food to track nutrition!"
+		msg.Text = i18n.T(lang, i18n.BotFallback)
 	}
 
 	return msg
@@ -202,7 +228,7 @@ func (a *API) fetchAndUploadUserAvatar(ctx context.Context, userID int64) (*stri
 	}
 
 	// Download the file
-	fileURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", a.cfg.TelegramBotToken, file.FilePath)
+	fileURL := fmt.Sprintf("%s/file/bot%s/%s", a.telegramAPIURL(), a.cfg.TelegramBotToken, file.FilePath)
 	resp, err := http.Get(fileURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download file: %w", err)
@@ -229,14 +255,14 @@ func (a *API) fetchAndUploadUserAvatar(ctx context.Context, userID int64) (*stri
 	return &avatarURL, nil
 }
 
-func (a *API) setMenuButton(chatID int64) {
+func (a *API) setMenuButton(chatID int64, lang string) {
 	ctx := context.Background()
 
 	menu := telegram.SetChatMenuButtonParams{
 		ChatID: chatID,
 		MenuButton: models.MenuButtonWebApp{
 			Type:   "web_app",
-			Text:   "Open App",
+			Text:   i18n.T(lang, i18n.BotMenuButton),
 			WebApp: models.WebAppInfo{URL: a.cfg.WebAppURL},
 		},
 	}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"log"
+	"net/url"
+	"regexp"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	botListPageSize = 5
+
+	callbackListPage = "list"
+)
+
+var priceArgRe = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)([A-Za-z]{3})?$`)
+
+// parseCommand splits "/cmd@bot arg1 arg2" into the bare command and the raw argument string.
+func parseCommand(text string) (command, args string) {
+	command = strings.TrimPrefix(text, "/")
+	if i := strings.IndexAny(command, " \n"); i >= 0 {
+		args = strings.TrimSpace(command[i+1:])
+		command = command[:i]
+	}
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
+	}
+	return command, args
+}
+
+// miniAppLink builds a t.me deep link that opens the mini app with the given start parameter.
+func (a *API) miniAppLink(startParam string) string {
+	return fmt.Sprintf("%s?startapp=%s", a.cfg.MiniAppURL, startParam)
+}
+
+// formatWishPrice renders "1500 RUB", or an empty string when the wish has no price.
+func formatWishPrice(w db.Wish) string {
+	if w.Price == nil {
+		return ""
+	}
+
+	price := strconv.FormatFloat(*w.Price, 'f', -1, 64)
+	if w.Currency != nil {
+		price += " " + *w.Currency
+	}
+
+	return price
+}
+
+func formatWishLine(lang string, n int, w db.Wish) string {
+	name := i18n.T(lang, i18n.BotUntitled)
+	if w.Name != nil {
+		name = *w.Name
+	}
+
+	line := fmt.Sprintf("%d. %s", n, name)
+	if price := formatWishPrice(w); price != "" {
+		line += " — " + price
+	}
+
+	return line
+}
+
+func (a *API) buildWishListPage(ctx context.Context, user db.User, page int) (string, *models.InlineKeyboardMarkup, error) {
+	wishes, err := a.storage.GetWishesByUserID(ctx, user.ID, user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(wishes) == 0 {
+		return i18n.T(user.LanguageCode, i18n.BotListEmpty), nil, nil
+	}
+
+	pages := (len(wishes) + botListPageSize - 1) / botListPageSize
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+
+	start := page * botListPageSize
+	end := min(start+botListPageSize, len(wishes))
+
+	lines := []string{i18n.T(user.LanguageCode, i18n.BotListHeader, page+1, pages)}
+	keyboard := make([][]models.InlineKeyboardButton, 0, end-start+1)
+	for i, w := range wishes[start:end] {
+		lines = append(lines, formatWishLine(user.LanguageCode, start+i+1, w))
+		keyboard = append(keyboard, []models.InlineKeyboardButton{
+			{Text: i18n.T(user.LanguageCode, i18n.BotOpenNumbered, start+i+1), URL: a.miniAppLink("w_" + w.ID)},
+		})
+	}
+
+	var nav []models.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, models.InlineKeyboardButton{
+			Text:         "◀️",
+			CallbackData: fmt.Sprintf("%s:%d", callbackListPage, page-1),
+		})
+	}
+	if page < pages-1 {
+		nav = append(nav, models.InlineKeyboardButton{
+			Text:         "▶️",
+			CallbackData: fmt.Sprintf("%s:%d", callbackListPage, page+1),
+		})
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+
+	return strings.Join(lines, "\n"), &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}, nil
+}
+
+func (a *API) commandList(ctx context.Context, user db.User, msg *telegram.SendMessageParams) {
+	text, markup, err := a.buildWishListPage(ctx, user, 0)
+	if err != nil {
+		log.Printf("Failed to list wishes: %v", err)
+		msg.Text = i18n.T(user.LanguageCode, i18n.BotListFailed)
+		return
+	}
+
+	msg.Text = text
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+}
+
+// parseAddArgs reads "<name> [url] [price]" where url and price are recognised from the tail of the arguments.
+func parseAddArgs(args string, defaultCurrency string) (name string, link *string, price *float64, currency *string) {
+	fields := strings.Fields(args)
+
+	if n := len(fields); n > 0 {
+		if m := priceArgRe.FindStringSubmatch(fields[n-1]); m != nil && n > 1 {
+			if p, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64); err == nil {
+				price = &p
+				cur := defaultCurrency
+				if m[2] != "" {
+					cur = strings.ToUpper(m[2])
+				}
+				currency = &cur
+				fields = fields[:n-1]
+			}
+		}
+	}
+
+	if n := len(fields); n > 1 {
+		last := fields[n-1]
+		if strings.HasPrefix(last, "http://") || strings.HasPrefix(last, "https://") {
+			link = &last
+			fields = fields[:n-1]
+		}
+	}
+
+	return strings.Join(fields, " "), link, price, currency
+}
+
+func (a *API) commandAdd(ctx context.Context, user db.User, args string, msg *telegram.SendMessageParams) {
+	defaultCurrency := "USD"
+	if user.LanguageCode == "ru" {
+		defaultCurrency = "RUB"
+	}
+
+	name, link, price, currency := parseAddArgs(args, defaultCurrency)
+	if name == "" {
+		msg.Text = i18n.T(user.LanguageCode, i18n.BotAddUsage)
+		return
+	}
+
+	if len(name) > 200 {
+		msg.Text = i18n.T(user.LanguageCode, i18n.BotAddNameTooLong)
+		return
+	}
+
+	if link != nil {
+		if parsed, err := url.ParseRequestURI(*link); err != nil || parsed.Host == "" {
+			msg.Text = i18n.T(user.LanguageCode, i18n.BotAddInvalidURL)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	// Wishes added from the bot have no photos or categories yet, so they stay
+	// unpublished until the user completes them in the mini app.
+	wish := db.Wish{
+		ID:        nanoid.Must(),
+		UserID:    user.ID,
+		Name:      &name,
+		URL:       link,
+		Price:     price,
+		Currency:  currency,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := a.storage.CreateWish(ctx, wish, nil); err != nil {
+		log.Printf("Failed to create wish from bot: %v", err)
+		msg.Text = i18n.T(user.LanguageCode, i18n.BotAddFailed)
+		return
+	}
+
+	msg.Text = i18n.T(user.LanguageCode, i18n.BotAddSuccess, name)
+	msg.ReplyMarkup = &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(user.LanguageCode, i18n.BotOpen), URL: a.miniAppLink("w_" + wish.ID)}},
+		},
+	}
+}
+
+func (a *API) commandShare(user db.User, msg *telegram.SendMessageParams) {
+	link := a.miniAppLink("u_" + user.ID)
+	msg.Text = i18n.T(user.LanguageCode, i18n.BotShareText, link)
+	msg.ReplyMarkup = &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(user.LanguageCode, i18n.BotShareButton), URL: "https://t.me/share/url?url=" + url.QueryEscape(link)}},
+		},
+	}
+}
+
+func (a *API) commandReserved(ctx context.Context, user db.User, msg *telegram.SendMessageParams) {
+	wishes, err := a.storage.ListReservedWishes(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to list reserved wishes: %v", err)
+		msg.Text = i18n.T(user.LanguageCode, i18n.BotReservedFailed)
+		return
+	}
+
+	if len(wishes) == 0 {
+		msg.Text = i18n.T(user.LanguageCode, i18n.BotReservedEmpty)
+		return
+	}
+
+	lines := []string{i18n.T(user.LanguageCode, i18n.BotReservedHeader)}
+	keyboard := make([][]models.InlineKeyboardButton, 0, len(wishes))
+	for i, w := range wishes {
+		lines = append(lines, formatWishLine(user.LanguageCode, i+1, w))
+		keyboard = append(keyboard, []models.InlineKeyboardButton{
+			{Text: i18n.T(user.LanguageCode, i18n.BotOpenNumbered, i+1), URL: a.miniAppLink("w_" + w.ID)},
+		})
+	}
+
+	msg.Text = strings.Join(lines, "\n")
+	msg.ReplyMarkup = &models.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+func (a *API) handleCallbackQuery(ctx context.Context, query *models.CallbackQuery) {
+	answer := &telegram.AnswerCallbackQueryParams{CallbackQueryID: query.ID}
+	defer func() {
+		if _, err := a.bot.AnswerCallbackQuery(ctx, answer); err != nil {
+			log.Printf("Failed to answer callback query: %v", err)
+		}
+	}()
+
+	user, err := a.storage.GetUserByChatID(query.From.ID)
+	if err != nil {
+		log.Printf("Failed to get user for callback query: %v", err)
+		answer.Text = i18n.T(query.From.LanguageCode, i18n.BotUserFetchFailed)
+		return
+	}
+
+	action, payload, _ := strings.Cut(query.Data, ":")
+
+	switch action {
+	case callbackListPage:
+		if query.Message.Message == nil {
+			return
+		}
+
+		page, _ := strconv.Atoi(payload)
+		text, markup, err := a.buildWishListPage(ctx, user, page)
+		if err != nil {
+			log.Printf("Failed to list wishes: %v", err)
+			answer.Text = i18n.T(user.LanguageCode, i18n.BotListFailed)
+			return
+		}
+
+		edit := &telegram.EditMessageTextParams{
+			ChatID:    query.Message.Message.Chat.ID,
+			MessageID: query.Message.Message.ID,
+			Text:      text,
+		}
+		if markup != nil {
+			edit.ReplyMarkup = markup
+		}
+
+		if _, err := a.bot.EditMessageText(ctx, edit); err != nil {
+			log.Printf("Failed to edit message: %v", err)
+		}
+	default:
+		log.Printf("Unknown callback data: %s", query.Data)
+	}
+}
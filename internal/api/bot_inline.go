@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"log"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"strconv"
+	"strings"
+)
+
+const inlineResultsLimit = 20
+
+// assetURL turns a stored object key into a public URL.
+func (a *API) assetURL(path string) string {
+	return fmt.Sprintf("%s/%s", a.cfg.AssetsURL, path)
+}
+
+func (a *API) handleInlineQuery(ctx context.Context, query *models.InlineQuery) {
+	answer, err := a.buildInlineQueryAnswer(ctx, query)
+	if err != nil {
+		log.Printf("Failed to build inline query answer: %v", err)
+		return
+	}
+
+	if _, err := a.bot.AnswerInlineQuery(ctx, answer); err != nil {
+		log.Printf("Failed to answer inline query: %v", err)
+	}
+}
+
+func (a *API) buildInlineQueryAnswer(ctx context.Context, query *models.InlineQuery) (*telegram.AnswerInlineQueryParams, error) {
+	lang := i18n.DefaultLanguage
+	if query.From != nil {
+		lang = i18n.Normalize(query.From.LanguageCode)
+	}
+
+	answer := &telegram.AnswerInlineQueryParams{
+		InlineQueryID: query.ID,
+		Results:       []models.InlineQueryResult{},
+		CacheTime:     10,
+		IsPersonal:    true,
+		Button: &models.InlineQueryResultsButton{
+			Text:   i18n.T(lang, i18n.BotOpenApp),
+			WebApp: &models.WebAppInfo{URL: a.cfg.WebAppURL},
+		},
+	}
+
+	if query.From == nil {
+		return answer, nil
+	}
+
+	user, err := a.storage.GetUserByChatID(query.From.ID)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		// Unknown users only get the button that opens the mini app and registers them.
+		return answer, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	offset, _ := strconv.Atoi(query.Offset)
+	search := strings.TrimSpace(query.Query)
+
+	// An empty query lists the sender's own wishes; typing something also searches public wishes.
+	wishes, err := a.storage.SearchUserWishes(ctx, user.ID, search, search != "", inlineResultsLimit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range wishes {
+		answer.Results = append(answer.Results, a.wishInlineResult(user.LanguageCode, w))
+	}
+
+	if len(wishes) == inlineResultsLimit {
+		answer.NextOffset = strconv.Itoa(offset + inlineResultsLimit)
+	}
+
+	return answer, nil
+}
+
+func (a *API) wishInlineResult(lang string, w db.Wish) models.InlineQueryResult {
+	title := i18n.T(lang, i18n.BotUntitled)
+	if w.Name != nil {
+		title = *w.Name
+	}
+
+	description := formatWishPrice(w)
+
+	caption := title
+	if description != "" {
+		caption += "\n" + description
+	}
+	if w.URL != nil {
+		caption += "\n" + *w.URL
+	}
+
+	markup := &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(lang, i18n.BotOpenInApp), URL: a.miniAppLink("w_" + w.ID)}},
+		},
+	}
+
+	if len(w.Images) > 0 {
+		img := w.Images[0]
+		for _, candidate := range w.Images {
+			if candidate.Position < img.Position {
+				img = candidate
+			}
+		}
+
+		return &models.InlineQueryResultPhoto{
+			ID:           w.ID,
+			PhotoURL:     a.assetURL(img.URL),
+			ThumbnailURL: a.assetURL(img.URL),
+			PhotoWidth:   img.Width,
+			PhotoHeight:  img.Height,
+			Title:        title,
+			Description:  description,
+			Caption:      caption,
+			ReplyMarkup:  markup,
+		}
+	}
+
+	return &models.InlineQueryResultArticle{
+		ID:          w.ID,
+		Title:       title,
+		Description: description,
+		InputMessageContent: &models.InputTextMessageContent{
+			MessageText: caption,
+		},
+		ReplyMarkup: markup,
+	}
+}
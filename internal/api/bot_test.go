@@ -0,0 +1,158 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func botMessage(chatID int64, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"message": map[string]interface{}{
+			"message_id": 1,
+			"date":       time.Now().Unix(),
+			"text":       text,
+			"from":       map[string]interface{}{"id": chatID, "first_name": "Bot", "username": "bot_tester", "language_code": "en"},
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+		},
+	}
+}
+
+func postWebhook(t *testing.T, e *echo.Echo, update interface{}) {
+	t.Helper()
+
+	body, err := json.Marshal(update)
+	require.NoError(t, err)
+
+	testutils.PerformRequest(t, e, http.MethodPost, "/webhook", string(body), "", http.StatusOK)
+}
+
+func TestBotWebhook_StartAndList(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	const chatID = 8101
+
+	postWebhook(t, ts.Echo, botMessage(chatID, "/start"))
+
+	user, err := ts.Storage.GetUserByChatID(chatID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.ReferralCode)
+
+	sent := ts.Telegram.Calls("sendMessage")
+	require.Len(t, sent, 1)
+	assert.Equal(t, "8101", sent[0].Params["chat_id"])
+	assert.NotEmpty(t, ts.Telegram.Calls("setChatMenuButton"))
+
+	ts.Telegram.Reset()
+	postWebhook(t, ts.Echo, botMessage(chatID, "/add Coffee grinder 120"))
+	postWebhook(t, ts.Echo, botMessage(chatID, "/list"))
+
+	sent = ts.Telegram.Calls("sendMessage")
+	require.Len(t, sent, 2)
+	assert.Contains(t, sent[1].Params["text"], "Coffee grinder — 120 USD")
+
+	var markup models.InlineKeyboardMarkup
+	sent[1].JSON(t, "reply_markup", &markup)
+	require.NotEmpty(t, markup.InlineKeyboard)
+	assert.Contains(t, markup.InlineKeyboard[0][0].URL, "https://t.me/test_bot/app?startapp=w_")
+}
+
+func TestBotWebhook_ListPagination(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	const chatID = 8102
+
+	postWebhook(t, ts.Echo, botMessage(chatID, "/start"))
+	for _, name := range []string{"One", "Two", "Three", "Four", "Five", "Six"} {
+		postWebhook(t, ts.Echo, botMessage(chatID, "/add "+name))
+	}
+	ts.Telegram.Reset()
+
+	postWebhook(t, ts.Echo, map[string]interface{}{
+		"callback_query": map[string]interface{}{
+			"id":            "cb1",
+			"from":          map[string]interface{}{"id": chatID, "first_name": "Bot"},
+			"chat_instance": "ci",
+			"data":          "list:1",
+			"message": map[string]interface{}{
+				"message_id": 42,
+				"date":       time.Now().Unix(),
+				"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+			},
+		},
+	})
+
+	edits := ts.Telegram.Calls("editMessageText")
+	require.Len(t, edits, 1)
+	assert.Equal(t, "42", edits[0].Params["message_id"])
+	assert.Contains(t, edits[0].Params["text"], "6. ")
+	assert.Len(t, ts.Telegram.Calls("answerCallbackQuery"), 1)
+}
+
+func TestBotWebhook_InlineQuery(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	const chatID = 8103
+
+	postWebhook(t, ts.Echo, botMessage(chatID, "/start"))
+	postWebhook(t, ts.Echo, botMessage(chatID, "/add Headphones"))
+
+	user, err := ts.Storage.GetUserByChatID(chatID)
+	require.NoError(t, err)
+	now := time.Now()
+	for _, visibility := range []string{db.WishVisibilityPrivate, db.WishVisibilityLink, db.WishVisibilityFollowers} {
+		name := "Secret " + visibility
+		require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+			ID: "wish_inline_" + visibility, UserID: user.ID, Name: &name, Visibility: visibility, CreatedAt: now, UpdatedAt: now,
+		}, nil))
+	}
+
+	postWebhook(t, ts.Echo, map[string]interface{}{
+		"inline_query": map[string]interface{}{
+			"id":     "iq1",
+			"from":   map[string]interface{}{"id": chatID, "first_name": "Bot"},
+			"query":  "",
+			"offset": "",
+		},
+	})
+
+	answers := ts.Telegram.Calls("answerInlineQuery")
+	require.Len(t, answers, 1)
+
+	var results []map[string]interface{}
+	answers[0].JSON(t, "results", &results)
+	require.Len(t, results, 1, "only wishes anyone can open are shared")
+	assert.Equal(t, "article", results[0]["type"])
+	assert.Equal(t, "Headphones", results[0]["title"])
+}
+
+func TestBotPolling(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	stop := ts.StartPolling(t)
+	defer stop()
+
+	const chatID = 8104
+
+	ts.Telegram.PushUpdate(t, botMessage(chatID, "/start"))
+	ts.Telegram.PushUpdate(t, botMessage(chatID, "/help"))
+
+	ts.Telegram.WaitForCall(t, "sendMessage", 2, 5*time.Second)
+	assert.NotEmpty(t, ts.Telegram.Calls("deleteWebhook"))
+
+	_, err := ts.Storage.GetUserByChatID(chatID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"strings"
+	"time"
+)
+
+// ListWishComments returns the comments on a wish as threads, oldest first.
+func (a *API) ListWishComments(c echo.Context) error {
+	uid, _ := getUserID(c) // guests can read comments on public wishes
+
+	wish, err := a.getCommentedWish(c, uid)
+	if err != nil {
+		return err
+	}
+
+	comments, err := a.storage.ListComments(c.Request().Context(), uid, wish.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list comments").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toCommentThreads(comments, uid))
+}
+
+// toCommentThreads nests replies under their top-level comment. Replies whose
+// thread is not visible are dropped.
+func toCommentThreads(comments []db.Comment, viewerID string) []contract.CommentResponse {
+	threads := make([]contract.CommentResponse, 0)
+	index := make(map[string]int)
+
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			index[comment.ID] = len(threads)
+			threads = append(threads, contract.ToCommentResponse(comment, viewerID))
+			continue
+		}
+
+		if i, ok := index[*comment.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, contract.ToCommentResponse(comment, viewerID))
+		}
+	}
+
+	return threads
+}
+
+func (a *API) CreateWishComment(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req contract.CreateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	wish, err := a.getCommentedWish(c, uid)
+	if err != nil {
+		return err
+	}
+
+	blocked, err := a.storage.IsBlocked(c.Request().Context(), uid, wish.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
+	if blocked {
+		return echo.NewHTTPError(http.StatusForbidden, "cannot comment on this wish")
+	}
+
+	comment := db.Comment{
+		ID:         nanoid.Must(),
+		WishID:     wish.ID,
+		UserID:     uid,
+		Body:       strings.TrimSpace(req.Body),
+		IsQuestion: req.IsQuestion,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if req.ParentID != nil {
+		parent, err := a.storage.GetComment(c.Request().Context(), *req.ParentID)
+		if err != nil && errors.Is(err, db.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "comment not found").WithInternal(err)
+		} else if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "could not create comment").WithInternal(err)
+		}
+
+		if parent.WishID != wish.ID || (parent.IsQuestion && uid != wish.UserID && uid != parent.ThreadUserID) {
+			return echo.NewHTTPError(http.StatusNotFound, "comment not found")
+		}
+
+		// Threads are one level deep: a reply to a reply joins the thread.
+		root := parent.ID
+		if parent.ParentID != nil {
+			root = *parent.ParentID
+		}
+		comment.ParentID = &root
+		comment.IsQuestion = parent.IsQuestion
+	} else if comment.IsQuestion && uid == wish.UserID {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot ask a question on own wish")
+	}
+
+	if err := a.storage.CreateComment(c.Request().Context(), comment); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create comment").WithInternal(err)
+	}
+
+	created, err := a.storage.GetComment(c.Request().Context(), comment.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create comment").WithInternal(err)
+	}
+
+	a.notify(c.Request().Context(), notificationEvent{
+		Type:       db.NotificationTypeComment,
+		ActorID:    uid,
+		WishID:     &wish.ID,
+		Recipients: []string{wish.UserID, created.ThreadUserID},
+	})
+
+	return c.JSON(http.StatusCreated, contract.ToCommentResponse(created, uid))
+}
+
+// DeleteWishComment lets authors delete their comments and wish owners
+// moderate every comment on their wishes. Replies go with the comment.
+func (a *API) DeleteWishComment(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	comment, err := a.storage.GetComment(c.Request().Context(), c.Param("comment_id"))
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "comment not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not delete comment").WithInternal(err)
+	}
+
+	if comment.WishID != c.Param("id") {
+		return echo.NewHTTPError(http.StatusNotFound, "comment not found")
+	}
+
+	if comment.UserID != uid && comment.WishOwnerID != uid {
+		return echo.NewHTTPError(http.StatusForbidden, "cannot delete this comment")
+	}
+
+	err = a.storage.DeleteComment(c.Request().Context(), comment.ID)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "comment not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not delete comment").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+// getCommentedWish loads the wish from the route if viewerID may see it.
+func (a *API) getCommentedWish(c echo.Context, viewerID string) (db.Wish, error) {
+	wish, err := a.storage.GetWishByID(c.Request().Context(), viewerID, c.Param("id"))
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return db.Wish{}, echo.NewHTTPError(http.StatusNotFound, "wish not found").WithInternal(err)
+	} else if err != nil {
+		return db.Wish{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot get wish").WithInternal(err)
+	}
+
+	return wish, nil
+}
@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishComments(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 8801, "comment_owner", "Owner")
+	require.NoError(t, err)
+	alice, err := testutils.AuthHelper(t, ts.Echo, 8802, "comment_alice", "Alice")
+	require.NoError(t, err)
+	bob, err := testutils.AuthHelper(t, ts.Echo, 8803, "comment_bob", "Bob")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name := "Running shoes"
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_comments", Name: "Comments Cat", ImageURL: "url"}))
+	testutils.CreateListedWish(t, ts.Storage, db.Wish{
+		ID: "wish_comments", UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, "cat_comments")
+
+	const path = "/v1/wishes/wish_comments/comments"
+	post := func(token, body string, status int) contract.CommentResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, path, body, token, status)
+		if status != http.StatusCreated {
+			return contract.CommentResponse{}
+		}
+		return testutils.ParseResponse[contract.CommentResponse](t, rec)
+	}
+	list := func(token string) []contract.CommentResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, path, "", token, http.StatusOK)
+		return testutils.ParseResponse[[]contract.CommentResponse](t, rec)
+	}
+
+	post(alice.Token, `{"body":"   "}`, http.StatusBadRequest)
+	post(owner.Token, `{"body":"Anyone?","is_question":true}`, http.StatusBadRequest)
+
+	comment := post(alice.Token, `{"body":"Great pick!"}`, http.StatusCreated)
+	assert.Equal(t, alice.User.ID, comment.Author.ID)
+	assert.True(t, comment.CanDelete)
+
+	question := post(bob.Token, `{"body":"Which size?","is_question":true}`, http.StatusCreated)
+	answer := post(owner.Token, `{"body":"42","parent_id":"`+question.ID+`"}`, http.StatusCreated)
+	assert.True(t, answer.IsQuestion, "replies to a question stay private")
+	post(alice.Token, `{"body":"Sneaky","parent_id":"`+question.ID+`"}`, http.StatusNotFound)
+
+	reply := post(owner.Token, `{"body":"Thanks!","parent_id":"`+comment.ID+`"}`, http.StatusCreated)
+	post(alice.Token, `{"body":"You're welcome","parent_id":"`+reply.ID+`"}`, http.StatusCreated)
+
+	t.Run("Questions are only visible to the owner and the asker", func(t *testing.T) {
+		threads := list(owner.Token)
+		require.Len(t, threads, 2)
+		assert.Len(t, threads[0].Replies, 2, "a reply to a reply joins the thread")
+		require.Len(t, threads[1].Replies, 1)
+		assert.Equal(t, answer.ID, threads[1].Replies[0].ID)
+		assert.True(t, threads[1].CanDelete, "the owner moderates every comment")
+
+		asker := list(bob.Token)
+		require.Len(t, asker, 2)
+		assert.Equal(t, question.ID, asker[1].ID)
+		assert.False(t, asker[0].CanDelete)
+
+		for _, token := range []string{alice.Token, ""} {
+			threads := list(token)
+			require.Len(t, threads, 1)
+			assert.Equal(t, comment.ID, threads[0].ID)
+		}
+	})
+
+	t.Run("Feed counts public comments", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", "", http.StatusOK)
+		feed := testutils.ParseResponse[[]contract.FeedItem](t, rec)
+		require.Len(t, feed, 1)
+		assert.Equal(t, 3, feed[0].CommentCount)
+
+		// Muting alice hides her comment along with the owner's reply to it.
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/mute", `{"user_id":"`+alice.User.ID+`"}`, bob.Token, http.StatusOK)
+		defer testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/unmute", `{"user_id":"`+alice.User.ID+`"}`, bob.Token, http.StatusOK)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", bob.Token, http.StatusOK)
+		feed = testutils.ParseResponse[[]contract.FeedItem](t, rec)
+		require.Len(t, feed, 1)
+		assert.Zero(t, feed[0].CommentCount)
+		assert.Len(t, list(bob.Token), 1, "only bob's own question is left")
+	})
+
+	t.Run("Owner is notified", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", owner.Token, http.StatusOK)
+		inbox := testutils.ParseResponse[contract.NotificationsResponse](t, rec)
+
+		var actors []string
+		for _, n := range inbox.Notifications {
+			assert.Equal(t, db.NotificationTypeComment, n.Type)
+			actors = append(actors, n.Actor.ID)
+		}
+		assert.ElementsMatch(t, []string{alice.User.ID, bob.User.ID}, actors)
+	})
+
+	t.Run("Moderation", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, path+"/"+comment.ID, "", bob.Token, http.StatusForbidden)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/other/comments/"+comment.ID, "", owner.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, path+"/"+comment.ID, "", owner.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, path+"/"+question.ID, "", bob.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, path+"/"+question.ID, "", bob.Token, http.StatusNotFound)
+
+		assert.Empty(t, list(owner.Token), "replies go with their comment")
+	})
+
+	t.Run("Hidden wishes have no comments", func(t *testing.T) {
+		require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+			ID: "wish_comments_private", UserID: owner.User.ID, Name: &name, Visibility: db.WishVisibilityPrivate, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+		}, nil))
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_comments_private/comments", "", alice.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_comments_private/comments", `{"body":"hi"}`, alice.Token, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"log"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DigestCheckInterval is how often the digest job looks for users whose
+	// local send window has opened.
+	DigestCheckInterval = time.Hour
+
+	// The digest goes out on Sunday between 10:00 and 20:00 in the user's
+	// timezone, or UTC when they have not set one.
+	digestWeekday   = time.Sunday
+	digestStartHour = 10
+	digestEndHour   = 20
+
+	digestPeriod      = 7 * 24 * time.Hour
+	digestItemLimit   = 5
+	digestButtonsRow  = 3
+	digestButtonLimit = 6
+)
+
+// RunDigests sends the weekly digest every interval until ctx is cancelled.
+func (a *API) RunDigests(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := a.SendDigests(ctx, now); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Failed to send digests: %v", err)
+			}
+		}
+	}
+}
+
+// SendDigests sends the weekly digest to every user whose send window is open
+// and who has not received this week's digest yet. Each digest is recorded
+// before it is sent, so rerunning the job after a crash never duplicates it.
+func (a *API) SendDigests(ctx context.Context, now time.Time) error {
+	if a.bot == nil {
+		return errors.New("bot is not initialized")
+	}
+
+	// Anyone who got a digest within the last six days is done for this week
+	// in every timezone.
+	recipients, err := a.storage.ListDigestRecipients(ctx, now.Add(-6*24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+
+	for _, r := range recipients {
+		local := now.In(userLocation(r.Timezone))
+		if local.Weekday() != digestWeekday || local.Hour() < digestStartHour || local.Hour() >= digestEndHour {
+			continue
+		}
+
+		year, week := local.ISOWeek()
+		period := fmt.Sprintf("%d-W%02d", year, week)
+
+		msg, err := a.digestMessage(ctx, r, now.Add(-digestPeriod))
+		if err != nil {
+			log.Printf("Failed to build digest for %s: %v", r.ID, err)
+			continue
+		}
+
+		first, err := a.storage.MarkDigestSent(ctx, r.ID, period, now)
+		if err != nil {
+			return fmt.Errorf("failed to record digest: %w", err)
+		}
+
+		// Quiet weeks are recorded too so they are not recomputed every hour.
+		if !first || msg == nil {
+			continue
+		}
+
+		if err := a.sendLimiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		if _, err := a.bot.SendMessage(ctx, msg); err != nil {
+			log.Printf("Failed to send digest to chat %d: %v", r.ChatID, err)
+		}
+	}
+
+	return nil
+}
+
+func userLocation(tz *string) *time.Location {
+	if tz == nil || *tz == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// digestMessage composes the digest, or returns nil when nothing happened.
+func (a *API) digestMessage(ctx context.Context, r db.DigestRecipient, since time.Time) (*telegram.SendMessageParams, error) {
+	lang := r.LanguageCode
+
+	newWishes, total, err := a.storage.ListNewFollowedWishes(ctx, r.ID, since, digestItemLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	drops, err := a.storage.ListFollowedPriceDrops(ctx, r.ID, since, digestItemLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	saves, err := a.storage.ListNewSaves(ctx, r.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(newWishes) == 0 && len(drops) == 0 && len(saves) == 0 {
+		return nil, nil
+	}
+
+	lines := []string{i18n.T(lang, i18n.DigestHeader)}
+	var buttons []models.InlineKeyboardButton
+	n := 0
+
+	addWish := func(w db.DigestWish, price string) {
+		n++
+
+		name := i18n.T(lang, i18n.BotUntitled)
+		if w.Name != nil {
+			name = *w.Name
+		}
+
+		line := fmt.Sprintf("%d. %s: %s", n, displayName(w.Owner), name)
+		if price != "" {
+			line += " — " + price
+		}
+		lines = append(lines, line)
+
+		if n <= digestButtonLimit {
+			buttons = append(buttons, models.InlineKeyboardButton{
+				Text: i18n.T(lang, i18n.BotOpenNumbered, n),
+				URL:  a.miniAppLink(startParamWish + "_" + w.ID),
+			})
+		}
+	}
+
+	if len(newWishes) > 0 {
+		lines = append(lines, "", i18n.T(lang, i18n.DigestNewWishes))
+		for _, w := range newWishes {
+			addWish(w, formatWishPrice(db.Wish{Price: w.Price, Currency: w.Currency}))
+		}
+		if total > len(newWishes) {
+			lines = append(lines, i18n.T(lang, i18n.DigestMoreWishes, total-len(newWishes)))
+		}
+	}
+
+	if len(drops) > 0 {
+		lines = append(lines, "", i18n.T(lang, i18n.DigestPriceDrops))
+		for _, w := range drops {
+			price := formatWishPrice(db.Wish{Price: w.Price, Currency: w.Currency})
+			if w.OldPrice != nil {
+				price = strconv.FormatFloat(*w.OldPrice, 'f', -1, 64) + " → " + price
+			}
+			addWish(w, price)
+		}
+	}
+
+	if len(saves) > 0 {
+		lines = append(lines, "", i18n.T(lang, i18n.DigestSaves))
+		for _, s := range saves {
+			name := i18n.T(lang, i18n.BotUntitled)
+			if s.WishName != nil {
+				name = *s.WishName
+			}
+			lines = append(lines, "• "+i18n.T(lang, i18n.DigestSavesLine, name, s.Saves))
+		}
+	}
+
+	var keyboard [][]models.InlineKeyboardButton
+	for start := 0; start < len(buttons); start += digestButtonsRow {
+		keyboard = append(keyboard, buttons[start:min(start+digestButtonsRow, len(buttons))])
+	}
+	keyboard = append(keyboard, []models.InlineKeyboardButton{
+		{Text: i18n.T(lang, i18n.DigestOpenFeed), URL: a.cfg.MiniAppURL},
+	})
+
+	return &telegram.SendMessageParams{
+		ChatID:      r.ChatID,
+		Text:        strings.Join(lines, "\n"),
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}, nil
+}
@@ -0,0 +1,109 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDigests(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	readerAuth, err := testutils.AuthHelper(t, ts.Echo, 7301, "digest_reader", "Reader")
+	require.NoError(t, err)
+	friendAuth, err := testutils.AuthHelper(t, ts.Echo, 7302, "digest_friend", "Friend")
+	require.NoError(t, err)
+	saverAuth, err := testutils.AuthHelper(t, ts.Echo, 7303, "digest_saver", "Saver")
+	require.NoError(t, err)
+	mutedAuth, err := testutils.AuthHelper(t, ts.Echo, 7304, "digest_muted", "Muted")
+	require.NoError(t, err)
+
+	catID := "cat_digest"
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: catID, Name: "Digest Cat", ImageURL: "url"}))
+
+	settings := fmt.Sprintf(`{"interests":["%s"],"email":"reader@example.com","timezone":"Asia/Tokyo"}`, catID)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings", settings, readerAuth.Token, http.StatusOK)
+
+	invalid := fmt.Sprintf(`{"interests":["%s"],"email":"reader@example.com","timezone":"Mars/Olympus"}`, catID)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings", invalid, readerAuth.Token, http.StatusBadRequest)
+
+	require.NoError(t, ts.Storage.FollowUser(context.Background(), readerAuth.User.ID, friendAuth.User.ID))
+	require.NoError(t, ts.Storage.FollowUser(context.Background(), readerAuth.User.ID, mutedAuth.User.ID))
+	require.NoError(t, ts.Storage.MuteUser(context.Background(), readerAuth.User.ID, mutedAuth.User.ID))
+
+	now := time.Now().UTC()
+	createWish := func(id, owner, name string, price float64) db.Wish {
+		currency := "USD"
+		w := db.Wish{
+			ID: id, UserID: owner, Name: &name, Price: &price, Currency: &currency,
+			PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+		}
+		testutils.CreateListedWish(t, ts.Storage, w, catID)
+		return w
+	}
+
+	createWish("wish_digest_new", friendAuth.User.ID, "Board Game", 40)
+	drop := createWish("wish_digest_drop", friendAuth.User.ID, "Headphones", 100)
+	own := createWish("wish_digest_own", readerAuth.User.ID, "Kayak", 900)
+
+	mutedDrop := createWish("wish_digest_muted", mutedAuth.User.ID, "Muted Lamp", 50)
+
+	draftName := "Draft Tent"
+	require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+		ID: "wish_digest_draft", UserID: friendAuth.User.ID, Name: &draftName, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{catID}))
+
+	cheaper := 80.0
+	drop.Price = &cheaper
+	require.NoError(t, ts.Storage.UpdateWish(context.Background(), drop, []string{catID}))
+	mutedCheaper := 30.0
+	mutedDrop.Price = &mutedCheaper
+	require.NoError(t, ts.Storage.UpdateWish(context.Background(), mutedDrop, []string{catID}))
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/"+own.ID+"/bookmark", "", saverAuth.Token, http.StatusOK)
+
+	// Next Sunday 11:00 in Tokyo, which is still Sunday 02:00 in UTC and so
+	// outside the default window for everyone else.
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	local := now.In(tokyo)
+	sunday := time.Date(local.Year(), local.Month(), local.Day(), 11, 0, 0, 0, tokyo)
+	for sunday.Weekday() != time.Sunday || !sunday.After(now) {
+		sunday = sunday.AddDate(0, 0, 1)
+	}
+
+	t.Run("Outside send window", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendDigests(context.Background(), sunday.Add(-24*time.Hour)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"))
+	})
+
+	t.Run("Sent in local window", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendDigests(context.Background(), sunday))
+
+		sent := ts.Telegram.Calls("sendMessage")
+		require.Len(t, sent, 1)
+		assert.Equal(t, "7301", sent[0].Params["chat_id"])
+		assert.Contains(t, sent[0].Params["text"], "Board Game")
+		assert.Contains(t, sent[0].Params["text"], "100 → 80 USD")
+		assert.Contains(t, sent[0].Params["text"], "Kayak — 1")
+		assert.Contains(t, sent[0].Params["reply_markup"], "startapp=w_wish_digest_new")
+		assert.NotContains(t, sent[0].Params["text"], "Muted Lamp", "muted authors are left out")
+		assert.NotContains(t, sent[0].Params["text"], "Draft Tent", "wishes without a photo are left out")
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendDigests(context.Background(), sunday.Add(time.Hour)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"))
+	})
+}
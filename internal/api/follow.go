@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"strconv"
+)
+
+// ListFollowers returns a page of the users following a profile.
+func (a *API) ListFollowers(c echo.Context) error {
+	return a.listFollows(c, a.storage.ListFollowers)
+}
+
+// ListFollowing returns a page of the users a profile follows.
+func (a *API) ListFollowing(c echo.Context) error {
+	return a.listFollows(c, a.storage.ListFollowing)
+}
+
+type listFollowsFunc func(ctx context.Context, viewerID, uid string, limit, offset int) ([]db.User, int, error)
+
+func (a *API) listFollows(c echo.Context, list listFollowsFunc) error {
+	uid, _ := getUserID(c) // guests can see lists of public profiles
+
+	owner, err := a.getProfileListOwner(c, uid)
+	if err != nil {
+		return err
+	}
+
+	limit, offset := pageParams(c)
+
+	users, total, err := list(c.Request().Context(), uid, owner.ID, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list follows").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, contract.FollowListResponse{
+		Users: toFollowListUsers(users),
+		Total: total,
+	})
+}
+
+func toFollowListUsers(users []db.User) []contract.FollowListUser {
+	resp := make([]contract.FollowListUser, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, contract.FollowListUser{
+			ShortUserProfile: contract.ToShortUserProfile(u),
+			IsFollowing:      u.IsFollowing,
+		})
+	}
+
+	return resp
+}
+
+// getProfileListOwner loads the profile whose follow lists or received gifts
+// are requested. Private accounts show them to their followers only.
+func (a *API) getProfileListOwner(c echo.Context, viewerID string) (db.User, error) {
+	owner, err := a.storage.GetUserByID(c.Param("id"))
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return db.User{}, echo.NewHTTPError(http.StatusNotFound, "user not found").WithInternal(err)
+	} else if err != nil {
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	leaving, err := a.isPendingDeletion(c.Request().Context(), owner, viewerID)
+	if err != nil {
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	blockedBy, err := a.storage.HasBlocked(c.Request().Context(), owner.ID, viewerID)
+	if err != nil {
+		return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
+	if leaving || blockedBy {
+		return db.User{}, echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if owner.IsPrivate && owner.ID != viewerID {
+		isFollowing, err := a.storage.IsFollowing(c.Request().Context(), viewerID, owner.ID)
+		if err != nil {
+			return db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot check following status").WithInternal(err)
+		}
+		if !isFollowing {
+			return db.User{}, echo.NewHTTPError(http.StatusForbidden, "account is private")
+		}
+	}
+
+	return owner, nil
+}
+
+// pageParams reads the page and limit query parameters, defaulting to the
+// first page of 20 and allowing at most 100 per page.
+func pageParams(c echo.Context) (limit, offset int) {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err = strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return limit, (page - 1) * limit
+}
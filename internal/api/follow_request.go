@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sacred/internal/db"
+)
+
+// Values of "status" in the follow response.
+const (
+	followStatusFollowing = "following"
+	followStatusRequested = "requested"
+)
+
+// requestFollow asks a private account for approval instead of following it
+// right away.
+func (a *API) requestFollow(c echo.Context, uid, targetID string) error {
+	err := a.storage.CreateFollowRequest(c.Request().Context(), uid, targetID)
+	if err != nil && errors.Is(err, db.ErrAlreadyExists) {
+		return echo.NewHTTPError(http.StatusConflict, "follow request already sent")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not follow user").WithInternal(err)
+	}
+
+	a.notify(c.Request().Context(), notificationEvent{
+		Type:       db.NotificationTypeFollowRequest,
+		ActorID:    uid,
+		Recipients: []string{targetID},
+	})
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK", "status": followStatusRequested})
+}
+
+func (a *API) ListFollowRequests(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	users, err := a.storage.ListFollowRequests(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list follow requests").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toShortUserProfiles(users))
+}
+
+func (a *API) AcceptFollowRequest(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	requesterID := c.Param("id")
+
+	err = a.storage.AcceptFollowRequest(c.Request().Context(), uid, requesterID)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "follow request not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not accept follow request").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) DeclineFollowRequest(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	requesterID := c.Param("id")
+
+	err = a.storage.DeleteFollowRequest(c.Request().Context(), requesterID, uid)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "follow request not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not decline follow request").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
@@ -0,0 +1,110 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateProfiles(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 8001, "private_owner", "Owner")
+	require.NoError(t, err)
+	fan, err := testutils.AuthHelper(t, ts.Echo, 8002, "private_fan", "Fan")
+	require.NoError(t, err)
+	stranger, err := testutils.AuthHelper(t, ts.Echo, 8003, "private_stranger", "Stranger")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name := "Private Telescope"
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_private", Name: "Private Cat", ImageURL: "url"}))
+	testutils.CreateListedWish(t, ts.Storage, db.Wish{
+		ID: "wish_private", UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, "cat_private")
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings",
+		`{"interests":["cat_private"],"email":"owner@example.com","is_private":true}`, owner.Token, http.StatusOK)
+
+	feedHas := func(token string) bool {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", token, http.StatusOK)
+		for _, item := range testutils.ParseResponse[[]contract.FeedItem](t, rec) {
+			if item.ID == "wish_private" {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("Hidden from non-followers", func(t *testing.T) {
+		assert.False(t, feedHas(fan.Token))
+		assert.False(t, feedHas(""))
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_private", "", fan.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_private", "", owner.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/wish_private", "", "", http.StatusNotFound)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+owner.User.ID, "", fan.Token, http.StatusOK)
+		profile := testutils.ParseResponse[contract.UserProfileResponse](t, rec)
+		assert.True(t, profile.IsPrivate)
+		assert.Empty(t, profile.SavedItems)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles", "", fan.Token, http.StatusOK)
+		for _, p := range testutils.ParseResponse[[]contract.UserProfileResponse](t, rec) {
+			if p.ID == owner.User.ID {
+				assert.Empty(t, p.SavedItems)
+			}
+		}
+	})
+
+	t.Run("Follow request", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, fan.Token, http.StatusOK)
+		assert.Contains(t, rec.Body.String(), `"status":"requested"`)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, fan.Token, http.StatusConflict)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, stranger.Token, http.StatusOK)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+owner.User.ID, "", fan.Token, http.StatusOK)
+		assert.True(t, testutils.ParseResponse[contract.UserProfileResponse](t, rec).IsRequested)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/follow-requests", "", owner.Token, http.StatusOK)
+		assert.Len(t, testutils.ParseResponse[[]contract.ShortUserProfile](t, rec), 2)
+	})
+
+	t.Run("Accept", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/follow-requests/"+fan.User.ID+"/accept", "", fan.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/follow-requests/"+fan.User.ID+"/accept", "", owner.Token, http.StatusOK)
+
+		assert.True(t, feedHas(fan.Token))
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_private", "", fan.Token, http.StatusOK)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+owner.User.ID, "", fan.Token, http.StatusOK)
+		assert.Len(t, testutils.ParseResponse[contract.UserProfileResponse](t, rec).SavedItems, 1)
+	})
+
+	t.Run("Decline", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/follow-requests/"+stranger.User.ID+"/decline", "", owner.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/follow-requests/"+stranger.User.ID+"/decline", "", owner.Token, http.StatusNotFound)
+
+		assert.False(t, feedHas(stranger.Token))
+	})
+
+	t.Run("Going public accepts pending requests", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, stranger.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings",
+			`{"interests":["cat_private"],"email":"owner@example.com","is_private":false}`, owner.Token, http.StatusOK)
+
+		following, err := ts.Storage.IsFollowing(ctx, stranger.User.ID, owner.User.ID)
+		require.NoError(t, err)
+		assert.True(t, following)
+		assert.True(t, feedHas(""))
+	})
+}
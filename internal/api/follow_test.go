@@ -0,0 +1,105 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowLists(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	star, err := testutils.AuthHelper(t, ts.Echo, 8201, "follow_star", "Star")
+	require.NoError(t, err)
+	fan1, err := testutils.AuthHelper(t, ts.Echo, 8202, "follow_fan1", "Fan 1")
+	require.NoError(t, err)
+	fan2, err := testutils.AuthHelper(t, ts.Echo, 8203, "follow_fan2", "Fan 2")
+	require.NoError(t, err)
+	loner, err := testutils.AuthHelper(t, ts.Echo, 8204, "follow_loner", "Loner")
+	require.NoError(t, err)
+
+	follow := func(token, id string) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+id+`"}`, token, http.StatusOK)
+	}
+	follow(fan1.Token, star.User.ID)
+	follow(fan2.Token, star.User.ID)
+	follow(star.Token, fan1.User.ID)
+	follow(loner.Token, fan2.User.ID)
+
+	list := func(path, token string, status int) contract.FollowListResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, path, "", token, status)
+		if status != http.StatusOK {
+			return contract.FollowListResponse{}
+		}
+		return testutils.ParseResponse[contract.FollowListResponse](t, rec)
+	}
+
+	t.Run("Counts on profile", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+star.User.ID, "", "", http.StatusOK)
+		profile := testutils.ParseResponse[contract.UserProfileResponse](t, rec)
+		assert.Equal(t, 2, profile.Followers)
+		assert.Equal(t, 1, profile.Following)
+	})
+
+	t.Run("Followers", func(t *testing.T) {
+		resp := list("/v1/profiles/"+star.User.ID+"/followers", loner.Token, http.StatusOK)
+		assert.Equal(t, 2, resp.Total)
+		require.Len(t, resp.Users, 2)
+
+		// Most recent first; the viewer follows fan2 only.
+		assert.Equal(t, fan2.User.ID, resp.Users[0].ID)
+		assert.True(t, resp.Users[0].IsFollowing)
+		assert.Equal(t, fan1.User.ID, resp.Users[1].ID)
+		assert.False(t, resp.Users[1].IsFollowing)
+		assert.Equal(t, 1, resp.Users[1].Followers)
+	})
+
+	t.Run("Following", func(t *testing.T) {
+		resp := list("/v1/profiles/"+star.User.ID+"/following", "", http.StatusOK)
+		assert.Equal(t, 1, resp.Total)
+		require.Len(t, resp.Users, 1)
+		assert.Equal(t, fan1.User.ID, resp.Users[0].ID)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		resp := list("/v1/profiles/"+star.User.ID+"/followers?limit=1&page=2", "", http.StatusOK)
+		assert.Equal(t, 2, resp.Total)
+		require.Len(t, resp.Users, 1)
+		assert.Equal(t, fan1.User.ID, resp.Users[0].ID)
+	})
+
+	t.Run("Counts match the lists for hidden users", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/mute", `{"user_id":"`+fan1.User.ID+`"}`, loner.Token, http.StatusOK)
+		defer testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/unmute", `{"user_id":"`+fan1.User.ID+`"}`, loner.Token, http.StatusOK)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+star.User.ID, "", loner.Token, http.StatusOK)
+		profile := testutils.ParseResponse[contract.UserProfileResponse](t, rec)
+		assert.Equal(t, 1, profile.Followers)
+		assert.Equal(t, 0, profile.Following)
+
+		assert.Equal(t, profile.Followers, list("/v1/profiles/"+star.User.ID+"/followers", loner.Token, http.StatusOK).Total)
+		assert.Equal(t, profile.Following, list("/v1/profiles/"+star.User.ID+"/following", loner.Token, http.StatusOK).Total)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		list("/v1/profiles/missing/followers", "", http.StatusNotFound)
+	})
+
+	t.Run("Private account", func(t *testing.T) {
+		require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: "cat_follow", Name: "Follow Cat", ImageURL: "url"}))
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings",
+			`{"interests":["cat_follow"],"email":"star@example.com","is_private":true}`, star.Token, http.StatusOK)
+
+		list("/v1/profiles/"+star.User.ID+"/followers", loner.Token, http.StatusForbidden)
+		list("/v1/profiles/"+star.User.ID+"/following", "", http.StatusForbidden)
+		assert.Equal(t, 2, list("/v1/profiles/"+star.User.ID+"/followers", fan1.Token, http.StatusOK).Total)
+		assert.Equal(t, 2, list("/v1/profiles/"+star.User.ID+"/followers", star.Token, http.StatusOK).Total)
+	})
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"errors"
+	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/labstack/echo/v4"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"log"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"strings"
+	"time"
+)
+
+// FulfillWish records that the owner received their wish. The gift is
+// credited to whoever reserved it, and they get the optional thank-you note
+// in Telegram.
+func (a *API) FulfillWish(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req contract.FulfillWishRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	fulfillment := db.Fulfillment{
+		ID:        nanoid.Must(),
+		WishID:    c.Param("id"),
+		Quantity:  req.Quantity,
+		CreatedAt: time.Now().UTC(),
+	}
+	if req.ThankYouNote != nil {
+		if note := strings.TrimSpace(*req.ThankYouNote); note != "" {
+			fulfillment.ThankYouNote = &note
+		}
+	}
+
+	fulfillment, err = a.storage.FulfillWish(c.Request().Context(), uid, fulfillment)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wish not found").WithInternal(err)
+	} else if err != nil && errors.Is(err, db.ErrAlreadyExists) {
+		return echo.NewHTTPError(http.StatusConflict, "wish is already fulfilled").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not fulfill wish").WithInternal(err)
+	}
+
+	wish, err := a.storage.GetWishByID(c.Request().Context(), uid, fulfillment.WishID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not fulfill wish").WithInternal(err)
+	}
+
+	if fulfillment.GifterID != nil && fulfillment.ThankYouNote != nil {
+		a.sendThankYouNote(c.Request().Context(), uid, *fulfillment.GifterID, wish, *fulfillment.ThankYouNote)
+	}
+
+	return c.JSON(http.StatusOK, wish)
+}
+
+// UnfulfillWish undoes the latest recorded gift, e.g. one marked by mistake.
+func (a *API) UnfulfillWish(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	wishID := c.Param("id")
+
+	err = a.storage.UnfulfillWish(c.Request().Context(), uid, wishID)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wish not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not unfulfill wish").WithInternal(err)
+	}
+
+	wish, err := a.storage.GetWishByID(c.Request().Context(), uid, wishID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not unfulfill wish").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, wish)
+}
+
+// sendThankYouNote passes the owner's note on to the gifter. Failures are
+// logged; the gift is recorded either way.
+func (a *API) sendThankYouNote(ctx context.Context, ownerID, gifterID string, wish db.Wish, note string) {
+	if a.bot == nil {
+		return
+	}
+
+	owner, err := a.storage.GetUserByID(ownerID)
+	if err != nil {
+		log.Printf("Failed to get user %s for thank-you note: %v", ownerID, err)
+		return
+	}
+
+	gifter, err := a.storage.GetUserByID(gifterID)
+	if err != nil {
+		log.Printf("Failed to get gifter %s for thank-you note: %v", gifterID, err)
+		return
+	}
+
+	lang := gifter.LanguageCode
+	name := i18n.T(lang, i18n.BotUntitled)
+	if wish.Name != nil {
+		name = *wish.Name
+	}
+
+	msg := &telegram.SendMessageParams{
+		ChatID: gifter.ChatID,
+		Text:   i18n.T(lang, i18n.ThankYouNote, displayName(owner), name, note),
+		ReplyMarkup: &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{
+				{{Text: i18n.T(lang, i18n.BotOpen), URL: a.miniAppLink(startParamWish + "_" + wish.ID)}},
+			},
+		},
+	}
+
+	if _, err := a.bot.SendMessage(ctx, msg); err != nil {
+		log.Printf("Failed to send thank-you note to chat %d: %v", gifter.ChatID, err)
+	}
+}
+
+// ListReceivedGifts returns a page of the gifts a profile received.
+func (a *API) ListReceivedGifts(c echo.Context) error {
+	uid, _ := getUserID(c) // guests can see gifts of public profiles
+
+	owner, err := a.getProfileListOwner(c, uid)
+	if err != nil {
+		return err
+	}
+
+	limit, offset := pageParams(c)
+
+	gifts, total, err := a.storage.ListReceivedGifts(c.Request().Context(), uid, owner.ID, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list received gifts").WithInternal(err)
+	}
+
+	resp := contract.ReceivedGiftsResponse{
+		Gifts: make([]contract.ReceivedGiftResponse, 0, len(gifts)),
+		Total: total,
+	}
+	for _, g := range gifts {
+		resp.Gifts = append(resp.Gifts, contract.ToReceivedGiftResponse(g))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
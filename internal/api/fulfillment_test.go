@@ -0,0 +1,124 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFulfillWish(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 9101, "gift_owner", "Owner")
+	require.NoError(t, err)
+	gifter, err := testutils.AuthHelper(t, ts.Echo, 9102, "gift_gifter", "Gifter")
+	require.NoError(t, err)
+	stranger, err := testutils.AuthHelper(t, ts.Echo, 9103, "gift_stranger", "Stranger")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name := "Wine glasses"
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_gift", UserID: owner.User.ID, Name: &name, Quantity: 2, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, nil))
+
+	const path = "/v1/wishes/wish_gift/fulfill"
+	fulfill := func(token, body string, status int) db.Wish {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, path, body, token, status)
+		if status != http.StatusOK {
+			return db.Wish{}
+		}
+		return testutils.ParseResponse[db.Wish](t, rec)
+	}
+	received := func(token string) contract.ReceivedGiftsResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+owner.User.ID+"/received", "", token, http.StatusOK)
+		return testutils.ParseResponse[contract.ReceivedGiftsResponse](t, rec)
+	}
+
+	fulfill(gifter.Token, `{}`, http.StatusNotFound)
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_gift/reserve", "", gifter.Token, http.StatusOK)
+	ts.Telegram.Reset()
+
+	t.Run("Gift is credited to the reservation", func(t *testing.T) {
+		wish := fulfill(owner.Token, `{"thank_you_note":"  Thank you, they are lovely!  "}`, http.StatusOK)
+		assert.Equal(t, 1, wish.Received)
+		assert.False(t, wish.IsFulfilled, "one of two glasses is still missing")
+		assert.Nil(t, wish.ReservedBy)
+
+		sent := ts.Telegram.Calls("sendMessage")
+		require.Len(t, sent, 1)
+		assert.Equal(t, "9102", sent[0].Params["chat_id"])
+		assert.Contains(t, sent[0].Params["text"], "Thank you, they are lovely!")
+		assert.Contains(t, sent[0].Params["text"], name)
+	})
+
+	t.Run("Wish is fulfilled once everything arrived", func(t *testing.T) {
+		ts.Telegram.Reset()
+		wish := fulfill(owner.Token, `{"quantity":5,"thank_you_note":"Nobody to thank"}`, http.StatusOK)
+		assert.Equal(t, 2, wish.Received, "quantity is capped at what was missing")
+		assert.True(t, wish.IsFulfilled)
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"), "there was no reservation to thank")
+
+		fulfill(owner.Token, `{}`, http.StatusConflict)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_gift/reserve", "", stranger.Token, http.StatusConflict)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", stranger.Token, http.StatusOK)
+		assert.Empty(t, testutils.ParseResponse[[]contract.FeedItem](t, rec), "fulfilled wishes leave the feed")
+	})
+
+	t.Run("Received gifts history", func(t *testing.T) {
+		history := received(owner.Token)
+		assert.Equal(t, 2, history.Total)
+		require.Len(t, history.Gifts, 2)
+
+		latest, first := history.Gifts[0], history.Gifts[1]
+		assert.Equal(t, "wish_gift", first.WishID)
+		assert.Equal(t, name, *first.WishName)
+		require.NotNil(t, first.Gifter)
+		assert.Equal(t, gifter.User.ID, first.Gifter.ID)
+		require.NotNil(t, first.ThankYouNote)
+		assert.Equal(t, "Thank you, they are lovely!", *first.ThankYouNote)
+		assert.Equal(t, 1, latest.Quantity)
+		assert.Nil(t, latest.Gifter)
+
+		gifterView := received(gifter.Token)
+		require.Len(t, gifterView.Gifts, 2)
+		assert.NotNil(t, gifterView.Gifts[1].ThankYouNote)
+
+		require.NotNil(t, gifterView.Gifts[1].Gifter)
+
+		for _, token := range []string{stranger.Token, ""} {
+			public := received(token)
+			require.Len(t, public.Gifts, 2)
+			assert.Nil(t, public.Gifts[1].ThankYouNote, "notes are between the owner and the gifter")
+			assert.Nil(t, public.Gifts[0].ThankYouNote)
+			assert.Nil(t, public.Gifts[1].Gifter, "so is who gave the gift")
+		}
+	})
+
+	t.Run("Undo a gift", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/wish_gift/fulfill", "", stranger.Token, http.StatusNotFound)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/wish_gift/fulfill", "", owner.Token, http.StatusOK)
+		wish := testutils.ParseResponse[db.Wish](t, rec)
+		assert.Equal(t, 1, wish.Received)
+		assert.False(t, wish.IsFulfilled)
+
+		history := received(owner.Token)
+		require.Len(t, history.Gifts, 1)
+		assert.Equal(t, gifter.User.ID, history.Gifts[0].Gifter.ID)
+
+		wish = fulfill(owner.Token, `{}`, http.StatusOK)
+		assert.True(t, wish.IsFulfilled)
+	})
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"log"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"strconv"
+)
+
+// notificationEvent is something a user did that other users should hear
+// about. It fans out into one notification per recipient.
+type notificationEvent struct {
+	Type       string
+	ActorID    string
+	WishID     *string
+	Recipients []string
+}
+
+// notify records the event in each recipient's inbox and queues Telegram
+// delivery according to their preferences. Failures are logged and never
+// fail the request that triggered the event.
+func (a *API) notify(ctx context.Context, ev notificationEvent) {
+	for _, recipient := range ev.Recipients {
+		if recipient == "" || recipient == ev.ActorID {
+			continue
+		}
+
+		prefs, err := a.storage.GetNotificationPreferences(ctx, recipient)
+		if err != nil {
+			log.Printf("Failed to get notification preferences for %s: %v", recipient, err)
+			continue
+		}
+
+		push := false
+		for _, p := range prefs {
+			if p.Type == ev.Type {
+				push = p.Telegram
+				break
+			}
+		}
+
+		actorID := ev.ActorID
+		n := db.Notification{
+			ID:      nanoid.Must(),
+			UserID:  recipient,
+			Type:    ev.Type,
+			ActorID: &actorID,
+			WishID:  ev.WishID,
+		}
+
+		if err := a.storage.CreateNotification(ctx, n, push); err != nil {
+			log.Printf("Failed to create %s notification for %s: %v", ev.Type, recipient, err)
+		}
+	}
+}
+
+func (a *API) ListNotifications(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(c.QueryParam("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	notifications, err := a.storage.ListNotifications(c.Request().Context(), uid, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list notifications").WithInternal(err)
+	}
+
+	unread, err := a.storage.CountUnreadNotifications(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list notifications").WithInternal(err)
+	}
+
+	resp := contract.NotificationsResponse{
+		Notifications: make([]contract.NotificationResponse, 0, len(notifications)),
+		UnreadCount:   unread,
+	}
+	for _, n := range notifications {
+		resp.Notifications = append(resp.Notifications, contract.ToNotificationResponse(n))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (a *API) MarkNotificationsRead(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req contract.MarkNotificationsReadRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to validate request").WithInternal(err)
+	}
+
+	if err := a.storage.MarkNotificationsRead(c.Request().Context(), uid, req.IDs); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not mark notifications as read").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) GetNotificationPreferences(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := a.storage.GetNotificationPreferences(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not get notification preferences").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
+
+func (a *API) UpdateNotificationPreferences(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req contract.UpdateNotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to validate request").WithInternal(err)
+	}
+
+	if err := a.storage.UpdateNotificationPreferences(c.Request().Context(), uid, req.Preferences); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not update notification preferences").WithInternal(err)
+	}
+
+	prefs, err := a.storage.GetNotificationPreferences(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not get notification preferences").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, prefs)
+}
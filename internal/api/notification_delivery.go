@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"log"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"strings"
+	"time"
+)
+
+const (
+	// NotificationDeliveryInterval is how often queued notifications are sent.
+	// Everything a user received in between goes out as a single message.
+	NotificationDeliveryInterval = 30 * time.Second
+
+	notificationBatchSize = 500
+
+	// Telegram allows about 30 messages per second across all chats.
+	notificationSendRate = 25
+
+	// Batches that fail to send for other reasons than a blocked bot or a
+	// missing chat are retried after notificationRetryBackoff, doubling
+	// with every attempt, and dropped after notificationMaxAttempts.
+	notificationRetryBackoff = time.Minute
+	notificationMaxAttempts  = 8
+)
+
+// RunNotificationDelivery sends queued notifications to Telegram every
+// interval until ctx is cancelled.
+func (a *API) RunNotificationDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := a.DeliverNotifications(ctx, now); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Failed to deliver notifications: %v", err)
+			}
+		}
+	}
+}
+
+// DeliverNotifications sends one message per recipient for everything queued
+// since the last run. When Telegram asks to slow down, the rest of the queue is
+// left for the next run; batches that fail otherwise are retried with backoff.
+func (a *API) DeliverNotifications(ctx context.Context, now time.Time) error {
+	if a.bot == nil {
+		return errors.New("bot is not initialized")
+	}
+
+	pending, err := a.storage.ListPendingNotifications(ctx, now, notificationBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list pending notifications: %w", err)
+	}
+
+	for start := 0; start < len(pending); {
+		end := start + 1
+		for end < len(pending) && pending[end].UserID == pending[start].UserID {
+			end++
+		}
+		batch := pending[start:end]
+		start = end
+
+		if err := a.sendLimiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(batch))
+		attempts := 0
+		for _, n := range batch {
+			ids = append(ids, n.ID)
+			attempts = max(attempts, n.PushAttempts)
+		}
+
+		_, err := a.bot.SendMessage(ctx, a.notificationMessage(batch))
+
+		var tooMany *telegram.TooManyRequestsError
+		if errors.As(err, &tooMany) {
+			log.Printf("Telegram rate limit hit, retrying in %ds", tooMany.RetryAfter)
+			return nil
+		} else if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		} else if err != nil && (errors.Is(err, telegram.ErrorForbidden) || errors.Is(err, telegram.ErrorBadRequest)) {
+			// Blocked bots and deleted chats will not recover, so the batch
+			// is dropped from the queue; it stays in the in-app inbox.
+			log.Printf("Failed to send notifications to chat %d: %v", batch[0].ChatID, err)
+		} else if err != nil && attempts+1 < notificationMaxAttempts {
+			retryAt := now.Add(notificationRetryBackoff << attempts)
+			log.Printf("Failed to send notifications to chat %d, retrying at %s: %v", batch[0].ChatID, retryAt.Format(time.RFC3339), err)
+
+			if err := a.storage.RetryNotifications(ctx, ids, retryAt); err != nil {
+				return fmt.Errorf("failed to requeue notifications: %w", err)
+			}
+			continue
+		} else if err != nil {
+			log.Printf("Giving up on notifications to chat %d after %d attempts: %v", batch[0].ChatID, attempts+1, err)
+		}
+
+		if err := a.storage.MarkNotificationsPushed(ctx, ids); err != nil {
+			return fmt.Errorf("failed to mark notifications as sent: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (a *API) notificationMessage(batch []db.PendingNotification) *telegram.SendMessageParams {
+	lang := batch[0].LanguageCode
+
+	msg := &telegram.SendMessageParams{ChatID: batch[0].ChatID}
+
+	link := a.cfg.MiniAppURL
+	if len(batch) == 1 {
+		msg.Text = formatNotification(lang, batch[0].Notification)
+		if startParam := notificationStartParam(batch[0].Notification); startParam != "" {
+			link = a.miniAppLink(startParam)
+		}
+	} else {
+		lines := []string{i18n.T(lang, i18n.NotificationBatchHeader, len(batch))}
+		for _, n := range batch {
+			lines = append(lines, "• "+formatNotification(lang, n.Notification))
+		}
+		msg.Text = strings.Join(lines, "\n")
+	}
+
+	msg.ReplyMarkup = &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{
+			{{Text: i18n.T(lang, i18n.BotOpen), URL: link}},
+		},
+	}
+
+	return msg
+}
+
+// displayName is how a user is referred to in bot messages.
+func displayName(u db.User) string {
+	if u.Name != nil && *u.Name != "" {
+		return *u.Name
+	}
+	return "@" + u.Username
+}
+
+func formatNotification(lang string, n db.Notification) string {
+	actor := "?"
+	if n.Actor != nil {
+		actor = displayName(*n.Actor)
+	}
+
+	wish := i18n.T(lang, i18n.BotUntitled)
+	if n.WishName != nil {
+		wish = *n.WishName
+	}
+
+	switch n.Type {
+	case db.NotificationTypeFollow:
+		return i18n.T(lang, i18n.NotificationFollow, actor)
+	case db.NotificationTypeFollowRequest:
+		return i18n.T(lang, i18n.NotificationFollowRequest, actor)
+	case db.NotificationTypeBookmark:
+		return i18n.T(lang, i18n.NotificationBookmark, actor, wish)
+	case db.NotificationTypeCopy:
+		return i18n.T(lang, i18n.NotificationCopy, actor, wish)
+	case db.NotificationTypeComment:
+		return i18n.T(lang, i18n.NotificationComment, actor, wish)
+	default:
+		return n.Type
+	}
+}
+
+// notificationStartParam picks what the mini app should open for a notification.
+func notificationStartParam(n db.Notification) string {
+	if n.WishID != nil {
+		return startParamWish + "_" + *n.WishID
+	}
+	if n.ActorID != nil {
+		return startParamProfile + "_" + *n.ActorID
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	ownerAuth, err := testutils.AuthHelper(t, ts.Echo, 7101, "notify_owner", "Owner")
+	require.NoError(t, err)
+	fanAuth, err := testutils.AuthHelper(t, ts.Echo, 7102, "notify_fan", "Fan")
+	require.NoError(t, err)
+
+	catID := "cat_notify"
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: catID, Name: "Notify Cat", ImageURL: "url"}))
+
+	wishID := "wish_notify"
+	wishName := "Espresso Machine"
+	now := time.Now()
+	require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+		ID: wishID, UserID: ownerAuth.User.ID, Name: &wishName, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{catID}))
+
+	followBody := fmt.Sprintf(`{"following_id":"%s"}`, ownerAuth.User.ID)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", followBody, fanAuth.Token, http.StatusOK)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/"+wishID+"/bookmark", "", fanAuth.Token, http.StatusOK)
+
+	// Following again after unfollowing must not add a second unread notification.
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/unfollow", followBody, fanAuth.Token, http.StatusOK)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", followBody, fanAuth.Token, http.StatusOK)
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", ownerAuth.Token, http.StatusOK)
+	inbox := testutils.ParseResponse[contract.NotificationsResponse](t, rec)
+	require.Len(t, inbox.Notifications, 2)
+	assert.Equal(t, 2, inbox.UnreadCount)
+
+	types := []string{inbox.Notifications[0].Type, inbox.Notifications[1].Type}
+	assert.ElementsMatch(t, []string{db.NotificationTypeFollow, db.NotificationTypeBookmark}, types)
+	for _, n := range inbox.Notifications {
+		require.NotNil(t, n.Actor)
+		assert.Equal(t, fanAuth.User.ID, n.Actor.ID)
+	}
+
+	rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", fanAuth.Token, http.StatusOK)
+	assert.Empty(t, testutils.ParseResponse[contract.NotificationsResponse](t, rec).Notifications)
+
+	t.Run("Telegram delivery is batched per user", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.DeliverNotifications(context.Background(), time.Now()))
+
+		sent := ts.Telegram.Calls("sendMessage")
+		require.Len(t, sent, 1)
+		assert.Equal(t, "7101", sent[0].Params["chat_id"])
+		assert.Equal(t, 2, strings.Count(sent[0].Params["text"], "\n• "))
+		assert.Contains(t, sent[0].Params["text"], "Espresso Machine")
+
+		require.NoError(t, ts.API.DeliverNotifications(context.Background(), time.Now()))
+		assert.Len(t, ts.Telegram.Calls("sendMessage"), 1)
+	})
+
+	t.Run("Mark read", func(t *testing.T) {
+		body := fmt.Sprintf(`{"ids":["%s"]}`, inbox.Notifications[0].ID)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/notifications/read", body, ownerAuth.Token, http.StatusOK)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", ownerAuth.Token, http.StatusOK)
+		assert.Equal(t, 1, testutils.ParseResponse[contract.NotificationsResponse](t, rec).UnreadCount)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/notifications/read", `{}`, ownerAuth.Token, http.StatusOK)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", ownerAuth.Token, http.StatusOK)
+		assert.Equal(t, 0, testutils.ParseResponse[contract.NotificationsResponse](t, rec).UnreadCount)
+	})
+}
+
+func TestNotificationRetries(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 7121, "retry_owner", "Owner")
+	require.NoError(t, err)
+	fan, err := testutils.AuthHelper(t, ts.Echo, 7122, "retry_fan", "Fan")
+	require.NoError(t, err)
+	other, err := testutils.AuthHelper(t, ts.Echo, 7123, "retry_other", "Other")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	deliver := func(at time.Duration) int {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.DeliverNotifications(ctx, now.Add(at)))
+		return len(ts.Telegram.Calls("sendMessage"))
+	}
+
+	t.Run("Transient errors are retried with backoff", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, fan.Token, http.StatusOK)
+		ts.Telegram.FailNext("sendMessage", http.StatusInternalServerError, "Internal Server Error")
+		ts.Telegram.FailNext("sendMessage", http.StatusBadGateway, "Bad Gateway")
+
+		assert.Equal(t, 1, deliver(0))
+		assert.Equal(t, 0, deliver(30*time.Second), "waits a minute after the first failure")
+		assert.Equal(t, 1, deliver(time.Minute))
+		assert.Equal(t, 0, deliver(2*time.Minute), "waits twice as long after the second")
+		assert.Equal(t, 1, deliver(3*time.Minute))
+		assert.Equal(t, 0, deliver(time.Hour), "sent messages leave the queue")
+	})
+
+	t.Run("Blocked bots are not retried", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, other.Token, http.StatusOK)
+		ts.Telegram.FailNext("sendMessage", http.StatusForbidden, "Forbidden: bot was blocked by the user")
+
+		assert.Equal(t, 1, deliver(0))
+		assert.Equal(t, 0, deliver(time.Hour))
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", owner.Token, http.StatusOK)
+		assert.Len(t, testutils.ParseResponse[contract.NotificationsResponse](t, rec).Notifications, 2, "the inbox keeps them")
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	ownerAuth, err := testutils.AuthHelper(t, ts.Echo, 7111, "prefs_owner", "Owner")
+	require.NoError(t, err)
+	fanAuth, err := testutils.AuthHelper(t, ts.Echo, 7112, "prefs_fan", "Fan")
+	require.NoError(t, err)
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications/preferences", "", ownerAuth.Token, http.StatusOK)
+	prefs := testutils.ParseResponse[[]db.NotificationPreference](t, rec)
+	require.Len(t, prefs, len(db.NotificationTypes))
+	for _, p := range prefs {
+		assert.True(t, p.Telegram)
+	}
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/notifications/preferences",
+		`{"preferences":[{"type":"unknown","telegram":false}]}`, ownerAuth.Token, http.StatusBadRequest)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/notifications/preferences",
+		`{"preferences":[{"type":"follow","telegram":false}]}`, ownerAuth.Token, http.StatusOK)
+
+	followBody := fmt.Sprintf(`{"following_id":"%s"}`, ownerAuth.User.ID)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", followBody, fanAuth.Token, http.StatusOK)
+
+	ts.Telegram.Reset()
+	require.NoError(t, ts.API.DeliverNotifications(context.Background(), time.Now()))
+	assert.Empty(t, ts.Telegram.Calls("sendMessage"))
+
+	rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/notifications", "", ownerAuth.Token, http.StatusOK)
+	assert.Equal(t, 1, testutils.ParseResponse[contract.NotificationsResponse](t, rec).UnreadCount)
+}
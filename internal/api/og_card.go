@@ -0,0 +1,413 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"sacred/internal/i18n"
+	"sacred/internal/s3"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cardWidth   = 1200
+	cardHeight  = 630
+	cardPadding = 56
+	cardGap     = 6
+
+	cardCollageMax = 4
+	cardTitleLines = 4
+	cardAvatarSize = 72
+
+	// cardLayoutVersion is part of every fingerprint; bump it when the layout
+	// changes so stale cards are not served from blob storage.
+	cardLayoutVersion = "1"
+
+	// cardFingerprintMetadata names the object metadata that records which
+	// content a cached card was rendered from.
+	cardFingerprintMetadata = "fingerprint"
+
+	cardImageMaxBytes = 10 << 20
+)
+
+var (
+	cardRegularFont = mustParseFont(goregular.TTF)
+	cardBoldFont    = mustParseFont(gobold.TTF)
+
+	cardBackground  = color.RGBA{R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff}
+	cardPlaceholder = color.RGBA{R: 0xe5, G: 0xe5, B: 0xe5, A: 0xff}
+	cardText        = color.RGBA{R: 0x11, G: 0x11, B: 0x11, A: 0xff}
+	cardMuted       = color.RGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff}
+	cardAccent      = color.RGBA{R: 0x2a, G: 0xab, B: 0xee, A: 0xff}
+
+	cardImageClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+func mustParseFont(ttf []byte) *sfnt.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// ogCard is what a share preview image shows: a collage on the left, the
+// title and subtitle on the right and the owner at the bottom.
+type ogCard struct {
+	Title     string
+	Subtitle  string
+	OwnerName string
+	AvatarURL string
+	ImageURLs []string
+}
+
+// fingerprint changes whenever anything drawn on the card changes, so edits
+// to a wish or its images invalidate the cached copy.
+func (c ogCard) fingerprint() string {
+	h := sha256.New()
+	for _, s := range append([]string{cardLayoutVersion, c.Title, c.Subtitle, c.OwnerName, c.AvatarURL}, c.ImageURLs...) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// ogCardObject is where the card for key, e.g. "wishes/<id>", is cached in
+// lang. Each card has one object per language that is overwritten when the
+// content changes.
+func ogCardObject(key, lang string) string {
+	return fmt.Sprintf("og/%s-%s.png", key, lang)
+}
+
+// ogCardObjects lists the cached cards for key in every language.
+func ogCardObjects(key string) []string {
+	langs := i18n.Languages()
+
+	objects := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		objects = append(objects, ogCardObject(key, lang))
+	}
+	return objects
+}
+
+// serveOGCard responds with the card PNG, reusing the copy in blob storage
+// when it was rendered from the same content.
+func (a *API) serveOGCard(c echo.Context, key, lang string, card ogCard) error {
+	ctx := c.Request().Context()
+	fp := card.fingerprint()
+	etag := `"` + fp + `"`
+
+	c.Response().Header().Set("Cache-Control", shareCacheControl)
+	c.Response().Header().Set("Vary", "Accept-Language")
+	c.Response().Header().Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	object := ogCardObject(key, lang)
+	if a.s3 != nil {
+		data, metadata, err := a.s3.DownloadFileWithMetadata(ctx, object)
+		if err == nil && metadata[cardFingerprintMetadata] == fp {
+			return c.Blob(http.StatusOK, "image/png", data)
+		} else if err != nil && !errors.Is(err, s3.ErrNotFound) {
+			log.Printf("failed to get cached card %s: %v", object, err)
+		}
+	}
+
+	images, avatar := loadCardImages(ctx, card)
+	data, err := renderOGCard(card, images, avatar)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to render image").WithInternal(err)
+	}
+
+	if a.s3 != nil {
+		metadata := map[string]string{cardFingerprintMetadata: fp}
+		if _, err := a.s3.UploadFileWithMetadata(data, object, metadata); err != nil {
+			log.Printf("failed to cache card %s: %v", object, err)
+		}
+	}
+
+	return c.Blob(http.StatusOK, "image/png", data)
+}
+
+// loadCardImages downloads the collage images and the avatar in parallel.
+// Images that fail to load are left out.
+func loadCardImages(ctx context.Context, card ogCard) ([]image.Image, image.Image) {
+	urls := card.ImageURLs
+	if len(urls) > cardCollageMax {
+		urls = urls[:cardCollageMax]
+	}
+
+	loaded := make([]image.Image, len(urls))
+	var avatar image.Image
+
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loaded[i] = loadCardImage(ctx, u)
+		}()
+	}
+	if card.AvatarURL != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			avatar = loadCardImage(ctx, card.AvatarURL)
+		}()
+	}
+	wg.Wait()
+
+	images := make([]image.Image, 0, len(loaded))
+	for _, img := range loaded {
+		if img != nil {
+			images = append(images, img)
+		}
+	}
+
+	return images, avatar
+}
+
+func loadCardImage(ctx context.Context, url string) image.Image {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := cardImageClient.Do(req)
+	if err != nil {
+		log.Printf("failed to download card image %s: %v", url, err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("failed to download card image %s: status %d", url, resp.StatusCode)
+		return nil
+	}
+
+	// SVG avatars and other unsupported formats are skipped here.
+	img, _, err := image.Decode(io.LimitReader(resp.Body, cardImageMaxBytes))
+	if err != nil {
+		return nil
+	}
+
+	return img
+}
+
+// renderOGCard draws a 1200x630 PNG preview.
+func renderOGCard(card ogCard, images []image.Image, avatar image.Image) ([]byte, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+
+	collage := image.Rect(0, 0, cardHeight, cardHeight)
+	if len(images) == 0 {
+		draw.Draw(dst, collage, image.NewUniform(cardPlaceholder), image.Point{}, draw.Src)
+	}
+	for i, r := range collageRects(collage, len(images)) {
+		drawCover(dst, r, images[i])
+	}
+
+	titleFace, err := opentype.NewFace(cardBoldFont, &opentype.FaceOptions{Size: 56, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer titleFace.Close()
+
+	subtitleFace, err := opentype.NewFace(cardBoldFont, &opentype.FaceOptions{Size: 44, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer subtitleFace.Close()
+
+	ownerFace, err := opentype.NewFace(cardRegularFont, &opentype.FaceOptions{Size: 32, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	defer ownerFace.Close()
+
+	x := cardHeight + cardPadding
+	width := cardWidth - x - cardPadding
+
+	y := cardPadding
+	for _, line := range wrapText(titleFace, card.Title, width, cardTitleLines) {
+		y += 66
+		drawText(dst, titleFace, cardText, x, y, line)
+	}
+
+	if card.Subtitle != "" {
+		y += 72
+		drawText(dst, subtitleFace, cardAccent, x, y, truncateText(subtitleFace, card.Subtitle, width))
+	}
+
+	if card.OwnerName != "" {
+		top := cardHeight - cardPadding - cardAvatarSize
+		drawAvatar(dst, image.Rect(x, top, x+cardAvatarSize, top+cardAvatarSize), avatar)
+
+		nameX := x + cardAvatarSize + 20
+		name := truncateText(ownerFace, card.OwnerName, cardWidth-cardPadding-nameX)
+		drawText(dst, ownerFace, cardMuted, nameX, top+cardAvatarSize/2+11, name)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// collageRects splits r into tiles for up to four images: one fills it, two
+// sit side by side, three put one large tile next to two stacked ones.
+func collageRects(r image.Rectangle, n int) []image.Rectangle {
+	midX := r.Min.X + r.Dx()/2
+	midY := r.Min.Y + r.Dy()/2
+	left := image.Rect(r.Min.X, r.Min.Y, midX-cardGap/2, r.Max.Y)
+	right := image.Rect(midX+cardGap/2, r.Min.Y, r.Max.X, r.Max.Y)
+
+	switch {
+	case n <= 0:
+		return nil
+	case n == 1:
+		return []image.Rectangle{r}
+	case n == 2:
+		return []image.Rectangle{left, right}
+	case n == 3:
+		return []image.Rectangle{
+			left,
+			image.Rect(right.Min.X, r.Min.Y, right.Max.X, midY-cardGap/2),
+			image.Rect(right.Min.X, midY+cardGap/2, right.Max.X, r.Max.Y),
+		}
+	default:
+		return []image.Rectangle{
+			image.Rect(left.Min.X, r.Min.Y, left.Max.X, midY-cardGap/2),
+			image.Rect(right.Min.X, r.Min.Y, right.Max.X, midY-cardGap/2),
+			image.Rect(left.Min.X, midY+cardGap/2, left.Max.X, r.Max.Y),
+			image.Rect(right.Min.X, midY+cardGap/2, right.Max.X, r.Max.Y),
+		}
+	}
+}
+
+// drawCover scales src to fill r, cropping the overflow around the center.
+func drawCover(dst draw.Image, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	crop := sb
+	if sb.Dx()*r.Dy() > sb.Dy()*r.Dx() {
+		w := sb.Dy() * r.Dx() / r.Dy()
+		crop.Min.X += (sb.Dx() - w) / 2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := sb.Dx() * r.Dy() / r.Dx()
+		crop.Min.Y += (sb.Dy() - h) / 2
+		crop.Max.Y = crop.Min.Y + h
+	}
+
+	draw.CatmullRom.Scale(dst, r, src, crop, draw.Src, nil)
+}
+
+// drawAvatar draws the avatar clipped to a circle, or a plain circle when
+// there is none.
+func drawAvatar(dst draw.Image, r image.Rectangle, avatar image.Image) {
+	var src image.Image = image.NewUniform(cardAccent)
+	if avatar != nil {
+		scaled := image.NewRGBA(r)
+		drawCover(scaled, r, avatar)
+		src = scaled
+	}
+
+	draw.DrawMask(dst, r, src, r.Min, circleMask{r}, r.Min, draw.Over)
+}
+
+type circleMask struct {
+	r image.Rectangle
+}
+
+func (m circleMask) ColorModel() color.Model { return color.AlphaModel }
+
+func (m circleMask) Bounds() image.Rectangle { return m.r }
+
+func (m circleMask) At(x, y int) color.Color {
+	radius := float64(m.r.Dx()) / 2
+	dx := float64(x-m.r.Min.X) + 0.5 - radius
+	dy := float64(y-m.r.Min.Y) + 0.5 - radius
+	if dx*dx+dy*dy <= radius*radius {
+		return color.Opaque
+	}
+	return color.Transparent
+}
+
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// wrapText breaks text into lines that fit width. Text that does not fit in
+// maxLines is cut with an ellipsis.
+func wrapText(face font.Face, text string, width, maxLines int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := strings.TrimSpace(line + " " + word)
+		if line == "" || font.MeasureString(face, candidate).Ceil() <= width {
+			line = candidate
+			continue
+		}
+
+		lines = append(lines, line)
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines-1], strings.Join(lines[maxLines-1:], " "))
+	}
+	for i, l := range lines {
+		lines[i] = truncateText(face, l, width)
+	}
+
+	return lines
+}
+
+func truncateText(face font.Face, text string, width int) string {
+	if font.MeasureString(face, text).Ceil() <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		s := strings.TrimSpace(string(runes)) + "…"
+		if font.MeasureString(face, s).Ceil() <= width {
+			return s
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+)
+
+// ToggleWishReaction sets the user's reaction to a wish. Sending the current
+// reaction again takes it back.
+func (a *API) ToggleWishReaction(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req contract.ReactionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid reaction").WithInternal(err)
+	}
+
+	wish, err := a.storage.GetWishByID(c.Request().Context(), uid, c.Param("id"))
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wish not found")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wish").WithInternal(err)
+	}
+
+	if wish.UserID == uid {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot react to own wish")
+	}
+
+	blocked, err := a.storage.IsBlocked(c.Request().Context(), uid, wish.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
+	if blocked {
+		return echo.NewHTTPError(http.StatusForbidden, "cannot react to this wish")
+	}
+
+	reaction, err := a.storage.ToggleReaction(c.Request().Context(), uid, wish.ID, req.Reaction)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not save reaction").WithInternal(err)
+	}
+
+	return a.respondWithReactions(c, wish.ID, reaction)
+}
+
+// RemoveWishReaction takes the user's reaction back. Wishes hidden from the
+// user answer 404, as in ToggleWishReaction.
+func (a *API) RemoveWishReaction(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	wid := c.Param("id")
+	if wid == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "wish id cannot be empty")
+	}
+
+	wish, err := a.storage.GetWishByID(c.Request().Context(), uid, wid)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wish not found")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wish").WithInternal(err)
+	}
+
+	if err := a.storage.RemoveReaction(c.Request().Context(), uid, wish.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not remove reaction").WithInternal(err)
+	}
+
+	return a.respondWithReactions(c, wish.ID, nil)
+}
+
+func (a *API) respondWithReactions(c echo.Context, wishID string, reaction *string) error {
+	counts, err := a.storage.GetReactionCounts(c.Request().Context(), wishID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not count reactions").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, contract.ReactionResponse{Reaction: reaction, Reactions: counts})
+}
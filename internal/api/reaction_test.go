@@ -0,0 +1,111 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishReactions(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 8901, "react_owner", "Owner")
+	require.NoError(t, err)
+	alice, err := testutils.AuthHelper(t, ts.Echo, 8902, "react_alice", "Alice")
+	require.NoError(t, err)
+	bob, err := testutils.AuthHelper(t, ts.Echo, 8903, "react_bob", "Bob")
+	require.NoError(t, err)
+	viewer, err := testutils.AuthHelper(t, ts.Echo, 8904, "react_viewer", "Viewer")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_react", Name: "React Cat", ImageURL: "url"}))
+	for i, id := range []string{"wish_react_old", "wish_react_new"} {
+		name := id
+		created := now.Add(time.Duration(i-1) * time.Hour)
+		testutils.CreateListedWish(t, ts.Storage, db.Wish{
+			ID: id, UserID: owner.User.ID, Name: &name, PublishedAt: &created, CreatedAt: created, UpdatedAt: created,
+		}, "cat_react")
+	}
+
+	react := func(token, wishID, reaction string, status int) contract.ReactionResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/"+wishID+"/reaction", `{"reaction":"`+reaction+`"}`, token, status)
+		if status != http.StatusOK {
+			return contract.ReactionResponse{}
+		}
+		return testutils.ParseResponse[contract.ReactionResponse](t, rec)
+	}
+	feed := func(token string) []contract.FeedItem {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", token, http.StatusOK)
+		return testutils.ParseResponse[[]contract.FeedItem](t, rec)
+	}
+
+	react(alice.Token, "wish_react_old", "love", http.StatusBadRequest)
+	react(owner.Token, "wish_react_old", db.ReactionLike, http.StatusBadRequest)
+	react(alice.Token, "wish_unknown", db.ReactionLike, http.StatusNotFound)
+
+	t.Run("Toggle", func(t *testing.T) {
+		res := react(alice.Token, "wish_react_old", db.ReactionLike, http.StatusOK)
+		require.NotNil(t, res.Reaction)
+		assert.Equal(t, map[string]int{db.ReactionLike: 1}, res.Reactions)
+
+		res = react(alice.Token, "wish_react_old", db.ReactionFire, http.StatusOK)
+		assert.Equal(t, db.ReactionFire, *res.Reaction, "a new reaction replaces the old one")
+		assert.Equal(t, map[string]int{db.ReactionFire: 1}, res.Reactions)
+
+		res = react(alice.Token, "wish_react_old", db.ReactionFire, http.StatusOK)
+		assert.Nil(t, res.Reaction, "reacting the same way again takes it back")
+		assert.Empty(t, res.Reactions)
+	})
+
+	t.Run("Feed shows counts and ranks by reactions", func(t *testing.T) {
+		items := feed(viewer.Token)
+		require.Len(t, items, 2)
+		assert.Equal(t, "wish_react_new", items[0].ID)
+
+		react(alice.Token, "wish_react_old", db.ReactionWantToo, http.StatusOK)
+		react(bob.Token, "wish_react_old", db.ReactionLike, http.StatusOK)
+		react(viewer.Token, "wish_react_old", db.ReactionLike, http.StatusOK)
+
+		items = feed(viewer.Token)
+		require.Len(t, items, 2)
+		assert.Equal(t, "wish_react_old", items[0].ID, "reactions outweigh an hour of age")
+		assert.Equal(t, map[string]int{db.ReactionLike: 2, db.ReactionWantToo: 1}, items[0].Reactions)
+		require.NotNil(t, items[0].MyReaction)
+		assert.Equal(t, db.ReactionLike, *items[0].MyReaction)
+		assert.Nil(t, items[1].MyReaction)
+		assert.Empty(t, items[1].Reactions)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/wish_react_old/reaction", "", viewer.Token, http.StatusOK)
+		res := testutils.ParseResponse[contract.ReactionResponse](t, rec)
+		assert.Nil(t, res.Reaction)
+		assert.Equal(t, map[string]int{db.ReactionLike: 1, db.ReactionWantToo: 1}, res.Reactions)
+
+		wish, err := ts.Storage.GetWishByID(ctx, bob.User.ID, "wish_react_old")
+		require.NoError(t, err)
+		require.NotNil(t, wish.MyReaction)
+		assert.Equal(t, db.ReactionLike, *wish.MyReaction)
+	})
+
+	t.Run("Hidden wishes", func(t *testing.T) {
+		name := "wish_react_private"
+		testutils.CreateListedWish(t, ts.Storage, db.Wish{
+			ID: name, UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+			Visibility: db.WishVisibilityPrivate,
+		}, "cat_react")
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/"+name+"/reaction", "", viewer.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/wish_unknown/reaction", "", viewer.Token, http.StatusNotFound)
+	})
+}
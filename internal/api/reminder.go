@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	telegram "github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"log"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"strings"
+	"time"
+)
+
+const (
+	// ReminderCheckInterval is how often upcoming events are looked up. Each
+	// reminder is sent once, so checking more often than daily only makes
+	// delivery more punctual.
+	ReminderCheckInterval = time.Hour
+
+	// reminderLeadDays is how many days ahead followers hear about an event.
+	reminderLeadDays = 7
+
+	reminderGiftIdeas = 3
+)
+
+// RunReminders sends birthday and event reminders every interval until ctx
+// is cancelled.
+func (a *API) RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := a.SendReminders(ctx, now); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("Failed to send reminders: %v", err)
+			}
+		}
+	}
+}
+
+// SendReminders tells followers about friends' birthdays and wishlist events
+// in the next reminderLeadDays days, once per occurrence.
+func (a *API) SendReminders(ctx context.Context, now time.Time) error {
+	if a.bot == nil {
+		return errors.New("bot is not initialized")
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	last := today.AddDate(0, 0, reminderLeadDays)
+
+	var monthDays []string
+	for d := today; !d.After(last); d = d.AddDate(0, 0, 1) {
+		monthDays = append(monthDays, d.Format("01-02"))
+		// People born on Feb 29 celebrate on Feb 28 in common years.
+		if d.Month() == time.February && d.Day() == 28 && !isLeapYear(d.Year()) {
+			monthDays = append(monthDays, "02-29")
+		}
+	}
+
+	events, err := a.storage.ListUpcomingEvents(ctx, monthDays, today.Format(time.DateOnly), last.Format(time.DateOnly))
+	if err != nil {
+		return fmt.Errorf("failed to list upcoming events: %w", err)
+	}
+
+	for _, ev := range events {
+		occursOn, ok := nextOccurrence(ev, today)
+		if !ok || occursOn.After(last) {
+			continue
+		}
+
+		day := occursOn.Format(time.DateOnly)
+		first, err := a.storage.MarkReminderSent(ctx, ev.FollowerID, ev.Kind, ev.EventID, day)
+		if err != nil {
+			return fmt.Errorf("failed to record reminder: %w", err)
+		}
+		if !first {
+			continue
+		}
+
+		msg, err := a.reminderMessage(ctx, ev, int(occursOn.Sub(today).Hours()/24))
+		if err != nil {
+			log.Printf("Failed to build reminder for %s: %v", ev.FollowerID, err)
+			continue
+		}
+
+		if err := a.sendLimiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		_, err = a.bot.SendMessage(ctx, msg)
+		if err == nil {
+			continue
+		}
+
+		log.Printf("Failed to send reminder to chat %d: %v", ev.FollowerChatID, err)
+		if errors.Is(err, telegram.ErrorForbidden) || errors.Is(err, telegram.ErrorBadRequest) {
+			// Blocked bots and deleted chats will not recover, so the
+			// reminder stays recorded and is not retried.
+			continue
+		}
+
+		// Anything else may be transient: forget the reminder so the next
+		// check sends it again.
+		if err := a.storage.UnmarkReminderSent(context.WithoutCancel(ctx), ev.FollowerID, ev.Kind, ev.EventID, day); err != nil {
+			return fmt.Errorf("failed to unrecord reminder: %w", err)
+		}
+
+		var tooMany *telegram.TooManyRequestsError
+		if errors.As(err, &tooMany) {
+			log.Printf("Telegram rate limit hit, retrying in %ds", tooMany.RetryAfter)
+			return nil
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// nextOccurrence returns the next date on or after today the event happens.
+func nextOccurrence(ev db.UpcomingEvent, today time.Time) (time.Time, bool) {
+	date, err := time.Parse(time.DateOnly, ev.Date)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if ev.Kind != db.ReminderKindBirthday {
+		return date, !date.Before(today)
+	}
+
+	for year := today.Year(); year <= today.Year()+1; year++ {
+		day := date.Day()
+		if date.Month() == time.February && day == 29 && !isLeapYear(year) {
+			day = 28
+		}
+
+		occurrence := time.Date(year, date.Month(), day, 0, 0, 0, 0, time.UTC)
+		if !occurrence.Before(today) {
+			return occurrence, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func (a *API) reminderMessage(ctx context.Context, ev db.UpcomingEvent, daysLeft int) (*telegram.SendMessageParams, error) {
+	lang := ev.FollowerLanguage
+	friend := displayName(ev.Friend)
+
+	var header string
+	if ev.Kind == db.ReminderKindBirthday {
+		switch daysLeft {
+		case 0:
+			header = i18n.T(lang, i18n.ReminderBirthdayToday, friend)
+		case 1:
+			header = i18n.T(lang, i18n.ReminderBirthdayTomorrow, friend)
+		default:
+			header = i18n.T(lang, i18n.ReminderBirthdayIn, friend, daysLeft)
+		}
+	} else {
+		event := i18n.T(lang, i18n.BotUntitled)
+		if ev.EventName != nil {
+			event = *ev.EventName
+		}
+
+		switch daysLeft {
+		case 0:
+			header = i18n.T(lang, i18n.ReminderEventToday, friend, event)
+		case 1:
+			header = i18n.T(lang, i18n.ReminderEventTomorrow, friend, event)
+		default:
+			header = i18n.T(lang, i18n.ReminderEventIn, friend, event, daysLeft)
+		}
+	}
+
+	wishes, err := a.storage.ListGiftIdeas(ctx, ev.FollowerID, ev.Friend.ID, reminderGiftIdeas)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{header, ""}
+	keyboard := make([][]models.InlineKeyboardButton, 0, len(wishes)+1)
+
+	if len(wishes) == 0 {
+		lines = append(lines, i18n.T(lang, i18n.ReminderNoWishes))
+	} else {
+		lines = append(lines, i18n.T(lang, i18n.ReminderGiftIdeas))
+		for i, w := range wishes {
+			lines = append(lines, formatWishLine(lang, i+1, w))
+			keyboard = append(keyboard, []models.InlineKeyboardButton{
+				{Text: i18n.T(lang, i18n.BotOpenNumbered, i+1), URL: a.miniAppLink(startParamWish + "_" + w.ID)},
+			})
+		}
+	}
+
+	keyboard = append(keyboard, []models.InlineKeyboardButton{
+		{Text: i18n.T(lang, i18n.ReminderOpenProfile), URL: a.miniAppLink(startParamProfile + "_" + ev.Friend.ID)},
+	})
+
+	return &telegram.SendMessageParams{
+		ChatID:      ev.FollowerChatID,
+		Text:        strings.Join(lines, "\n"),
+		ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	}, nil
+}
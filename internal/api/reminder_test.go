@@ -0,0 +1,162 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendReminders(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	friendAuth, err := testutils.AuthHelper(t, ts.Echo, 7201, "reminder_friend", "Friend")
+	require.NoError(t, err)
+	followerAuth, err := testutils.AuthHelper(t, ts.Echo, 7202, "reminder_follower", "Follower")
+	require.NoError(t, err)
+
+	catID := "cat_reminder"
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: catID, Name: "Reminder Cat", ImageURL: "url"}))
+
+	settings := fmt.Sprintf(`{"interests":["%s"],"email":"friend@example.com","birthday":"1990-03-14"}`, catID)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings", settings, friendAuth.Token, http.StatusOK)
+
+	invalid := fmt.Sprintf(`{"interests":["%s"],"email":"friend@example.com","birthday":"14.03.1990"}`, catID)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings", invalid, friendAuth.Token, http.StatusBadRequest)
+
+	now := time.Now()
+	open, reserved := "Vinyl Player", "Tea Set"
+	require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+		ID: "wish_reminder_open", UserID: friendAuth.User.ID, Name: &open, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{catID}))
+	require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+		ID: "wish_reminder_reserved", UserID: friendAuth.User.ID, Name: &reserved, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{catID}))
+	require.NoError(t, ts.Storage.ReserveWish(context.Background(), followerAuth.User.ID, "wish_reminder_reserved"))
+
+	require.NoError(t, ts.Storage.FollowUser(context.Background(), followerAuth.User.ID, friendAuth.User.ID))
+
+	t.Run("Too early", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"))
+	})
+
+	t.Run("Within lead time", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2026, 3, 11, 9, 0, 0, 0, time.UTC)))
+
+		sent := ts.Telegram.Calls("sendMessage")
+		require.Len(t, sent, 1)
+		assert.Equal(t, "7202", sent[0].Params["chat_id"])
+		assert.Contains(t, sent[0].Params["text"], "Vinyl Player")
+		assert.NotContains(t, sent[0].Params["text"], "Tea Set")
+		assert.Contains(t, sent[0].Params["reply_markup"], "startapp=u_"+friendAuth.User.ID)
+	})
+
+	t.Run("Sent once per occurrence", func(t *testing.T) {
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2026, 3, 12, 9, 0, 0, 0, time.UTC)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"))
+
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2027, 3, 10, 9, 0, 0, 0, time.UTC)))
+		assert.Len(t, ts.Telegram.Calls("sendMessage"), 1)
+	})
+
+	t.Run("Birthday is shown to followers without the year", func(t *testing.T) {
+		stranger, err := testutils.AuthHelper(t, ts.Echo, 7203, "reminder_stranger", "Stranger")
+		require.NoError(t, err)
+
+		monthDay := "03-14"
+		for token, expected := range map[string]*string{followerAuth.Token: &monthDay, stranger.Token: nil, "": nil} {
+			rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+friendAuth.User.ID, "", token, http.StatusOK)
+			assert.Equal(t, expected, testutils.ParseResponse[contract.UserProfileResponse](t, rec).Birthday)
+		}
+	})
+
+	t.Run("Wishlist events", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishlists", `{"name":"Party","event_date":"20.06.2026"}`, friendAuth.Token, http.StatusBadRequest)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishlists", `{"name":" Housewarming ","event_date":"2026-06-20"}`, friendAuth.Token, http.StatusCreated)
+		list := testutils.ParseResponse[db.Wishlist](t, rec)
+		assert.Equal(t, "Housewarming", list.Name)
+		require.NotNil(t, list.EventDate)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/wishlists/"+list.ID, `{"name":"Hijacked"}`, followerAuth.Token, http.StatusNotFound)
+
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2026, 6, 15, 9, 0, 0, 0, time.UTC)))
+		sent := ts.Telegram.Calls("sendMessage")
+		require.Len(t, sent, 1)
+		assert.Contains(t, sent[0].Params["text"], "Housewarming")
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/wishlists/"+list.ID, `{"name":"Housewarming","event_date":"2026-09-01"}`, friendAuth.Token, http.StatusOK)
+		assert.Equal(t, "2026-09-01", *testutils.ParseResponse[db.Wishlist](t, rec).EventDate)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishlists/"+list.ID, "", friendAuth.Token, http.StatusOK)
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/wishlists", "", friendAuth.Token, http.StatusOK)
+		assert.Empty(t, testutils.ParseResponse[[]db.Wishlist](t, rec))
+
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2026, 8, 28, 9, 0, 0, 0, time.UTC)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"), "deleted wishlists have no events")
+	})
+
+	t.Run("Retried after a transient failure", func(t *testing.T) {
+		ts.Telegram.Reset()
+		ts.Telegram.FailNext("sendMessage", http.StatusInternalServerError, "Internal Server Error")
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2029, 3, 10, 9, 0, 0, 0, time.UTC)))
+		require.Len(t, ts.Telegram.Calls("sendMessage"), 1)
+
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2029, 3, 10, 10, 0, 0, 0, time.UTC)))
+		assert.Len(t, ts.Telegram.Calls("sendMessage"), 2, "the failed reminder is sent again")
+
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2029, 3, 10, 11, 0, 0, 0, time.UTC)))
+		assert.Len(t, ts.Telegram.Calls("sendMessage"), 2)
+	})
+
+	t.Run("Dropped when the chat is gone", func(t *testing.T) {
+		ts.Telegram.Reset()
+		ts.Telegram.FailNext("sendMessage", http.StatusForbidden, "Forbidden: bot was blocked by the user")
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2030, 3, 10, 9, 0, 0, 0, time.UTC)))
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2030, 3, 10, 10, 0, 0, 0, time.UTC)))
+		assert.Len(t, ts.Telegram.Calls("sendMessage"), 1)
+	})
+
+	t.Run("Muted friend", func(t *testing.T) {
+		require.NoError(t, ts.Storage.MuteUser(context.Background(), followerAuth.User.ID, friendAuth.User.ID))
+
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2031, 3, 10, 9, 0, 0, 0, time.UTC)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"), "muted friends are not announced")
+
+		require.NoError(t, ts.Storage.UnmuteUser(context.Background(), followerAuth.User.ID, friendAuth.User.ID))
+	})
+
+	t.Run("Friend pending deletion", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/user/deletion", "", friendAuth.Token, http.StatusOK)
+
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2032, 3, 10, 9, 0, 0, 0, time.UTC)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"), "accounts pending deletion are not announced")
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/deletion", "", friendAuth.Token, http.StatusOK)
+	})
+
+	t.Run("Opted out", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/notifications/preferences",
+			`{"preferences":[{"type":"reminder","telegram":false}]}`, followerAuth.Token, http.StatusOK)
+
+		ts.Telegram.Reset()
+		require.NoError(t, ts.API.SendReminders(context.Background(), time.Date(2028, 3, 10, 9, 0, 0, 0, time.UTC)))
+		assert.Empty(t, ts.Telegram.Calls("sendMessage"))
+	})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sacred/internal/db"
+)
+
+func (a *API) ReserveWishHandler(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	wid := c.Param("id")
+	if wid == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "wish id cannot be empty")
+	}
+
+	wish, err := a.storage.GetWishByID(c.Request().Context(), uid, wid)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wish not found")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wish").WithInternal(err)
+	}
+
+	if wish.UserID == uid {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot reserve own wish")
+	}
+
+	if wish.IsFulfilled {
+		return echo.NewHTTPError(http.StatusConflict, "wish is already fulfilled")
+	}
+
+	blocked, err := a.storage.IsBlocked(c.Request().Context(), uid, wish.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
+	if blocked {
+		return echo.NewHTTPError(http.StatusForbidden, "cannot reserve this wish")
+	}
+
+	err = a.storage.ReserveWish(c.Request().Context(), uid, wid)
+	if err != nil && errors.Is(err, db.ErrAlreadyExists) {
+		return echo.NewHTTPError(http.StatusConflict, "wish is already reserved")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot reserve wish").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) UnreserveWishHandler(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	wid := c.Param("id")
+	if wid == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "wish id cannot be empty")
+	}
+
+	err = a.storage.UnreserveWish(c.Request().Context(), uid, wid)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "reservation not found")
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot remove reservation").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) ListReservedWishes(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	items, err := a.storage.ListReservedWishes(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list reserved wishes").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, items)
+}
@@ -0,0 +1,51 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveWishHandler(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	ownerAuth, err := testutils.AuthHelper(t, ts.Echo, 7001, "reserve_owner", "Owner")
+	require.NoError(t, err)
+	gifterAuth, err := testutils.AuthHelper(t, ts.Echo, 7002, "reserve_gifter", "Gifter")
+	require.NoError(t, err)
+	otherAuth, err := testutils.AuthHelper(t, ts.Echo, 7003, "reserve_other", "Other")
+	require.NoError(t, err)
+
+	catID := "cat_reserve"
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: catID, Name: "Reserve Cat", ImageURL: "url"}))
+
+	wishID := "wish_reserve"
+	wishName := "Reserve Me"
+	now := time.Now()
+	require.NoError(t, ts.Storage.CreateWish(context.Background(), db.Wish{
+		ID: wishID, UserID: ownerAuth.User.ID, Name: &wishName, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{catID}))
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/"+wishID+"/reserve", "", ownerAuth.Token, http.StatusBadRequest)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/"+wishID+"/reserve", "", gifterAuth.Token, http.StatusOK)
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/"+wishID+"/reserve", "", otherAuth.Token, http.StatusConflict)
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/reserved", "", gifterAuth.Token, http.StatusOK)
+	reserved := testutils.ParseResponse[[]db.Wish](t, rec)
+	require.Len(t, reserved, 1)
+	assert.Equal(t, wishID, reserved[0].ID)
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/"+wishID+"/reserve", "", otherAuth.Token, http.StatusNotFound)
+	testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/wishes/"+wishID+"/reserve", "", gifterAuth.Token, http.StatusOK)
+
+	rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/reserved", "", gifterAuth.Token, http.StatusOK)
+	reserved = testutils.ParseResponse[[]db.Wish](t, rec)
+	assert.Len(t, reserved, 0)
+}
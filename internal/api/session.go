@@ -0,0 +1,222 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/labstack/echo/v4"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/middleware"
+	"time"
+)
+
+const (
+	// accessTokenTTL is short because access tokens are checked against the
+	// session only by ID; the refresh token is what keeps a device signed in.
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	ErrInvalidRefreshToken = "invalid refresh token"
+	ErrSessionRevoked      = "session has been revoked"
+)
+
+// startSession opens a session for a freshly authenticated user and issues
+// its first token pair.
+func (a *API) startSession(c echo.Context, user db.User) (contract.TokenResponse, error) {
+	refreshToken, err := newSecretToken()
+	if err != nil {
+		return contract.TokenResponse{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to create session").WithInternal(err)
+	}
+
+	session := db.Session{
+		ID:        nanoid.Must(),
+		UserID:    user.ID,
+		UserAgent: optionalString(c.Request().UserAgent()),
+		IP:        optionalString(c.RealIP()),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
+
+	if err := a.storage.CreateSession(c.Request().Context(), session, hashToken(refreshToken)); err != nil {
+		return contract.TokenResponse{}, echo.NewHTTPError(http.StatusInternalServerError, "failed to create session").WithInternal(err)
+	}
+
+	token, err := generateJWT(user.ID, user.ChatID, user.LanguageCode, session.ID, a.cfg.JWTSecret)
+	if err != nil {
+		return contract.TokenResponse{}, echo.NewHTTPError(http.StatusInternalServerError, "jwt library error").WithInternal(err)
+	}
+
+	return contract.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token. The refresh
+// token is rotated on every call; sending an old one again revokes the session.
+func (a *API) RefreshToken(c echo.Context) error {
+	var req contract.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	refreshToken, err := newSecretToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to refresh session").WithInternal(err)
+	}
+
+	session, err := a.storage.RotateSession(
+		c.Request().Context(),
+		hashToken(req.RefreshToken),
+		hashToken(refreshToken),
+		optionalString(c.Request().UserAgent()),
+		optionalString(c.RealIP()),
+		time.Now().Add(refreshTokenTTL),
+	)
+	if errors.Is(err, db.ErrNotFound) || errors.Is(err, db.ErrTokenReused) {
+		return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidRefreshToken).WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to refresh session").WithInternal(err)
+	}
+
+	user, err := a.storage.GetUserByID(session.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, ErrInvalidRefreshToken).WithInternal(err)
+	}
+
+	token, err := generateJWT(user.ID, user.ChatID, user.LanguageCode, session.ID, a.cfg.JWTSecret)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "jwt library error").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, contract.TokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	})
+}
+
+// Logout revokes the session of the given refresh token. Unknown tokens are
+// ignored so signing out twice is not an error.
+func (a *API) Logout(c echo.Context) error {
+	var req contract.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	err := a.storage.RevokeSessionByToken(c.Request().Context(), hashToken(req.RefreshToken))
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke session").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+func (a *API) ListSessions(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := a.storage.ListSessions(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list sessions").WithInternal(err)
+	}
+
+	current := getSessionID(c)
+
+	resp := make([]contract.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, contract.SessionResponse{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			Current:    s.ID == current,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (a *API) RevokeSession(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	err = a.storage.RevokeSession(c.Request().Context(), uid, c.Param("id"))
+	if errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "session not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke session").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+// requireActiveSession rejects access tokens whose session was revoked or has
+// expired. It runs after the JWT middleware and lets anonymous requests through.
+func (a *API) requireActiveSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sid := getSessionID(c)
+		if sid == "" {
+			return next(c)
+		}
+
+		active, err := a.storage.IsSessionActive(c.Request().Context(), sid)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to check session").WithInternal(err)
+		}
+
+		if !active {
+			return echo.NewHTTPError(http.StatusUnauthorized, ErrSessionRevoked)
+		}
+
+		return next(c)
+	}
+}
+
+// getSessionID returns the session of the access token, or "" for anonymous
+// requests, API tokens and tokens issued before sessions existed.
+func getSessionID(c echo.Context) string {
+	if p, ok := middleware.GetPrincipal(c); ok {
+		return p.SessionID
+	}
+	return ""
+}
+
+func newSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
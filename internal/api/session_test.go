@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func refreshBody(token string) string {
+	return fmt.Sprintf(`{"refresh_token":"%s"}`, token)
+}
+
+func TestSessions(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	phone, err := testutils.AuthHelper(t, ts.Echo, 7401, "session_user", "Session")
+	require.NoError(t, err)
+	require.NotEmpty(t, phone.RefreshToken)
+	assert.Equal(t, 900, phone.ExpiresIn)
+
+	laptop, err := testutils.AuthHelper(t, ts.Echo, 7401, "session_user", "Session")
+	require.NoError(t, err)
+
+	t.Run("List sessions", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", phone.Token, http.StatusOK)
+		sessions := testutils.ParseResponse[[]contract.SessionResponse](t, rec)
+		require.Len(t, sessions, 2)
+
+		current := 0
+		for _, s := range sessions {
+			if s.Current {
+				current++
+			}
+		}
+		assert.Equal(t, 1, current)
+	})
+
+	t.Run("Refresh rotates the token", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/refresh", refreshBody(phone.RefreshToken), "", http.StatusOK)
+		refreshed := testutils.ParseResponse[contract.TokenResponse](t, rec)
+		require.NotEmpty(t, refreshed.Token)
+		require.NotEqual(t, phone.RefreshToken, refreshed.RefreshToken)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", refreshed.Token, http.StatusOK)
+
+		// Replaying the rotated token looks like theft and ends the session.
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/refresh", refreshBody(phone.RefreshToken), "", http.StatusUnauthorized)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", refreshed.Token, http.StatusUnauthorized)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/refresh", refreshBody(refreshed.RefreshToken), "", http.StatusUnauthorized)
+	})
+
+	t.Run("Revoke another device", func(t *testing.T) {
+		tablet, err := testutils.AuthHelper(t, ts.Echo, 7401, "session_user", "Session")
+		require.NoError(t, err)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", laptop.Token, http.StatusOK)
+		sessions := testutils.ParseResponse[[]contract.SessionResponse](t, rec)
+		require.Len(t, sessions, 2)
+
+		var tabletID string
+		for _, s := range sessions {
+			if !s.Current {
+				tabletID = s.ID
+			}
+		}
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/sessions/"+tabletID, "", laptop.Token, http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/sessions/"+tabletID, "", laptop.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", tablet.Token, http.StatusUnauthorized)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", laptop.Token, http.StatusOK)
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/logout", refreshBody(laptop.RefreshToken), "", http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/logout", refreshBody(laptop.RefreshToken), "", http.StatusOK)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", laptop.Token, http.StatusUnauthorized)
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/auth/refresh", refreshBody(laptop.RefreshToken), "", http.StatusUnauthorized)
+	})
+
+	t.Run("Another user's session", func(t *testing.T) {
+		other, err := testutils.AuthHelper(t, ts.Echo, 7402, "session_other", "Other")
+		require.NoError(t, err)
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", other.Token, http.StatusOK)
+		sessions := testutils.ParseResponse[[]contract.SessionResponse](t, rec)
+		require.Len(t, sessions, 1)
+
+		owner, err := testutils.AuthHelper(t, ts.Echo, 7401, "session_user", "Session")
+		require.NoError(t, err)
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodDelete, "/v1/user/sessions/"+sessions[0].ID, "", owner.Token, http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/user/sessions", "", other.Token, http.StatusOK)
+	})
+}
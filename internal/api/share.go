@@ -0,0 +1,351 @@
+package api
+
+import (
+	"embed"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/share.html
+var shareTemplates embed.FS
+
+var shareTemplate = template.Must(template.ParseFS(shareTemplates, "templates/share.html"))
+
+const (
+	shareProfileWishLimit = 20
+
+	// shareCacheControl lets link preview crawlers and CDNs reuse pages for a
+	// few minutes.
+	shareCacheControl = "public, max-age=300"
+)
+
+// sharePage is what share.html renders: a wish or a profile as seen by
+// someone outside Telegram.
+type sharePage struct {
+	Lang        string
+	Title       string
+	Description string
+	PageURL     string
+	ImageURL    string
+	CoverURL    string
+	AvatarURL   string
+	AppURL      string
+	OpenLabel   string
+	Items       []shareItem
+}
+
+type shareItem struct {
+	URL      string
+	Name     string
+	Price    string
+	ImageURL string
+}
+
+// ShareWishPage renders a public wish with OpenGraph tags for link previews.
+func (a *API) ShareWishPage(c echo.Context) error {
+	lang := shareLanguage(c)
+
+	wish, owner, err := a.getShareableWish(c)
+	if err != nil {
+		return err
+	}
+
+	description := []string{}
+	if price := formatWishPrice(wish); price != "" {
+		description = append(description, price)
+	}
+	description = append(description, i18n.T(lang, i18n.ShareWishOwner, displayName(owner)))
+
+	page := sharePage{
+		Lang:        lang,
+		Title:       wishTitle(lang, wish),
+		Description: strings.Join(description, " · "),
+		PageURL:     shareURL(c, "/w/"+wish.ID),
+		ImageURL:    shareURL(c, "/w/"+wish.ID+"/og.png"),
+		AppURL:      a.miniAppLink(startParamWish + "_" + wish.ID),
+		OpenLabel:   i18n.T(lang, i18n.ShareOpenWish),
+	}
+
+	if images := sortedImages(wish); len(images) > 0 {
+		page.CoverURL = a.assetURL(images[0].URL)
+	}
+
+	return renderSharePage(c, page)
+}
+
+// ShareWishCard renders the preview image referenced by the wish page.
+func (a *API) ShareWishCard(c echo.Context) error {
+	lang := shareLanguage(c)
+
+	wish, owner, err := a.getShareableWish(c)
+	if err != nil {
+		return err
+	}
+
+	card := ogCard{
+		Title:     wishTitle(lang, wish),
+		Subtitle:  formatWishPrice(wish),
+		OwnerName: displayName(owner),
+	}
+	if owner.AvatarURL != nil {
+		card.AvatarURL = *owner.AvatarURL
+	}
+	for _, img := range sortedImages(wish) {
+		card.ImageURLs = append(card.ImageURLs, a.assetURL(img.URL))
+	}
+
+	return a.serveOGCard(c, "wishes/"+wish.ID, lang, card)
+}
+
+// ShareProfilePage renders a user's public wishes with OpenGraph tags.
+func (a *API) ShareProfilePage(c echo.Context) error {
+	lang := shareLanguage(c)
+
+	user, wishes, err := a.getShareableProfile(c)
+	if err != nil {
+		return err
+	}
+
+	page := sharePage{
+		Lang:        lang,
+		Title:       displayName(user),
+		Description: i18n.T(lang, i18n.ShareProfileWishes, len(wishes)),
+		PageURL:     shareURL(c, "/u/"+user.Username),
+		ImageURL:    shareURL(c, "/u/"+user.Username+"/og.png"),
+		AppURL:      a.miniAppLink(startParamProfile + "_" + user.ID),
+		OpenLabel:   i18n.T(lang, i18n.ShareOpenProfile),
+	}
+
+	if user.AvatarURL != nil {
+		page.AvatarURL = *user.AvatarURL
+	}
+
+	for _, w := range wishes {
+		item := shareItem{
+			URL:   "/w/" + w.ID,
+			Name:  wishTitle(lang, w),
+			Price: formatWishPrice(w),
+		}
+		if images := sortedImages(w); len(images) > 0 {
+			item.ImageURL = a.assetURL(images[0].URL)
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	return renderSharePage(c, page)
+}
+
+// ShareProfileCard renders the preview image referenced by the profile page,
+// with a collage of the first public wishes.
+func (a *API) ShareProfileCard(c echo.Context) error {
+	lang := shareLanguage(c)
+
+	user, wishes, err := a.getShareableProfile(c)
+	if err != nil {
+		return err
+	}
+
+	card := ogCard{
+		Title:     displayName(user),
+		Subtitle:  i18n.T(lang, i18n.ShareProfileWishes, len(wishes)),
+		OwnerName: "@" + user.Username,
+	}
+	if user.AvatarURL != nil {
+		card.AvatarURL = *user.AvatarURL
+	}
+	for _, w := range wishes {
+		if images := sortedImages(w); len(images) > 0 {
+			card.ImageURLs = append(card.ImageURLs, a.assetURL(images[0].URL))
+		}
+		if len(card.ImageURLs) == cardCollageMax {
+			break
+		}
+	}
+
+	return a.serveOGCard(c, "profiles/"+user.ID, lang, card)
+}
+
+// ShareWishlistCard renders the preview image for a public wishlist, with a
+// collage of its first public wishes.
+func (a *API) ShareWishlistCard(c echo.Context) error {
+	lang := shareLanguage(c)
+
+	list, owner, wishes, err := a.getShareableWishlist(c)
+	if err != nil {
+		return err
+	}
+
+	card := ogCard{
+		Title:     list.Name,
+		Subtitle:  i18n.T(lang, i18n.ShareProfileWishes, len(wishes)),
+		OwnerName: displayName(owner),
+	}
+	if owner.AvatarURL != nil {
+		card.AvatarURL = *owner.AvatarURL
+	}
+	for _, w := range wishes {
+		if images := sortedImages(w); len(images) > 0 {
+			card.ImageURLs = append(card.ImageURLs, a.assetURL(images[0].URL))
+		}
+		if len(card.ImageURLs) == cardCollageMax {
+			break
+		}
+	}
+
+	return a.serveOGCard(c, "wishlists/"+list.ID, lang, card)
+}
+
+// getShareableWish loads the :id wish and its owner, answering 404 for wishes
+// that are not public.
+func (a *API) getShareableWish(c echo.Context) (db.Wish, db.User, error) {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	ok, err := a.storage.IsWishShareable(ctx, id)
+	if err != nil {
+		return db.Wish{}, db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist item").WithInternal(err)
+	}
+	if !ok {
+		return db.Wish{}, db.User{}, echo.NewHTTPError(http.StatusNotFound, "page not found")
+	}
+
+	wish, err := a.storage.GetWishByID(ctx, "", id)
+	if errors.Is(err, db.ErrNotFound) {
+		return db.Wish{}, db.User{}, echo.NewHTTPError(http.StatusNotFound, "page not found").WithInternal(err)
+	} else if err != nil {
+		return db.Wish{}, db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist item").WithInternal(err)
+	}
+
+	owner, err := a.storage.GetUserByID(wish.UserID)
+	if err != nil {
+		return db.Wish{}, db.User{}, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	return wish, owner, nil
+}
+
+// followUsernameChanges redirects profile links that use a previous username
+// to the current one.
+func (a *API) followUsernameChanges(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		username := c.Param("username")
+		if _, err := a.storage.GetUserByUsername(username); !errors.Is(err, db.ErrNotFound) {
+			return next(c)
+		}
+
+		user, err := a.storage.GetUserByPreviousUsername(username)
+		if err != nil {
+			return next(c)
+		}
+
+		suffix := strings.TrimPrefix(c.Path(), "/u/:username")
+		return c.Redirect(http.StatusMovedPermanently, "/u/"+url.PathEscape(user.Username)+suffix)
+	}
+}
+
+// getShareableProfile loads the :username user and their public wishes.
+func (a *API) getShareableProfile(c echo.Context) (db.User, []db.Wish, error) {
+	user, err := a.storage.GetUserByUsername(c.Param("username"))
+	if errors.Is(err, db.ErrNotFound) {
+		return db.User{}, nil, echo.NewHTTPError(http.StatusNotFound, "page not found").WithInternal(err)
+	} else if err != nil {
+		return db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	leaving, err := a.isPendingDeletion(c.Request().Context(), user, "")
+	if err != nil {
+		return db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+	if leaving {
+		return db.User{}, nil, echo.NewHTTPError(http.StatusNotFound, "page not found")
+	}
+
+	wishes, err := a.storage.ListShareableWishes(c.Request().Context(), user.ID, shareProfileWishLimit)
+	if err != nil {
+		return db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist items").WithInternal(err)
+	}
+
+	return user, wishes, nil
+}
+
+// getShareableWishlist loads the :id wishlist, its owner and its public
+// wishes, answering 404 for private wishlists and ones of private accounts.
+func (a *API) getShareableWishlist(c echo.Context) (db.Wishlist, db.User, []db.Wish, error) {
+	ctx := c.Request().Context()
+
+	list, err := a.storage.GetWishlistByID(ctx, c.Param("id"))
+	if errors.Is(err, db.ErrNotFound) {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusNotFound, "page not found").WithInternal(err)
+	} else if err != nil {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "could not get wishlist").WithInternal(err)
+	}
+
+	owner, err := a.storage.GetUserByID(list.UserID)
+	if errors.Is(err, db.ErrNotFound) {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusNotFound, "page not found").WithInternal(err)
+	} else if err != nil {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	leaving, err := a.isPendingDeletion(ctx, owner, "")
+	if err != nil {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	if !list.IsPublic || owner.IsPrivate || leaving {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusNotFound, "page not found")
+	}
+
+	wishes, err := a.storage.ListShareableWishlistWishes(ctx, list.ID, shareProfileWishLimit)
+	if err != nil {
+		return db.Wishlist{}, db.User{}, nil, echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist items").WithInternal(err)
+	}
+
+	return list, owner, wishes, nil
+}
+
+func renderSharePage(c echo.Context, page sharePage) error {
+	var b strings.Builder
+	if err := shareTemplate.Execute(&b, page); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to render page").WithInternal(err)
+	}
+
+	c.Response().Header().Set("Cache-Control", shareCacheControl)
+	c.Response().Header().Set("Vary", "Accept-Language")
+	return c.HTML(http.StatusOK, b.String())
+}
+
+// shareLanguage picks the page language from Accept-Language, since visitors
+// are usually not signed in.
+func shareLanguage(c echo.Context) string {
+	if lang := i18n.FromAcceptLanguage(c.Request().Header.Get("Accept-Language")); lang != "" {
+		return lang
+	}
+	return i18n.DefaultLanguage
+}
+
+func shareURL(c echo.Context, path string) string {
+	return c.Scheme() + "://" + c.Request().Host + path
+}
+
+func wishTitle(lang string, w db.Wish) string {
+	if w.Name != nil && *w.Name != "" {
+		return *w.Name
+	}
+	return i18n.T(lang, i18n.BotUntitled)
+}
+
+// sortedImages returns the wish's images ordered by position.
+func sortedImages(w db.Wish) []db.WishImage {
+	images := append([]db.WishImage(nil), w.Images...)
+	sort.Slice(images, func(i, j int) bool { return images[i].Position < images[j].Position })
+
+	return images
+}
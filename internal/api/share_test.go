@@ -0,0 +1,154 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharePages(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 7801, "share_owner", "Sharer")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	published, draft := "Shared Kettle", "Secret Draft"
+	price, currency := 1500.0, "RUB"
+
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_share", Name: "Share Cat", ImageURL: "url"}))
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_shared", UserID: owner.User.ID, Name: &published, Price: &price, Currency: &currency,
+		PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{"cat_share"}))
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_draft", UserID: owner.User.ID, Name: &draft, CreatedAt: now, UpdatedAt: now,
+	}, []string{"cat_share"}))
+
+	_, err = ts.Storage.CreateWishImage(ctx, db.WishImage{
+		ID: "img_shared", WishID: "wish_shared", URL: "wishes/kettle.jpg", Position: 1,
+	})
+	require.NoError(t, err)
+
+	t.Run("Published wish", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/wish_shared", "", "", http.StatusOK)
+		body := rec.Body.String()
+
+		assert.Contains(t, body, `<meta property="og:title" content="Shared Kettle">`)
+		assert.Contains(t, body, "1500 RUB")
+		assert.Contains(t, body, `<meta property="og:image" content="http://example.com/w/wish_shared/og.png">`)
+		assert.Contains(t, body, `<img class="cover" src="http://localhost/assets/wishes/kettle.jpg"`)
+		assert.Equal(t, "public, max-age=300", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Unpublished wish", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/wish_draft", "", "", http.StatusNotFound)
+	})
+
+	t.Run("Missing wish", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/missing", "", "", http.StatusNotFound)
+	})
+
+	t.Run("Profile lists only shared wishes", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/u/share_owner", "", "", http.StatusOK)
+		body := rec.Body.String()
+
+		assert.Contains(t, body, "Shared Kettle")
+		assert.NotContains(t, body, "Secret Draft")
+		assert.Contains(t, body, `href="/w/wish_shared"`)
+	})
+
+	t.Run("Unknown username", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/u/nobody_here", "", "", http.StatusNotFound)
+	})
+}
+
+func TestShareCards(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 7802, "card_owner", "Carder")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name, draft := "Card Lamp", "Card Draft"
+	wish := db.Wish{ID: "wish_card", UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now}
+
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_card", Name: "Card Cat", ImageURL: "url"}))
+	require.NoError(t, ts.Storage.CreateWish(ctx, wish, []string{"cat_card"}))
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_card_draft", UserID: owner.User.ID, Name: &draft, CreatedAt: now, UpdatedAt: now,
+	}, []string{"cat_card"}))
+
+	var etag string
+
+	t.Run("Wish card", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/wish_card/og.png", "", "", http.StatusOK)
+		assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+
+		img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 1200, img.Bounds().Dx())
+		assert.Equal(t, 630, img.Bounds().Dy())
+
+		etag = rec.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/w/wish_card/og.png", nil)
+		req.Header.Set("If-None-Match", etag)
+		rec := httptest.NewRecorder()
+		ts.Echo.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("Edits invalidate the card", func(t *testing.T) {
+		price := 99.0
+		wish.Price = &price
+		require.NoError(t, ts.Storage.UpdateWish(ctx, wish, []string{"cat_card"}))
+
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/wish_card/og.png", "", "", http.StatusOK)
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	})
+
+	t.Run("Unpublished wish", func(t *testing.T) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/w/wish_card_draft/og.png", "", "", http.StatusNotFound)
+	})
+
+	t.Run("Profile card", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/u/card_owner/og.png", "", "", http.StatusOK)
+
+		img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 1200, img.Bounds().Dx())
+	})
+
+	t.Run("Wishlist card", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishlists", `{"name":"Card Party"}`, owner.Token, http.StatusCreated)
+		public := testutils.ParseResponse[db.Wishlist](t, rec)
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishlists", `{"name":"Card Secret","is_public":false}`, owner.Token, http.StatusCreated)
+		private := testutils.ParseResponse[db.Wishlist](t, rec)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/l/"+public.ID+"/og.png", "", "", http.StatusOK)
+		img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 630, img.Bounds().Dy())
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/l/"+private.ID+"/og.png", "", "", http.StatusNotFound)
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/l/missing/og.png", "", "", http.StatusNotFound)
+	})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"strings"
+)
+
+// Start parameters are shared between bot deep links (/start <payload>) and
+// mini app links (?startapp=<payload>) and have the form "<kind>_<value>".
+const (
+	startParamReferral = "r"
+	startParamWish     = "w"
+	startParamProfile  = "u"
+)
+
+type startParam struct {
+	Kind  string
+	Value string
+	Raw   string
+}
+
+func parseStartParam(raw string) (startParam, bool) {
+	raw = strings.TrimSpace(raw)
+
+	kind, value, ok := strings.Cut(raw, "_")
+	if !ok || value == "" {
+		return startParam{}, false
+	}
+
+	switch kind {
+	case startParamReferral, startParamWish, startParamProfile:
+		return startParam{Kind: kind, Value: value, Raw: raw}, true
+	default:
+		return startParam{}, false
+	}
+}
+
+// target returns what the mini app should open for this start parameter, if anything.
+func (p startParam) target() *contract.StartTarget {
+	switch p.Kind {
+	case startParamWish:
+		return &contract.StartTarget{Type: contract.StartTargetWish, ID: p.Value}
+	case startParamProfile:
+		return &contract.StartTarget{Type: contract.StartTargetProfile, ID: p.Value}
+	default:
+		return nil
+	}
+}
+
+// findReferrer resolves who brought a new user in. Shared profiles and wishes
+// count as invitations from their owner.
+func (a *API) findReferrer(ctx context.Context, p startParam) (*db.User, error) {
+	var (
+		user db.User
+		err  error
+	)
+
+	switch p.Kind {
+	case startParamReferral:
+		user, err = a.storage.GetUserByReferralCode(p.Value)
+	case startParamProfile:
+		user, err = a.storage.GetUserByID(p.Value)
+	case startParamWish:
+		var wish db.Wish
+		wish, err = a.storage.GetWishByID(ctx, "", p.Value)
+		if err == nil {
+			user, err = a.storage.GetUserByID(wish.UserID)
+		}
+	default:
+		return nil, nil
+	}
+
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// attachReferrer sets ReferredBy on a user that is about to be created and
+// returns the referrer so the conversion can be recorded afterwards.
+func (a *API) attachReferrer(ctx context.Context, user *db.User, p startParam) *db.User {
+	referrer, err := a.findReferrer(ctx, p)
+	if err != nil {
+		log.Printf("Failed to resolve referrer for %q: %v", p.Raw, err)
+		return nil
+	}
+
+	if referrer == nil {
+		return nil
+	}
+
+	user.ReferredBy = &referrer.ReferralCode
+	return referrer
+}
+
+func (a *API) recordReferral(ctx context.Context, referrer *db.User, user db.User, source string, p startParam) {
+	if referrer == nil {
+		return
+	}
+
+	ref := db.Referral{
+		ReferrerID: referrer.ID,
+		ReferredID: user.ID,
+		Source:     source,
+		StartParam: &p.Raw,
+	}
+
+	if err := a.storage.CreateReferral(ctx, ref); err != nil && !errors.Is(err, db.ErrAlreadyExists) {
+		log.Printf("Failed to record referral: %v", err)
+	}
+}
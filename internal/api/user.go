@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"sacred/internal/contract"
 	"sacred/internal/db"
+	"sacred/internal/middleware"
+	"strings"
+	"time"
 )
 
 func (a *API) UpdateUserPreferences(c echo.Context) error {
@@ -19,7 +22,9 @@ func (a *API) UpdateUserPreferences(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
 	}
 
-	if err := req.Validate(); err != nil {
+	if err := req.Validate(); errors.Is(err, contract.ErrInvalidUsername) || errors.Is(err, contract.ErrReservedUsername) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "failed to validate request")
 	}
 
@@ -28,7 +33,26 @@ func (a *API) UpdateUserPreferences(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
 	}
 
-	user.Email = &req.Email
+	if req.Username != nil && !strings.EqualFold(*req.Username, user.Username) {
+		taken, err := a.storage.IsUsernameTaken(c.Request().Context(), *req.Username, uid)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "cannot check username").WithInternal(err)
+		}
+		if taken {
+			return echo.NewHTTPError(http.StatusConflict, "username is already taken")
+		}
+	}
+
+	// A new email has to be confirmed before it can be used to log in, and
+	// only a signed-in session may start that.
+	emailChanged := user.Email == nil || !strings.EqualFold(*user.Email, req.Email)
+	if emailChanged {
+		if p, ok := middleware.GetPrincipal(c); ok && p.APITokenID != "" {
+			return echo.NewHTTPError(http.StatusForbidden, "not allowed with an api token")
+		}
+
+		user.Email = &req.Email
+	}
 
 	if req.Name != nil {
 		user.Name = req.Name
@@ -38,15 +62,56 @@ func (a *API) UpdateUserPreferences(c echo.Context) error {
 		user.Username = *req.Username
 	}
 
-	if err := a.storage.UpdateUser(c.Request().Context(), user, req.Interests); err != nil {
+	if req.LanguageCode != nil {
+		user.LanguageCode = *req.LanguageCode
+	}
+
+	if req.Birthday != nil {
+		if *req.Birthday == "" {
+			user.Birthday = nil
+		} else {
+			user.Birthday = req.Birthday
+		}
+	}
+
+	if req.Timezone != nil {
+		if *req.Timezone == "" {
+			user.Timezone = nil
+		} else {
+			user.Timezone = req.Timezone
+		}
+	}
+
+	wasPrivate := user.IsPrivate
+	if req.IsPrivate != nil {
+		user.IsPrivate = *req.IsPrivate
+	}
+
+	err = a.storage.UpdateUser(c.Request().Context(), user, req.Interests)
+	if err != nil && errors.Is(err, db.ErrAlreadyExists) {
+		return echo.NewHTTPError(http.StatusConflict, "username is already taken").WithInternal(err)
+	} else if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot update user").WithInternal(err)
 	}
 
+	// Nobody is left waiting once the account is public again.
+	if wasPrivate && !user.IsPrivate {
+		if err := a.storage.AcceptAllFollowRequests(c.Request().Context(), uid); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "cannot update user").WithInternal(err)
+		}
+	}
+
 	updated, err := a.storage.GetUserByID(uid)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get updated user").WithInternal(err)
 	}
 
+	if emailChanged {
+		if err := a.sendEmailConfirmation(c.Request().Context(), updated); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "cannot send email confirmation").WithInternal(err)
+		}
+	}
+
 	return c.JSON(http.StatusOK, updated)
 }
 
@@ -88,26 +153,100 @@ func (a *API) GetUserProfile(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "user id cannot be empty")
 	}
 
-	currentUserID, err := getUserID(c)
-	if err != nil {
-		return err
-	}
+	currentUserID, _ := getUserID(c) // guests can see public profiles
 
 	user, err := a.storage.GetUserByID(profileID)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	return a.respondWithProfile(c, currentUserID, user)
+}
+
+// GetUserProfileByUsername looks a profile up by its current or a previous
+// username, ignoring case.
+func (a *API) GetUserProfileByUsername(c echo.Context) error {
+	username := c.Param("username")
+	currentUserID, _ := getUserID(c) // guests can see public profiles
+
+	user, err := a.storage.GetUserByUsername(username)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		user, err = a.storage.GetUserByPreviousUsername(username)
+	}
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	return a.respondWithProfile(c, currentUserID, user)
+}
+
+// respondWithProfile writes the profile as seen by currentUserID.
+func (a *API) respondWithProfile(c echo.Context, currentUserID string, user db.User) error {
+	leaving, err := a.isPendingDeletion(c.Request().Context(), user, currentUserID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
 	}
+	if leaving {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	isBlocked, err := a.storage.HasBlocked(c.Request().Context(), currentUserID, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
 
-	items, err := a.storage.GetWishesByUserID(c.Request().Context(), profileID)
+	// Users who blocked the viewer look like they do not exist.
+	blockedBy, err := a.storage.HasBlocked(c.Request().Context(), user.ID, currentUserID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist items").WithInternal(err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
+	if blockedBy {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	isMuted, err := a.storage.IsMuted(c.Request().Context(), currentUserID, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check mute status").WithInternal(err)
 	}
 
-	isFollowing, err := a.storage.IsFollowing(c.Request().Context(), currentUserID, profileID)
+	isFollowing, err := a.storage.IsFollowing(c.Request().Context(), currentUserID, user.ID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check following status").WithInternal(err)
 	}
 
+	isRequested, err := a.storage.HasFollowRequest(c.Request().Context(), currentUserID, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check following status").WithInternal(err)
+	}
+
+	followers, following, err := a.storage.CountFollows(c.Request().Context(), currentUserID, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot count followers").WithInternal(err)
+	}
+
+	// Private accounts show their wishes to approved followers only.
+	canSeeWishes := !user.IsPrivate || isFollowing || currentUserID == user.ID
+
+	items := []db.Wish{}
+	if !isBlocked && canSeeWishes {
+		items, err = a.storage.GetWishesByUserID(c.Request().Context(), currentUserID, user.ID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist items").WithInternal(err)
+		}
+	}
+
+	// Followers are the ones reminded of a birthday, so only they see it, and
+	// without the year.
+	var birthday *string
+	if user.Birthday != nil && len(*user.Birthday) == len(time.DateOnly) && !isBlocked && (isFollowing || currentUserID == user.ID) {
+		monthDay := (*user.Birthday)[5:]
+		birthday = &monthDay
+	}
+
 	resp := contract.UserProfileResponse{
 		ID:          user.ID,
 		Name:        user.Name,
@@ -115,9 +254,15 @@ func (a *API) GetUserProfile(c echo.Context) error {
 		CreatedAt:   user.CreatedAt,
 		Interests:   user.Interests,
 		AvatarURL:   user.AvatarURL,
-		Followers:   user.Followers,
+		Birthday:    birthday,
+		Followers:   followers,
+		Following:   following,
 		SavedItems:  items,
 		IsFollowing: isFollowing,
+		IsBlocked:   isBlocked,
+		IsMuted:     isMuted,
+		IsPrivate:   user.IsPrivate,
+		IsRequested: isRequested,
 	}
 
 	return c.JSON(http.StatusOK, resp)
@@ -136,9 +281,12 @@ func (a *API) ListProfiles(c echo.Context) error {
 
 	resp := make([]contract.UserProfileResponse, 0, len(users))
 	for _, user := range users {
-		items, err := a.storage.GetWishesByUserID(c.Request().Context(), user.ID)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist items").WithInternal(err)
+		items := []db.Wish{}
+		if !user.IsPrivate || user.IsFollowing {
+			items, err = a.storage.GetWishesByUserID(c.Request().Context(), uid, user.ID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist items").WithInternal(err)
+			}
 		}
 
 		resp = append(resp, contract.UserProfileResponse{
@@ -149,8 +297,10 @@ func (a *API) ListProfiles(c echo.Context) error {
 			Interests:   user.Interests,
 			AvatarURL:   user.AvatarURL,
 			Followers:   user.Followers,
+			Following:   user.Following,
 			SavedItems:  items,
 			IsFollowing: user.IsFollowing,
+			IsPrivate:   user.IsPrivate,
 		})
 	}
 
@@ -176,6 +326,11 @@ func (a *API) UnfollowUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "could not unfollow user").WithInternal(err)
 	}
 
+	// Unfollowing also withdraws a request that is still pending.
+	if err := a.storage.DeleteFollowRequest(c.Request().Context(), uid, req.FollowingID); err != nil && !errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not unfollow user").WithInternal(err)
+	}
+
 	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
 }
 
@@ -194,16 +349,41 @@ func (a *API) FollowUser(c echo.Context) error {
 		return err
 	}
 
+	blocked, err := a.storage.IsBlocked(c.Request().Context(), uid, req.FollowingID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot check block status").WithInternal(err)
+	}
+	if blocked {
+		return echo.NewHTTPError(http.StatusForbidden, "cannot follow this user")
+	}
+
 	isFollowing, _ := a.storage.IsFollowing(c.Request().Context(), uid, req.FollowingID)
 	if isFollowing {
 		return echo.NewHTTPError(http.StatusConflict, "already following this user")
 	}
 
+	target, err := a.storage.GetUserByID(req.FollowingID)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get user").WithInternal(err)
+	}
+
+	if target.IsPrivate {
+		return a.requestFollow(c, uid, target.ID)
+	}
+
 	if err := a.storage.FollowUser(c.Request().Context(), uid, req.FollowingID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "could not follow user").WithInternal(err)
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+	a.notify(c.Request().Context(), notificationEvent{
+		Type:       db.NotificationTypeFollow,
+		ActorID:    uid,
+		Recipients: []string{req.FollowingID},
+	})
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK", "status": followStatusFollowing})
 }
 
 func (a *API) SaveWishToBookmarks(c echo.Context) error {
@@ -237,6 +417,13 @@ func (a *API) SaveWishToBookmarks(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Cannot create copied wish").WithInternal(err)
 	}
 
+	a.notify(c.Request().Context(), notificationEvent{
+		Type:       db.NotificationTypeBookmark,
+		ActorID:    uid,
+		WishID:     &wish.ID,
+		Recipients: []string{wish.UserID},
+	})
+
 	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
 }
 
@@ -272,3 +459,26 @@ func (a *API) ListBookmarkedWishes(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, items)
 }
+
+func (a *API) ListReferrals(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	referrals, err := a.storage.ListReferrals(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list referrals").WithInternal(err)
+	}
+
+	resp := make([]contract.ReferralResponse, 0, len(referrals))
+	for _, r := range referrals {
+		resp = append(resp, contract.ReferralResponse{
+			User:     contract.ToShortUserProfile(r.User),
+			Source:   r.Source,
+			JoinedAt: r.JoinedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
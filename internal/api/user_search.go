@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultUserSuggestions = 20
+	maxUserSuggestions     = 50
+)
+
+// SearchUsers finds people by username or name.
+func (a *API) SearchUsers(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	search := strings.TrimSpace(c.QueryParam("q"))
+	if search == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "search query cannot be empty")
+	}
+
+	limit, offset := pageParams(c)
+
+	users, err := a.storage.SearchUsers(c.Request().Context(), uid, search, limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not search users").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toFollowListUsers(users))
+}
+
+// SuggestUsers recommends people to follow based on mutual follows, shared
+// interests and bookmarks.
+func (a *API) SuggestUsers(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultUserSuggestions
+	}
+	if limit > maxUserSuggestions {
+		limit = maxUserSuggestions
+	}
+
+	users, err := a.storage.SuggestUsers(c.Request().Context(), uid, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not suggest users").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, toFollowListUsers(users))
+}
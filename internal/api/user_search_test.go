@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSearch(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	viewer, err := testutils.AuthHelper(t, ts.Echo, 8301, "search_viewer", "Viewer")
+	require.NoError(t, err)
+	alice, err := testutils.AuthHelper(t, ts.Echo, 8302, "alice_wonder", "Alice Liddell")
+	require.NoError(t, err)
+	alicia, err := testutils.AuthHelper(t, ts.Echo, 8303, "alicia", "Alicia Keys")
+	require.NoError(t, err)
+	_, err = testutils.AuthHelper(t, ts.Echo, 8304, "bob", "Bob Builder")
+	require.NoError(t, err)
+
+	search := func(q string) []contract.FollowListUser {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/users/search?q="+q, "", viewer.Token, http.StatusOK)
+		return testutils.ParseResponse[[]contract.FollowListUser](t, rec)
+	}
+	ids := func(users []contract.FollowListUser) []string {
+		var res []string
+		for _, u := range users {
+			res = append(res, u.ID)
+		}
+		return res
+	}
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/users/search?q=", "", viewer.Token, http.StatusBadRequest)
+
+	assert.ElementsMatch(t, []string{alice.User.ID, alicia.User.ID}, ids(search("ali")))
+	assert.Equal(t, []string{alice.User.ID}, ids(search("alice_w")))
+	assert.Equal(t, []string{alicia.User.ID}, ids(search("keys")))
+	assert.Empty(t, search("viewer"), "the viewer is not in their own results")
+	assert.Empty(t, search(`%22%29%28`), "FTS5 syntax is escaped")
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+alice.User.ID+`"}`, viewer.Token, http.StatusOK)
+	results := search("alice")
+	require.Len(t, results, 1)
+	assert.True(t, results[0].IsFollowing)
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/block", `{"user_id":"`+alicia.User.ID+`"}`, viewer.Token, http.StatusOK)
+	assert.Equal(t, []string{alice.User.ID}, ids(search("ali")))
+
+	t.Run("Renamed users are found by their new name", func(t *testing.T) {
+		user, err := ts.Storage.GetUserByID(alice.User.ID)
+		require.NoError(t, err)
+		name := "Queen of Hearts"
+		user.Name = &name
+		require.NoError(t, ts.Storage.UpdateUser(context.Background(), user, nil))
+
+		assert.Equal(t, []string{alice.User.ID}, ids(search("hearts")))
+		assert.Empty(t, search("liddell"))
+	})
+}
+
+func TestUserSuggestions(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	me, err := testutils.AuthHelper(t, ts.Echo, 8401, "suggest_me", "Me")
+	require.NoError(t, err)
+	friend, err := testutils.AuthHelper(t, ts.Echo, 8402, "suggest_friend", "Friend")
+	require.NoError(t, err)
+	mutual, err := testutils.AuthHelper(t, ts.Echo, 8403, "suggest_mutual", "Mutual")
+	require.NoError(t, err)
+	hobbyist, err := testutils.AuthHelper(t, ts.Echo, 8404, "suggest_hobbyist", "Hobbyist")
+	require.NoError(t, err)
+	collector, err := testutils.AuthHelper(t, ts.Echo, 8405, "suggest_collector", "Collector")
+	require.NoError(t, err)
+	_, err = testutils.AuthHelper(t, ts.Echo, 8406, "suggest_stranger", "Stranger")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_suggest", Name: "Suggest Cat", ImageURL: "url"}))
+	name := "Shared wish"
+	require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+		ID: "wish_suggest", UserID: friend.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, []string{"cat_suggest"}))
+
+	follow := func(token, id string) {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+id+`"}`, token, http.StatusOK)
+	}
+	follow(me.Token, friend.User.ID)
+	follow(friend.Token, mutual.User.ID)
+
+	for _, token := range []string{me.Token, hobbyist.Token} {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings", `{"interests":["cat_suggest"],"email":"x@example.com"}`, token, http.StatusOK)
+	}
+	for _, token := range []string{me.Token, collector.Token} {
+		testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_suggest/bookmark", "", token, http.StatusOK)
+	}
+
+	rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/users/suggestions", "", me.Token, http.StatusOK)
+	var ids []string
+	for _, u := range testutils.ParseResponse[[]contract.FollowListUser](t, rec) {
+		ids = append(ids, u.ID)
+	}
+
+	// Mutual follows weigh most, then shared interests, then bookmarks; people
+	// already followed and people with nothing in common are left out.
+	assert.Equal(t, []string{mutual.User.ID, hobbyist.User.ID, collector.User.ID}, ids)
+}
@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernames(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	alice, err := testutils.AuthHelper(t, ts.Echo, 8501, "alice", "Alice")
+	require.NoError(t, err)
+	bob, err := testutils.AuthHelper(t, ts.Echo, 8502, "bob_builder", "Bob")
+	require.NoError(t, err)
+
+	require.NoError(t, ts.Storage.CreateCategory(context.Background(), db.Category{ID: "cat_username", Name: "Username Cat", ImageURL: "url"}))
+
+	rename := func(token, username string, status int) {
+		body := `{"interests":["cat_username"],"email":"x@example.com","username":"` + username + `"}`
+		testutils.PerformRequest(t, ts.Echo, http.MethodPut, "/v1/user/settings", body, token, status)
+	}
+	profileByUsername := func(username string, status int) contract.UserProfileResponse {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/by-username/"+username, "", "", status)
+		if status != http.StatusOK {
+			return contract.UserProfileResponse{}
+		}
+		return testutils.ParseResponse[contract.UserProfileResponse](t, rec)
+	}
+
+	t.Run("Policy", func(t *testing.T) {
+		for _, username := range []string{"ab", "1alice", "with-dash", "way_too_long_for_a_telegram_handle", ""} {
+			rename(bob.Token, username, http.StatusBadRequest)
+		}
+		rename(bob.Token, "Admin", http.StatusBadRequest)
+	})
+
+	t.Run("Unique ignoring case", func(t *testing.T) {
+		rename(bob.Token, "ALICE", http.StatusConflict)
+		rename(alice.Token, "Alice", http.StatusOK)
+		assert.Equal(t, alice.User.ID, profileByUsername("aLiCe", http.StatusOK).ID)
+	})
+
+	t.Run("Rename keeps old links working", func(t *testing.T) {
+		rename(bob.Token, "robert", http.StatusOK)
+
+		profile := profileByUsername("robert", http.StatusOK)
+		assert.Equal(t, bob.User.ID, profile.ID)
+		assert.Equal(t, "robert", profile.Username)
+		assert.Equal(t, bob.User.ID, profileByUsername("Bob_Builder", http.StatusOK).ID)
+		profileByUsername("nobody_here", http.StatusNotFound)
+
+		for path, location := range map[string]string{
+			"/u/bob_builder":        "/u/robert",
+			"/u/bob_builder/og.png": "/u/robert/og.png",
+		} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			rec := httptest.NewRecorder()
+			ts.Echo.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusMovedPermanently, rec.Code, path)
+			assert.Equal(t, location, rec.Header().Get("Location"), path)
+		}
+	})
+
+	t.Run("Freed usernames can be claimed", func(t *testing.T) {
+		rename(alice.Token, "bob_builder", http.StatusOK)
+		assert.Equal(t, alice.User.ID, profileByUsername("bob_builder", http.StatusOK).ID)
+		assert.Equal(t, alice.User.ID, profileByUsername("alice", http.StatusOK).ID, "alice is in the history now")
+	})
+
+	t.Run("Registration falls back when the Telegram username is taken", func(t *testing.T) {
+		carol, err := testutils.AuthHelper(t, ts.Echo, 8503, "robert", "Carol")
+		require.NoError(t, err)
+		assert.Equal(t, "user_8503", carol.User.Username)
+	})
+
+	t.Run("Registration applies the username policy", func(t *testing.T) {
+		dave, err := testutils.AuthHelper(t, ts.Echo, 8504, "Support", "Dave")
+		require.NoError(t, err)
+		assert.Equal(t, "user_8504", dave.User.Username)
+	})
+
+	t.Run("Generated usernames are numbered when taken", func(t *testing.T) {
+		rename(bob.Token, "user_8505", http.StatusOK)
+
+		erin, err := testutils.AuthHelper(t, ts.Echo, 8505, "bob_builder", "Erin")
+		require.NoError(t, err)
+		assert.Equal(t, "user_8505_2", erin.User.Username)
+	})
+}
@@ -0,0 +1,168 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishVisibility(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 8101, "vis_owner", "Owner")
+	require.NoError(t, err)
+	follower, err := testutils.AuthHelper(t, ts.Echo, 8102, "vis_follower", "Follower")
+	require.NoError(t, err)
+	stranger, err := testutils.AuthHelper(t, ts.Echo, 8103, "vis_stranger", "Stranger")
+	require.NoError(t, err)
+
+	testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/users/follow", `{"following_id":"`+owner.User.ID+`"}`, follower.Token, http.StatusOK)
+
+	ctx := context.Background()
+	now := time.Now()
+	shareToken := "vis_share_token"
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_vis", Name: "Visibility Cat", ImageURL: "url"}))
+	for _, visibility := range db.WishVisibilities {
+		name := "wish_" + visibility
+		wish := db.Wish{
+			ID: name, UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+			Visibility: visibility,
+		}
+		if visibility == db.WishVisibilityLink {
+			wish.ShareToken = &shareToken
+		}
+		testutils.CreateListedWish(t, ts.Storage, wish, "cat_vis")
+	}
+
+	viewers := map[string]string{
+		"owner":    owner.Token,
+		"follower": follower.Token,
+		"stranger": stranger.Token,
+		"guest":    "",
+	}
+
+	feedHas := func(token, id string) bool {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", token, http.StatusOK)
+		for _, item := range testutils.ParseResponse[[]contract.FeedItem](t, rec) {
+			if item.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	tests := []struct {
+		visibility string
+		viewer     string
+		canOpen    bool
+		inFeed     bool
+	}{
+		{db.WishVisibilityPublic, "owner", true, false},
+		{db.WishVisibilityPublic, "follower", true, true},
+		{db.WishVisibilityPublic, "stranger", true, true},
+		{db.WishVisibilityPublic, "guest", true, true},
+		{db.WishVisibilityFollowers, "owner", true, false},
+		{db.WishVisibilityFollowers, "follower", true, true},
+		{db.WishVisibilityFollowers, "stranger", false, false},
+		{db.WishVisibilityFollowers, "guest", false, false},
+		{db.WishVisibilityPrivate, "owner", true, false},
+		{db.WishVisibilityPrivate, "follower", false, false},
+		{db.WishVisibilityPrivate, "stranger", false, false},
+		{db.WishVisibilityPrivate, "guest", false, false},
+		{db.WishVisibilityLink, "owner", true, false},
+		{db.WishVisibilityLink, "follower", false, false},
+		{db.WishVisibilityLink, "stranger", false, false},
+		{db.WishVisibilityLink, "guest", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.visibility+"/"+tt.viewer, func(t *testing.T) {
+			token := viewers[tt.viewer]
+			id := "wish_" + tt.visibility
+
+			status := http.StatusNotFound
+			if tt.canOpen {
+				status = http.StatusOK
+			}
+			testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/"+id, "", token, status)
+
+			assert.Equal(t, tt.inFeed, feedHas(token, id))
+
+			rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+owner.User.ID, "", token, http.StatusOK)
+			var listed bool
+			for _, w := range testutils.ParseResponse[contract.UserProfileResponse](t, rec).SavedItems {
+				listed = listed || w.ID == id
+			}
+			assert.Equal(t, tt.canOpen, listed)
+		})
+	}
+
+	t.Run("Wishes without a photo stay out of listings", func(t *testing.T) {
+		name := "wish_no_photo"
+		require.NoError(t, ts.Storage.CreateWish(ctx, db.Wish{
+			ID: name, UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+		}, []string{"cat_vis"}))
+
+		assert.False(t, feedHas(stranger.Token, name))
+
+		for token, expected := range map[string]bool{owner.Token: true, stranger.Token: false} {
+			rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/profiles/"+owner.User.ID, "", token, http.StatusOK)
+			var listed bool
+			for _, w := range testutils.ParseResponse[contract.UserProfileResponse](t, rec).SavedItems {
+				listed = listed || w.ID == name
+			}
+			assert.Equal(t, expected, listed)
+		}
+
+		require.NoError(t, ts.Storage.DeleteWish(ctx, owner.User.ID, name))
+	})
+
+	t.Run("Share token", func(t *testing.T) {
+		for viewer, token := range viewers {
+			rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/shared/"+shareToken, "", token, http.StatusOK)
+			wish := testutils.ParseResponse[contract.WishResponse](t, rec).Wish
+			assert.Equal(t, "wish_link", wish.ID)
+			if viewer == "owner" {
+				require.NotNil(t, wish.ShareToken)
+				assert.Equal(t, shareToken, *wish.ShareToken)
+			} else {
+				assert.Nil(t, wish.ShareToken, viewer)
+			}
+		}
+
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/shared/unknown", "", stranger.Token, http.StatusNotFound)
+	})
+
+	t.Run("Share token stops working once the wish is no longer link-only", func(t *testing.T) {
+		wish, err := ts.Storage.GetWishByID(ctx, owner.User.ID, "wish_link")
+		require.NoError(t, err)
+
+		wish.Visibility = db.WishVisibilityPrivate
+		require.NoError(t, ts.Storage.UpdateWish(ctx, wish, []string{"cat_vis"}))
+		testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/shared/"+shareToken, "", stranger.Token, http.StatusNotFound)
+
+		updated, err := ts.Storage.GetWishByID(ctx, owner.User.ID, "wish_link")
+		require.NoError(t, err)
+		require.NotNil(t, updated.PublishedAt)
+		assert.True(t, wish.PublishedAt.Equal(*updated.PublishedAt), "update keeps the publish time")
+
+		updated.Visibility = ""
+		require.NoError(t, ts.Storage.UpdateWish(ctx, updated, []string{"cat_vis"}))
+		kept, err := ts.Storage.GetWishByID(ctx, owner.User.ID, "wish_link")
+		require.NoError(t, err)
+		assert.Equal(t, db.WishVisibilityPrivate, kept.Visibility, "an empty visibility keeps the stored one")
+	})
+
+	t.Run("Savers of a hidden wish", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/wishes/wish_private/savers", "", stranger.Token, http.StatusOK)
+		assert.Equal(t, 0, testutils.ParseResponse[contract.WishSaversResponse](t, rec).Total)
+	})
+}
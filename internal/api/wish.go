@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
@@ -23,6 +24,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type ExtractContentResponse struct {
@@ -196,6 +198,22 @@ func getFormFloat(form *multipart.Form, key string) (*float64, error) {
 	return nil, nil
 }
 
+func getFormInt(form *multipart.Form, key string) (*int, error) {
+	values := form.Value[key]
+	if len(values) > 0 && values[0] != "" {
+		valueStr := strings.TrimSpace(values[0])
+		if valueStr == "" {
+			return nil, nil
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer value for '%s': %s", key, valueStr)
+		}
+		return &value, nil
+	}
+	return nil, nil
+}
+
 func getFormStringSlice(form *multipart.Form, key string) []string {
 	var result []string
 	values := form.Value[key]
@@ -237,12 +255,87 @@ func (a *API) parseAndPopulateWish(form *multipart.Form, userID string) (wish db
 	wish.Currency = getFormString(form, "currency", true) // Trim currency
 	wish.Notes = getFormString(form, "notes", true)       // Trim notes
 
+	if visibility := getFormString(form, "visibility", true); visibility != nil {
+		if !db.IsWishVisibility(*visibility) {
+			err = echo.NewHTTPError(http.StatusBadRequest, "invalid visibility")
+			return
+		}
+		wish.Visibility = *visibility
+	}
+
+	if priority := getFormString(form, "priority", true); priority != nil {
+		wish.Priority = *priority
+	}
+
+	// Received and is_fulfilled follow the recorded gifts and are changed
+	// through the fulfill endpoints only.
+	if _, ok := form.Value["is_fulfilled"]; ok {
+		err = echo.NewHTTPError(http.StatusBadRequest, "is_fulfilled cannot be set directly")
+		return
+	}
+
+	quantity, parseErr := getFormInt(form, "quantity")
+	if parseErr != nil {
+		err = echo.NewHTTPError(http.StatusBadRequest, parseErr.Error())
+		return
+	}
+	if quantity != nil {
+		wish.Quantity = *quantity
+	}
+
+	// Attributes come as a JSON array of {"key", "value"} objects, in the
+	// order they should be shown.
+	wish.Attributes = []db.WishAttribute{}
+	if attributes := getFormString(form, "attributes", true); attributes != nil {
+		if parseErr := json.Unmarshal([]byte(*attributes), &wish.Attributes); parseErr != nil {
+			err = echo.NewHTTPError(http.StatusBadRequest, "invalid attributes").WithInternal(parseErr)
+			return
+		}
+		for i := range wish.Attributes {
+			wish.Attributes[i].Key = strings.TrimSpace(wish.Attributes[i].Key)
+			wish.Attributes[i].Value = strings.TrimSpace(wish.Attributes[i].Value)
+		}
+	}
+
 	categoryIDs = getFormStringSlice(form, "category_ids")
 	imageURLs = getFormStringSlice(form, "image_urls")
 
 	return
 }
 
+// keepWishDetails copies the priority, quantity and attributes the form
+// leaves out from base, so clients that do not send them keep them as they
+// were. Received always comes from base.
+func keepWishDetails(form *multipart.Form, wish *db.Wish, base db.Wish) {
+	if _, ok := form.Value["priority"]; !ok {
+		wish.Priority = base.Priority
+	}
+	if _, ok := form.Value["quantity"]; !ok {
+		wish.Quantity = base.Quantity
+	}
+	wish.Received = base.Received
+	if _, ok := form.Value["attributes"]; !ok && base.Attributes != nil {
+		wish.Attributes = base.Attributes
+	}
+}
+
+// ensureShareToken gives a link-only wish the token its link is built from.
+// The token is kept when the wish changes visibility, so switching back to
+// link-only revives the links already handed out.
+func ensureShareToken(wish *db.Wish) error {
+	if wish.Visibility != db.WishVisibilityLink || wish.ShareToken != nil {
+		return nil
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot generate share token").WithInternal(err)
+	}
+	wish.ShareToken = &token
+
+	return nil
+}
+
 func (a *API) validateWishCreation(wish *db.Wish, categoryIDs []string) error {
 	// Name validation
 	if wish.Name == nil || *wish.Name == "" {
@@ -284,6 +377,36 @@ func (a *API) validateWishCreation(wish *db.Wish, categoryIDs []string) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "notes cannot be longer than 1000 characters")
 	}
 
+	// Priority and quantity validation
+	if wish.Priority != "" && !db.IsWishPriority(wish.Priority) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid priority")
+	}
+	if wish.Quantity < 1 || wish.Quantity > 99 {
+		return echo.NewHTTPError(http.StatusBadRequest, "quantity must be between 1 and 99")
+	}
+	if wish.Quantity < wish.Received {
+		return echo.NewHTTPError(http.StatusBadRequest, "quantity cannot be less than received")
+	}
+
+	// Attributes validation
+	if len(wish.Attributes) > 20 {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot have more than 20 attributes")
+	}
+	seenKeys := make(map[string]bool, len(wish.Attributes))
+	for _, attr := range wish.Attributes {
+		if attr.Key == "" || utf8.RuneCountInString(attr.Key) > 50 {
+			return echo.NewHTTPError(http.StatusBadRequest, "attribute key must be 1 to 50 characters long")
+		}
+		if attr.Value == "" || utf8.RuneCountInString(attr.Value) > 200 {
+			return echo.NewHTTPError(http.StatusBadRequest, "attribute value must be 1 to 200 characters long")
+		}
+		key := strings.ToLower(attr.Key)
+		if seenKeys[key] {
+			return echo.NewHTTPError(http.StatusBadRequest, "duplicate attribute key")
+		}
+		seenKeys[key] = true
+	}
+
 	// Category IDs validation
 	if len(categoryIDs) == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "category_ids cannot be empty")
@@ -341,6 +464,7 @@ func (a *API) CreateWishHandler(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	keepWishDetails(form, &wish, db.Wish{Priority: db.WishPriorityNiceToHave, Quantity: 1})
 
 	if err := a.validateWishCreation(&wish, categoryIDs); err != nil {
 		return err
@@ -354,6 +478,13 @@ func (a *API) CreateWishHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "at least photos or image_urls must be provided")
 	}
 
+	if wish.Visibility == "" {
+		wish.Visibility = db.WishVisibilityPublic
+	}
+	if err := ensureShareToken(&wish); err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
 	wish.PublishedAt = &now
 	wish.CreatedAt = now
@@ -415,6 +546,7 @@ func (a *API) UpdateWishHandler(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	keepWishDetails(form, &wish, existingWish)
 
 	if err := a.validateWishCreation(&wish, categoryIDs); err != nil {
 		return err
@@ -428,6 +560,13 @@ func (a *API) UpdateWishHandler(c echo.Context) error {
 	wish.ID = existingWish.ID
 	wish.UserID = existingWish.UserID
 	wish.PublishedAt = existingWish.PublishedAt
+	wish.ShareToken = existingWish.ShareToken
+	if wish.Visibility == "" {
+		wish.Visibility = existingWish.Visibility
+	}
+	if err := ensureShareToken(&wish); err != nil {
+		return err
+	}
 	// Update timestamps
 	wish.UpdatedAt = time.Now().UTC()
 
@@ -466,7 +605,28 @@ func (a *API) GetWishHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist item").WithInternal(err)
 	}
 
-	savers, count, err := a.storage.GetUsersWhoSavedWish(c.Request().Context(), item.ID, 2, 0)
+	return a.respondWithWish(c, uid, item)
+}
+
+// GetSharedWishHandler opens a link-only wish by its share token.
+func (a *API) GetSharedWishHandler(c echo.Context) error {
+	token := c.Param("token")
+	uid, _ := getUserID(c) // anyone with the link may see the wish
+
+	item, err := a.storage.GetWishByShareToken(c.Request().Context(), uid, token)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wishlist item not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist item").WithInternal(err)
+	}
+
+	return a.respondWithWish(c, uid, item)
+}
+
+// respondWithWish writes the wish together with the first few users who
+// saved it.
+func (a *API) respondWithWish(c echo.Context, uid string, item db.Wish) error {
+	savers, count, err := a.storage.GetUsersWhoSavedWish(c.Request().Context(), uid, item.ID, 2, 0)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wish savers").WithInternal(err)
 	}
@@ -496,7 +656,7 @@ func (a *API) ListUserWishes(c echo.Context) error {
 		return err
 	}
 
-	res, err := a.storage.GetWishesByUserID(c.Request().Context(), uid)
+	res, err := a.storage.GetWishesByUserID(c.Request().Context(), uid, uid)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot get wishlist item").WithInternal(err)
 	}
@@ -529,6 +689,13 @@ func (a *API) CopyWishHandler(c echo.Context) error {
 
 	now := time.Now().UTC()
 
+	// Copies of restricted wishes stay private, so saving one never widens its
+	// audience.
+	visibility := db.WishVisibilityPrivate
+	if sourceWish.Visibility == db.WishVisibilityPublic {
+		visibility = db.WishVisibilityPublic
+	}
+
 	newWish := db.Wish{
 		ID:          nanoid.Must(),
 		UserID:      targetUserID,
@@ -537,11 +704,15 @@ func (a *API) CopyWishHandler(c echo.Context) error {
 		Price:       sourceWish.Price,
 		Currency:    sourceWish.Currency,
 		Notes:       sourceWish.Notes,
+		Priority:    sourceWish.Priority,
+		Quantity:    sourceWish.Quantity,
+		Attributes:  sourceWish.Attributes,
 		SourceID:    &sourceWish.ID,
 		PublishedAt: &now,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		IsFulfilled: false,
+		Visibility:  visibility,
 	}
 
 	err = a.storage.CreateWish(c.Request().Context(), newWish, categoryIDs)
@@ -572,6 +743,13 @@ func (a *API) CopyWishHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "cannot fetch copied wish").WithInternal(err)
 	}
 
+	a.notify(c.Request().Context(), notificationEvent{
+		Type:       db.NotificationTypeCopy,
+		ActorID:    targetUserID,
+		WishID:     &sourceWish.ID,
+		Recipients: []string{sourceWish.UserID},
+	})
+
 	return c.JSON(http.StatusCreated, copiedWish)
 }
 
@@ -605,26 +783,11 @@ func (a *API) GetWishSaversHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Wish ID is required")
 	}
 
-	pageStr := c.QueryParam("page")
-	limitStr := c.QueryParam("limit")
-
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20 // Default limit
-	}
-	// Cap limit to a max of 100
-	if limit > 100 {
-		limit = 100
-	}
+	limit, offset := pageParams(c)
 
-	offset := (page - 1) * limit
+	uid, _ := getUserID(c) // guests see every saver
 
-	users, total, err := a.storage.GetUsersWhoSavedWish(c.Request().Context(), wishID, limit, offset)
+	users, total, err := a.storage.GetUsersWhoSavedWish(c.Request().Context(), uid, wishID, limit, offset)
 	if err != nil {
 		log.Printf("Error fetching wish savers for wishID %s: %v", wishID, err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve users who saved the wish").WithInternal(err)
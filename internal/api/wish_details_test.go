@@ -0,0 +1,131 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"sacred/internal/testutils"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWishDetails(t *testing.T) {
+	ts := testutils.SetupTestEnvironment(t)
+	defer ts.Teardown()
+
+	owner, err := testutils.AuthHelper(t, ts.Echo, 9001, "details_owner", "Owner")
+	require.NoError(t, err)
+	friend, err := testutils.AuthHelper(t, ts.Echo, 9002, "details_friend", "Friend")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	name := "Sneakers"
+	require.NoError(t, ts.Storage.CreateCategory(ctx, db.Category{ID: "cat_details", Name: "Details Cat", ImageURL: "url"}))
+	testutils.CreateListedWish(t, ts.Storage, db.Wish{
+		ID: "wish_details", UserID: owner.User.ID, Name: &name, PublishedAt: &now, CreatedAt: now, UpdatedAt: now,
+	}, "cat_details")
+
+	update := func(fields map[string]string, status int) db.Wish {
+		body := &bytes.Buffer{}
+		w := multipart.NewWriter(body)
+		require.NoError(t, w.WriteField("name", name))
+		require.NoError(t, w.WriteField("category_ids", "cat_details"))
+		for k, v := range fields {
+			require.NoError(t, w.WriteField(k, v))
+		}
+		require.NoError(t, w.Close())
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/wishes/wish_details", body)
+		req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+owner.Token)
+		rec := httptest.NewRecorder()
+		ts.Echo.ServeHTTP(rec, req)
+		require.Equal(t, status, rec.Code, rec.Body.String())
+
+		if status != http.StatusOK {
+			return db.Wish{}
+		}
+		return testutils.ParseResponse[db.Wish](t, rec)
+	}
+
+	wish, err := ts.Storage.GetWishByID(ctx, owner.User.ID, "wish_details")
+	require.NoError(t, err)
+	assert.Equal(t, db.WishPriorityNiceToHave, wish.Priority)
+	assert.Equal(t, 1, wish.Quantity)
+	assert.Empty(t, wish.Attributes)
+
+	wish = update(map[string]string{
+		"priority":   db.WishPriorityMustHave,
+		"quantity":   "3",
+		"attributes": `[{"key":"size","value":" 42 "},{"key":"color","value":"white"}]`,
+	}, http.StatusOK)
+	assert.Equal(t, db.WishPriorityMustHave, wish.Priority)
+	assert.Equal(t, 3, wish.Quantity)
+	assert.Zero(t, wish.Received)
+	assert.Equal(t, []db.WishAttribute{{Key: "size", Value: "42"}, {Key: "color", Value: "white"}}, wish.Attributes)
+
+	t.Run("Fields left out are kept", func(t *testing.T) {
+		kept := update(nil, http.StatusOK)
+		assert.Equal(t, db.WishPriorityMustHave, kept.Priority)
+		assert.Equal(t, 3, kept.Quantity)
+		assert.Zero(t, kept.Received)
+		assert.Equal(t, wish.Attributes, kept.Attributes)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for _, fields := range []map[string]string{
+			{"priority": "urgent"},
+			{"quantity": "0"},
+			{"quantity": "many"},
+			{"is_fulfilled": "true"},
+			{"attributes": "size=42"},
+			{"attributes": `[{"key":"Size","value":"42"},{"key":"size","value":"43"}]`},
+			{"attributes": `[{"key":"size","value":""}]`},
+		} {
+			update(fields, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Shown in the feed and carried over to copies", func(t *testing.T) {
+		rec := testutils.PerformRequest(t, ts.Echo, http.MethodGet, "/v1/feed", "", friend.Token, http.StatusOK)
+		feed := testutils.ParseResponse[[]contract.FeedItem](t, rec)
+		require.Len(t, feed, 1)
+		assert.Equal(t, db.WishPriorityMustHave, feed[0].Priority)
+		assert.Equal(t, 3, feed[0].Quantity)
+		assert.Equal(t, wish.Attributes, feed[0].Attributes)
+
+		rec = testutils.PerformRequest(t, ts.Echo, http.MethodPost, "/v1/wishes/wish_details/copy", "", friend.Token, http.StatusCreated)
+		copied := testutils.ParseResponse[db.Wish](t, rec)
+		assert.Equal(t, 3, copied.Quantity)
+		assert.Zero(t, copied.Received)
+		assert.Equal(t, wish.Attributes, copied.Attributes)
+	})
+
+	t.Run("Attributes can be cleared", func(t *testing.T) {
+		cleared := update(map[string]string{"attributes": "[]"}, http.StatusOK)
+		assert.Empty(t, cleared.Attributes)
+	})
+
+	t.Run("Received follows the recorded gifts", func(t *testing.T) {
+		_, err := ts.Storage.FulfillWish(ctx, owner.User.ID, db.Fulfillment{ID: "gift_details", WishID: "wish_details", Quantity: 2, CreatedAt: now})
+		require.NoError(t, err)
+
+		update(map[string]string{"quantity": "1", "received": "0"}, http.StatusBadRequest)
+
+		wish := update(map[string]string{"quantity": "2", "received": "0"}, http.StatusOK)
+		assert.Equal(t, 2, wish.Received)
+		assert.True(t, wish.IsFulfilled)
+
+		wish = update(map[string]string{"quantity": "4"}, http.StatusOK)
+		assert.False(t, wish.IsFulfilled, "more were wanted after all")
+	})
+}
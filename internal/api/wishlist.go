@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	nanoid "github.com/matoous/go-nanoid/v2"
+	"net/http"
+	"sacred/internal/contract"
+	"sacred/internal/db"
+	"strings"
+)
+
+func (a *API) ListWishlists(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	lists, err := a.storage.ListUserWishlists(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not list wishlists").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, lists)
+}
+
+func (a *API) CreateWishlist(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	list, err := bindWishlist(c)
+	if err != nil {
+		return err
+	}
+
+	list.ID = nanoid.Must()
+	list.UserID = uid
+
+	created, err := a.storage.CreateWishlist(c.Request().Context(), list)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not create wishlist").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+func (a *API) UpdateWishlist(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	list, err := bindWishlist(c)
+	if err != nil {
+		return err
+	}
+
+	list.ID = c.Param("id")
+	list.UserID = uid
+
+	err = a.storage.UpdateWishlist(c.Request().Context(), list)
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wishlist not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not update wishlist").WithInternal(err)
+	}
+
+	updated, err := a.storage.GetWishlistByID(c.Request().Context(), list.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not update wishlist").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
+
+func (a *API) DeleteWishlist(c echo.Context) error {
+	uid, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	err = a.storage.DeleteWishlist(c.Request().Context(), uid, c.Param("id"))
+	if err != nil && errors.Is(err, db.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "wishlist not found").WithInternal(err)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "could not delete wishlist").WithInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"message": "OK"})
+}
+
+// bindWishlist reads and validates a create or update request.
+func bindWishlist(c echo.Context) (db.Wishlist, error) {
+	var req contract.WishlistRequest
+	if err := c.Bind(&req); err != nil {
+		return db.Wishlist{}, echo.NewHTTPError(http.StatusBadRequest, "failed to bind request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return db.Wishlist{}, echo.NewHTTPError(http.StatusBadRequest, ErrInvalidRequest).WithInternal(err)
+	}
+
+	list := db.Wishlist{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		IsPublic:    req.IsPublic == nil || *req.IsPublic,
+	}
+	if req.EventDate != nil && *req.EventDate != "" {
+		list.EventDate = req.EventDate
+	}
+
+	return list, nil
+}
@@ -6,7 +6,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"regexp"
 	"sacred/internal/db"
+	"sacred/internal/i18n"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type OkResponse struct {
@@ -25,6 +29,11 @@ type JWTClaims struct {
 	jwt.RegisteredClaims
 	UID    string `json:"uid"`
 	ChatID int64  `json:"chat_id"`
+	Lang   string `json:"lang,omitempty"`
+
+	// SessionID ties the access token to a session so revoking the session
+	// rejects the token before it expires.
+	SessionID string `json:"sid,omitempty"`
 }
 
 type AuthTelegramRequest struct {
@@ -39,30 +48,85 @@ func (a AuthTelegramRequest) Validate() error {
 	return nil
 }
 
+// AuthTelegramWidgetRequest is the user object the Telegram Login Widget
+// passes to its callback, plus an optional unsigned start parameter.
+type AuthTelegramWidgetRequest struct {
+	ID         int64  `json:"id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Username   string `json:"username"`
+	PhotoURL   string `json:"photo_url"`
+	AuthDate   int64  `json:"auth_date"`
+	Hash       string `json:"hash"`
+	StartParam string `json:"start_param"`
+} // @Name AuthTelegramWidgetRequest
+
+func (a AuthTelegramWidgetRequest) Validate() error {
+	if a.ID == 0 || a.AuthDate == 0 || a.Hash == "" {
+		return errors.New("id, auth_date and hash are required")
+	}
+
+	return nil
+}
+
+type AuthEmailRequest struct {
+	Email string `json:"email"`
+} // @Name AuthEmailRequest
+
+func (a AuthEmailRequest) Validate() error {
+	if !emailRegexp.MatchString(a.Email) {
+		return errors.New("invalid email format")
+	}
+
+	return nil
+}
+
+type AuthEmailVerifyRequest struct {
+	Token string `json:"token"`
+} // @Name AuthEmailVerifyRequest
+
+func (a AuthEmailVerifyRequest) Validate() error {
+	if a.Token == "" {
+		return errors.New("token cannot be empty")
+	}
+
+	return nil
+}
+
 type UserResponse struct {
-	ID           string        `json:"id"`
-	Name         *string       `json:"name"`
-	Username     string        `json:"username"`
-	ChatID       int64         `json:"chat_id"`
-	LanguageCode string        `json:"language_code"`
-	CreatedAt    time.Time     `json:"created_at"`
-	Email        *string       `json:"email"`
-	ReferralCode string        `json:"referral_code"`
-	ReferredBy   *string       `json:"referred_by"`
-	Interests    []db.Interest `json:"interests"`
-	AvatarURL    *string       `json:"avatar_url"`
+	ID            string        `json:"id"`
+	Name          *string       `json:"name"`
+	Username      string        `json:"username"`
+	ChatID        int64         `json:"chat_id"`
+	LanguageCode  string        `json:"language_code"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Email         *string       `json:"email"`
+	EmailVerified bool          `json:"email_verified"`
+	ReferralCode  string        `json:"referral_code"`
+	ReferredBy    *string       `json:"referred_by"`
+	Interests     []db.Interest `json:"interests"`
+	AvatarURL     *string       `json:"avatar_url"`
+	Birthday      *string       `json:"birthday"`
+	Timezone      *string       `json:"timezone"`
 }
 
 type UserProfileResponse struct {
-	ID          string        `json:"id"`
-	Name        *string       `json:"name"`
-	Username    string        `json:"username"`
-	CreatedAt   time.Time     `json:"created_at"`
-	AvatarURL   *string       `json:"avatar_url"`
+	ID        string    `json:"id"`
+	Name      *string   `json:"name"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	AvatarURL *string   `json:"avatar_url"`
+	// Birthday is MM-DD and only shown to the user and their followers.
+	Birthday    *string       `json:"birthday,omitempty"`
 	Interests   []db.Interest `json:"interests"`
 	Followers   int           `json:"followers"`
+	Following   int           `json:"following"`
 	SavedItems  []db.Wish     `json:"wishlist_items"`
 	IsFollowing bool          `json:"is_following"`
+	IsBlocked   bool          `json:"is_blocked,omitempty"`
+	IsMuted     bool          `json:"is_muted,omitempty"`
+	IsPrivate   bool          `json:"is_private"`
+	IsRequested bool          `json:"is_requested,omitempty"`
 }
 
 // ShortUserProfile represents a subset of user information.
@@ -94,10 +158,42 @@ type WishSaversResponse struct {
 	Total int                `json:"total"`
 }
 
+// FollowListResponse is a page of a user's followers or followings.
+type FollowListResponse struct {
+	Users []FollowListUser `json:"users"`
+	Total int              `json:"total"`
+}
+
+// FollowListUser tells whether the viewer follows a listed user.
+type FollowListUser struct {
+	ShortUserProfile
+	IsFollowing bool `json:"is_following"`
+}
+
 type AuthResponse struct {
-	Token  string       `json:"token"`
-	User   UserResponse `json:"user"`
-	Wishes []db.Wish    `json:"wishes"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int          `json:"expires_in"`
+	User         UserResponse `json:"user"`
+	Wishes       []db.Wish    `json:"wishes"`
+	StartTarget  *StartTarget `json:"start_target,omitempty"`
+}
+
+const (
+	StartTargetWish    = "wish"
+	StartTargetProfile = "profile"
+)
+
+// StartTarget tells the mini app which screen to open for the start parameter it was launched with.
+type StartTarget struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type ReferralResponse struct {
+	User     ShortUserProfile `json:"user"`
+	Source   string           `json:"source"`
+	JoinedAt time.Time        `json:"joined_at"`
 }
 
 type FollowUserRequest struct {
@@ -123,11 +219,64 @@ func (r *FollowUserRequest) Validate() error {
 	return nil
 }
 
+// UserRelationRequest names the user to block, unblock, mute or unmute.
+type UserRelationRequest struct {
+	UserID string `json:"user_id"`
+}
+
+func (r *UserRelationRequest) Validate() error {
+	if r.UserID == "" {
+		return fmt.Errorf("user_id is empty")
+	}
+
+	return nil
+}
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// usernameRegexp follows Telegram's rules: a letter first, then letters,
+// digits and underscores, up to 32 characters.
+var usernameRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{2,31}$`)
+
+// reservedUsernames are handles that would look official or clash with app
+// paths.
+var reservedUsernames = []string{
+	"admin", "administrator", "api", "app", "assets", "auth", "bot", "feed",
+	"help", "login", "logout", "me", "moderator", "official", "profiles",
+	"root", "sacred", "settings", "support", "system", "user", "users", "wishes",
+}
+
+var (
+	ErrInvalidUsername  = errors.New("username must be 3 to 32 letters, digits or underscores and start with a letter")
+	ErrReservedUsername = errors.New("username is reserved")
+)
+
+// ValidateUsername checks a username the user picked against the character
+// policy and the reserved words.
+func ValidateUsername(username string) error {
+	if !usernameRegexp.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	if slices.Contains(reservedUsernames, strings.ToLower(username)) {
+		return ErrReservedUsername
+	}
+
+	return nil
+}
+
 type UpdateUserRequest struct {
-	Interests []string `json:"interests"`
-	Email     string   `json:"email"`
-	Name      *string  `json:"name"`
-	Username  *string  `json:"username"`
+	Interests    []string `json:"interests"`
+	Email        string   `json:"email"`
+	Name         *string  `json:"name"`
+	Username     *string  `json:"username"`
+	LanguageCode *string  `json:"language_code"`
+	// Birthday is YYYY-MM-DD; an empty string removes it.
+	Birthday *string `json:"birthday"`
+	// Timezone is an IANA name such as "Europe/Moscow"; an empty string removes it.
+	Timezone *string `json:"timezone"`
+	// IsPrivate limits the user's wishes to approved followers.
+	IsPrivate *bool `json:"is_private"`
 }
 
 func (u UpdateUserRequest) Validate() error {
@@ -135,17 +284,38 @@ func (u UpdateUserRequest) Validate() error {
 		return errors.New("interests cannot be empty")
 	}
 
-	re := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !re.MatchString(u.Email) {
+	if !emailRegexp.MatchString(u.Email) {
 		return errors.New("invalid email format")
 	}
 
-	if u.Name != nil && len(*u.Name) > 100 && len(*u.Name) < 1 {
-		return errors.New("name cannot be longer than 100 characters")
+	if u.Name != nil && (strings.TrimSpace(*u.Name) == "" || utf8.RuneCountInString(*u.Name) > 100) {
+		return errors.New("name must be between 1 and 100 characters")
 	}
 
-	if u.Username != nil && len(*u.Username) > 100 && len(*u.Username) < 1 {
-		return errors.New("username cannot be longer than 100 characters")
+	if u.Username != nil {
+		if err := ValidateUsername(*u.Username); err != nil {
+			return err
+		}
+	}
+
+	if u.LanguageCode != nil && !i18n.Supported(*u.LanguageCode) {
+		return errors.New("unsupported language code")
+	}
+
+	if u.Birthday != nil && *u.Birthday != "" {
+		birthday, err := time.Parse(time.DateOnly, *u.Birthday)
+		if err != nil {
+			return errors.New("birthday must be in YYYY-MM-DD format")
+		}
+		if birthday.After(time.Now()) {
+			return errors.New("birthday cannot be in the future")
+		}
+	}
+
+	if u.Timezone != nil && *u.Timezone != "" {
+		if _, err := time.LoadLocation(*u.Timezone); err != nil {
+			return errors.New("unknown timezone")
+		}
 	}
 
 	return nil
@@ -163,20 +333,327 @@ type FeedItem struct {
 	Categories []db.Category  `json:"categories"`
 	Images     []db.WishImage `json:"images"`
 	CopyID     *string        `json:"copy_id,omitempty"`
+	// CommentCount counts public comments; questions are left out.
+	CommentCount int `json:"comment_count"`
+	// Reactions counts reactions by type; MyReaction is the viewer's own.
+	Reactions  map[string]int     `json:"reactions"`
+	MyReaction *string            `json:"my_reaction,omitempty"`
+	Priority   string             `json:"priority"`
+	Quantity   int                `json:"quantity"`
+	Received   int                `json:"received"`
+	Attributes []db.WishAttribute `json:"attributes"`
 }
 
 func ToFeedItem(w db.Wish) FeedItem {
 	return FeedItem{
-		ID:         w.ID,
-		Name:       w.Name,
-		URL:        w.URL,
-		Price:      w.Price,
-		Currency:   w.Currency,
-		Notes:      w.Notes,
-		CreatedAt:  w.CreatedAt,
-		UpdatedAt:  w.UpdatedAt,
-		Categories: w.Categories,
-		Images:     w.Images,
-		CopyID:     w.CopyID,
+		ID:           w.ID,
+		Name:         w.Name,
+		URL:          w.URL,
+		Price:        w.Price,
+		Currency:     w.Currency,
+		Notes:        w.Notes,
+		CreatedAt:    w.CreatedAt,
+		UpdatedAt:    w.UpdatedAt,
+		Categories:   w.Categories,
+		Images:       w.Images,
+		CopyID:       w.CopyID,
+		CommentCount: w.CommentCount,
+		Reactions:    w.Reactions,
+		MyReaction:   w.MyReaction,
+		Priority:     w.Priority,
+		Quantity:     w.Quantity,
+		Received:     w.Received,
+		Attributes:   w.Attributes,
+	}
+}
+
+type FulfillWishRequest struct {
+	// Quantity is how many items arrived, one when left out.
+	Quantity     int     `json:"quantity"`
+	ThankYouNote *string `json:"thank_you_note"`
+} // @Name FulfillWishRequest
+
+func (r FulfillWishRequest) Validate() error {
+	if r.Quantity < 0 {
+		return errors.New("quantity cannot be negative")
+	}
+
+	if r.ThankYouNote != nil && utf8.RuneCountInString(strings.TrimSpace(*r.ThankYouNote)) > maxCommentLength {
+		return fmt.Errorf("thank-you note must be at most %d characters long", maxCommentLength)
 	}
+
+	return nil
 }
+
+type WishlistRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// IsPublic is true when left out.
+	IsPublic *bool `json:"is_public"`
+	// EventDate is YYYY-MM-DD; followers are reminded before it. An empty
+	// string or null removes it.
+	EventDate *string `json:"event_date"`
+} // @Name WishlistRequest
+
+func (r WishlistRequest) Validate() error {
+	if name := strings.TrimSpace(r.Name); name == "" || utf8.RuneCountInString(name) > 100 {
+		return errors.New("name must be between 1 and 100 characters")
+	}
+
+	if utf8.RuneCountInString(r.Description) > 1000 {
+		return errors.New("description must be at most 1000 characters long")
+	}
+
+	if r.EventDate != nil && *r.EventDate != "" {
+		if _, err := time.Parse(time.DateOnly, *r.EventDate); err != nil {
+			return errors.New("event_date must be YYYY-MM-DD")
+		}
+	}
+
+	return nil
+}
+
+type ReceivedGiftResponse struct {
+	ID           string            `json:"id"`
+	WishID       string            `json:"wish_id"`
+	WishName     *string           `json:"wish_name"`
+	WishImage    *string           `json:"wish_image"`
+	Quantity     int               `json:"quantity"`
+	Gifter       *ShortUserProfile `json:"gifter"`
+	ThankYouNote *string           `json:"thank_you_note,omitempty"`
+	ReceivedAt   time.Time         `json:"received_at"`
+} // @Name ReceivedGiftResponse
+
+func ToReceivedGiftResponse(g db.ReceivedGift) ReceivedGiftResponse {
+	resp := ReceivedGiftResponse{
+		ID:           g.ID,
+		WishID:       g.WishID,
+		WishName:     g.WishName,
+		WishImage:    g.WishImage,
+		Quantity:     g.Quantity,
+		ThankYouNote: g.ThankYouNote,
+		ReceivedAt:   g.CreatedAt,
+	}
+
+	if g.Gifter != nil {
+		gifter := ToShortUserProfile(*g.Gifter)
+		resp.Gifter = &gifter
+	}
+
+	return resp
+}
+
+type ReceivedGiftsResponse struct {
+	Gifts []ReceivedGiftResponse `json:"gifts"`
+	Total int                    `json:"total"`
+} // @Name ReceivedGiftsResponse
+
+type ReactionRequest struct {
+	Reaction string `json:"reaction"`
+} // @Name ReactionRequest
+
+func (r ReactionRequest) Validate() error {
+	if !db.IsReaction(r.Reaction) {
+		return fmt.Errorf("reaction must be one of %s", strings.Join(db.Reactions, ", "))
+	}
+
+	return nil
+}
+
+type ReactionResponse struct {
+	// Reaction is the viewer's reaction after the change, null when none.
+	Reaction  *string        `json:"reaction"`
+	Reactions map[string]int `json:"reactions"`
+} // @Name ReactionResponse
+
+// maxCommentLength is the longest comment body, in characters.
+const maxCommentLength = 1000
+
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+	// IsQuestion keeps the thread between the asker and the wish owner.
+	// Replies inherit it from the comment they answer.
+	IsQuestion bool    `json:"is_question"`
+	ParentID   *string `json:"parent_id"`
+} // @Name CreateCommentRequest
+
+func (r CreateCommentRequest) Validate() error {
+	body := strings.TrimSpace(r.Body)
+	if body == "" {
+		return errors.New("comment cannot be empty")
+	}
+
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return fmt.Errorf("comment must be at most %d characters long", maxCommentLength)
+	}
+
+	return nil
+}
+
+type CommentResponse struct {
+	ID         string            `json:"id"`
+	Author     ShortUserProfile  `json:"author"`
+	Body       string            `json:"body"`
+	IsQuestion bool              `json:"is_question"`
+	CanDelete  bool              `json:"can_delete"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Replies    []CommentResponse `json:"replies"`
+} // @Name CommentResponse
+
+// ToCommentResponse converts a comment for viewerID, who may delete it if
+// they wrote it or own the wish.
+func ToCommentResponse(c db.Comment, viewerID string) CommentResponse {
+	return CommentResponse{
+		ID:         c.ID,
+		Author:     ToShortUserProfile(c.Author),
+		Body:       c.Body,
+		IsQuestion: c.IsQuestion,
+		CanDelete:  viewerID != "" && (viewerID == c.UserID || viewerID == c.WishOwnerID),
+		CreatedAt:  c.CreatedAt,
+		Replies:    []CommentResponse{},
+	}
+}
+
+type NotificationResponse struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Actor     *ShortUserProfile `json:"actor,omitempty"`
+	WishID    *string           `json:"wish_id,omitempty"`
+	WishName  *string           `json:"wish_name,omitempty"`
+	ReadAt    *time.Time        `json:"read_at"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func ToNotificationResponse(n db.Notification) NotificationResponse {
+	resp := NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		WishID:    n.WishID,
+		WishName:  n.WishName,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+
+	if n.Actor != nil {
+		actor := ToShortUserProfile(*n.Actor)
+		resp.Actor = &actor
+	}
+
+	return resp
+}
+
+type NotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int                    `json:"unread_count"`
+}
+
+// MarkNotificationsReadRequest marks the listed notifications as read, or all of them when IDs is empty.
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (r MarkNotificationsReadRequest) Validate() error {
+	if len(r.IDs) > 100 {
+		return errors.New("cannot mark more than 100 notifications at once")
+	}
+
+	return nil
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []db.NotificationPreference `json:"preferences"`
+}
+
+func (r UpdateNotificationPreferencesRequest) Validate() error {
+	if len(r.Preferences) == 0 {
+		return errors.New("preferences cannot be empty")
+	}
+
+	for _, p := range r.Preferences {
+		if !slices.Contains(db.NotificationTypes, p.Type) {
+			return fmt.Errorf("unknown notification type %q", p.Type)
+		}
+	}
+
+	return nil
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+} // @Name RefreshTokenRequest
+
+func (r RefreshTokenRequest) Validate() error {
+	if r.RefreshToken == "" {
+		return errors.New("refresh token cannot be empty")
+	}
+
+	return nil
+}
+
+// TokenResponse is a new access token and the refresh token that replaces the one sent.
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+} // @Name TokenResponse
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  *string   `json:"user_agent"`
+	IP         *string   `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+} // @Name SessionResponse
+
+// AccountDeletionResponse tells when a requested account deletion takes
+// effect; both fields are null when none was requested.
+type AccountDeletionResponse struct {
+	RequestedAt *time.Time `json:"requested_at"`
+	DeleteAt    *time.Time `json:"delete_at"`
+} // @Name AccountDeletionResponse
+
+type CreateAPITokenRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+} // @Name CreateAPITokenRequest
+
+func (r CreateAPITokenRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+
+	if len(r.Name) > 64 {
+		return errors.New("name cannot be longer than 64 characters")
+	}
+
+	if r.Scope != db.APITokenScopeRead && r.Scope != db.APITokenScopeWrite {
+		return fmt.Errorf("unknown scope %q", r.Scope)
+	}
+
+	return nil
+}
+
+type APITokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+} // @Name APITokenResponse
+
+func ToAPITokenResponse(t db.APIToken) APITokenResponse {
+	return APITokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scope:      t.Scope,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// CreateAPITokenResponse carries the token itself, which is never shown again.
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+} // @Name CreateAPITokenResponse
@@ -0,0 +1,417 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// UserExport is everything stored about a user, as handed out by the data
+// export.
+type UserExport struct {
+	Profile   User               `json:"profile"`
+	Wishes    []Wish             `json:"wishes"`
+	Wishlists []ExportedWishlist `json:"wishlists"`
+	Bookmarks []ExportedRelation `json:"bookmarks"`
+	Followers []ExportedRelation `json:"followers"`
+	Following []ExportedRelation `json:"following"`
+	Blocked   []ExportedRelation `json:"blocked"`
+	Muted     []ExportedRelation `json:"muted"`
+	Comments  []Comment          `json:"comments"`
+	Reactions []ExportedReaction `json:"reactions"`
+	// ReceivedGifts lists every gift, with no page limit.
+	ReceivedGifts []ReceivedGift `json:"received_gifts"`
+}
+
+type ExportedWishlist struct {
+	Wishlist
+	WishIDs []string `json:"wish_ids"`
+}
+
+type ExportedReaction struct {
+	WishID    string    `json:"wish_id"`
+	Reaction  string    `json:"reaction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportedRelation is a link from the user to a wish or another user.
+type ExportedRelation struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportUserData collects the user's profile, wishes, wishlists, bookmarks,
+// follows, blocks and mutes.
+func (s *Storage) ExportUserData(ctx context.Context, uid string) (UserExport, error) {
+	var export UserExport
+
+	user, err := s.GetUserByID(uid)
+	if err != nil {
+		return UserExport{}, err
+	}
+	export.Profile = user
+
+	query := s.baseWishesQuery() + `
+			WHERE w.user_id = ? AND w.deleted_at IS NULL
+			GROUP BY w.id
+			ORDER BY w.created_at`
+	if export.Wishes, err = s.fetchWishes(ctx, query, baseWishesArgs(uid, uid)...); err != nil {
+		return UserExport{}, err
+	}
+
+	if export.Wishlists, err = s.exportWishlists(ctx, uid); err != nil {
+		return UserExport{}, err
+	}
+
+	relations := []struct {
+		dest  *[]ExportedRelation
+		query string
+	}{
+		{&export.Bookmarks, `SELECT wish_id, created_at FROM user_bookmarks WHERE user_id = ? ORDER BY created_at`},
+		{&export.Followers, `SELECT follower_id, created_at FROM followers WHERE following_id = ? ORDER BY created_at`},
+		{&export.Following, `SELECT following_id, created_at FROM followers WHERE follower_id = ? ORDER BY created_at`},
+		{&export.Blocked, `SELECT blocked_id, created_at FROM user_blocks WHERE user_id = ? ORDER BY created_at`},
+		{&export.Muted, `SELECT muted_id, created_at FROM user_mutes WHERE user_id = ? ORDER BY created_at`},
+	}
+
+	for _, r := range relations {
+		if *r.dest, err = s.exportRelations(ctx, r.query, uid); err != nil {
+			return UserExport{}, err
+		}
+	}
+
+	if export.Comments, err = s.exportComments(ctx, uid); err != nil {
+		return UserExport{}, err
+	}
+
+	if export.Reactions, err = s.exportReactions(ctx, uid); err != nil {
+		return UserExport{}, err
+	}
+
+	if export.ReceivedGifts, _, err = s.ListReceivedGifts(ctx, uid, uid, -1, 0); err != nil {
+		return UserExport{}, err
+	}
+
+	return export, nil
+}
+
+func (s *Storage) exportReactions(ctx context.Context, uid string) ([]ExportedReaction, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT wish_id, reaction, created_at FROM wish_reactions WHERE user_id = ? ORDER BY created_at`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := make([]ExportedReaction, 0)
+	for rows.Next() {
+		var r ExportedReaction
+		if err := rows.Scan(&r.WishID, &r.Reaction, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, r)
+	}
+
+	return reactions, rows.Err()
+}
+
+func (s *Storage) exportComments(ctx context.Context, uid string) ([]Comment, error) {
+	return s.listComments(ctx, `SELECT `+commentColumns+` WHERE c.user_id = ? ORDER BY c.created_at`, uid)
+}
+
+func (s *Storage) exportWishlists(ctx context.Context, uid string) ([]ExportedWishlist, error) {
+	query := `
+		SELECT l.id, l.user_id, l.name, IFNULL(l.description, ''), l.is_public, l.event_date, l.created_at,
+		       IFNULL((SELECT json_group_array(i.wish_id) FROM wishlist_items i WHERE i.wishlist_id = l.id), '[]')
+		FROM wishlists l
+		WHERE l.user_id = ? AND l.deleted_at IS NULL
+		ORDER BY l.created_at`
+
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]ExportedWishlist, 0)
+	for rows.Next() {
+		var list ExportedWishlist
+		var wishIDs string
+		if err := rows.Scan(
+			&list.ID,
+			&list.UserID,
+			&list.Name,
+			&list.Description,
+			&list.IsPublic,
+			&list.EventDate,
+			&list.CreatedAt,
+			&wishIDs,
+		); err != nil {
+			return nil, err
+		}
+
+		if list.WishIDs, err = UnmarshalJSONToSlice[string](wishIDs); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+func (s *Storage) exportRelations(ctx context.Context, query string, args ...interface{}) ([]ExportedRelation, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relations := make([]ExportedRelation, 0)
+	for rows.Next() {
+		var r ExportedRelation
+		if err := rows.Scan(&r.ID, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		relations = append(relations, r)
+	}
+
+	return relations, rows.Err()
+}
+
+// RequestAccountDeletion schedules the account for deletion. Requesting again
+// keeps the original date.
+func (s *Storage) RequestAccountDeletion(ctx context.Context, uid string, at time.Time) (time.Time, error) {
+	query := `UPDATE users SET deletion_requested_at = COALESCE(deletion_requested_at, ?) WHERE id = ? AND deleted_at IS NULL`
+	if _, err := s.db.ExecContext(ctx, query, at.UTC(), uid); err != nil {
+		return time.Time{}, err
+	}
+
+	requested, err := s.GetAccountDeletion(ctx, uid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if requested == nil {
+		return time.Time{}, ErrNotFound
+	}
+
+	return *requested, nil
+}
+
+// CancelAccountDeletion keeps the account. It returns ErrNotFound when no
+// deletion was requested.
+func (s *Storage) CancelAccountDeletion(ctx context.Context, uid string) error {
+	query := `UPDATE users SET deletion_requested_at = NULL WHERE id = ? AND deletion_requested_at IS NOT NULL AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, uid)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetAccountDeletion returns when the user asked to delete their account, or
+// nil.
+func (s *Storage) GetAccountDeletion(ctx context.Context, uid string) (*time.Time, error) {
+	var requested sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT deletion_requested_at FROM users WHERE id = ? AND deleted_at IS NULL`, uid).Scan(&requested)
+	if err != nil && IsNoRowsError(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !requested.Valid {
+		return nil, nil
+	}
+
+	return &requested.Time, nil
+}
+
+// ListAccountsDueForDeletion returns users who asked to be deleted before the
+// given time.
+func (s *Storage) ListAccountsDueForDeletion(ctx context.Context, before time.Time) ([]string, error) {
+	query := `
+		SELECT id FROM users
+		WHERE deletion_requested_at IS NOT NULL
+		  AND datetime(deletion_requested_at) <= datetime(?)
+		  AND deleted_at IS NULL`
+
+	rows, err := s.db.QueryContext(ctx, query, before.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ListOwnedContentIDs returns the ids of every wish and wishlist the user
+// created, deleted ones included.
+func (s *Storage) ListOwnedContentIDs(ctx context.Context, uid string) (wishIDs, wishlistIDs []string, err error) {
+	if wishIDs, err = s.listIDs(ctx, `SELECT id FROM wishes WHERE user_id = ? ORDER BY rowid`, uid); err != nil {
+		return nil, nil, err
+	}
+
+	if wishlistIDs, err = s.listIDs(ctx, `SELECT id FROM wishlists WHERE user_id = ? ORDER BY rowid`, uid); err != nil {
+		return nil, nil, err
+	}
+
+	return wishIDs, wishlistIDs, nil
+}
+
+func (s *Storage) listIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// PurgeUser removes everything the user created or that points at them and
+// anonymizes the users row, which stays behind so referral codes others
+// signed up with remain valid. Image files only this user referenced, plus
+// extraBlobs, are queued for deletion from storage.
+func (s *Storage) PurgeUser(ctx context.Context, uid string, extraBlobs ...string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queueBlobs := `
+		INSERT OR IGNORE INTO blob_deletions (key)
+		SELECT DISTINCT i.url FROM wish_images i
+		JOIN wishes w ON w.id = i.wish_id
+		WHERE w.user_id = ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM wish_images o
+			JOIN wishes ow ON ow.id = o.wish_id
+			WHERE o.url = i.url AND ow.user_id != ?)`
+
+	if _, err := tx.ExecContext(ctx, queueBlobs, uid, uid); err != nil {
+		return err
+	}
+
+	for _, key := range extraBlobs {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO blob_deletions (key) VALUES (?)`, key); err != nil {
+			return err
+		}
+	}
+
+	ownWishes := `SELECT id FROM wishes WHERE user_id = ?`
+	ownLists := `SELECT id FROM wishlists WHERE user_id = ?`
+
+	statements := []struct {
+		query string
+		args  int
+	}{
+		{`DELETE FROM wishlist_items WHERE wish_id IN (` + ownWishes + `) OR wishlist_id IN (` + ownLists + `)`, 2},
+		{`DELETE FROM wishlists WHERE user_id = ?`, 1},
+		{`DELETE FROM wish_comments WHERE user_id = ?`, 1},
+		{`DELETE FROM wish_reactions WHERE user_id = ?`, 1},
+		{`UPDATE wish_fulfillments SET gifter_id = NULL WHERE gifter_id = ?`, 1},
+		{`DELETE FROM wish_images WHERE wish_id IN (` + ownWishes + `)`, 1},
+		{`DELETE FROM wish_categories WHERE wish_id IN (` + ownWishes + `)`, 1},
+		{`UPDATE wishes SET source_id = NULL WHERE source_id IN (` + ownWishes + `)`, 1},
+		{`UPDATE wishes SET reserved_by = NULL, reserved_at = NULL WHERE reserved_by = ?`, 1},
+		{`DELETE FROM wishes WHERE user_id = ?`, 1},
+		{`DELETE FROM user_bookmarks WHERE user_id = ?`, 1},
+		{`DELETE FROM user_interests WHERE user_id = ?`, 1},
+		{`DELETE FROM followers WHERE follower_id = ? OR following_id = ?`, 2},
+		{`DELETE FROM follow_requests WHERE user_id = ? OR target_id = ?`, 2},
+		{`DELETE FROM user_blocks WHERE user_id = ? OR blocked_id = ?`, 2},
+		{`DELETE FROM user_mutes WHERE user_id = ? OR muted_id = ?`, 2},
+		{`DELETE FROM referrals WHERE referrer_id = ? OR referred_id = ?`, 2},
+		{`DELETE FROM notifications WHERE user_id = ? OR actor_id = ?`, 2},
+		{`DELETE FROM notification_preferences WHERE user_id = ?`, 1},
+		{`DELETE FROM sent_reminders WHERE user_id = ?`, 1},
+		{`DELETE FROM digests WHERE user_id = ?`, 1},
+		{`DELETE FROM sessions WHERE user_id = ?`, 1},
+		{`DELETE FROM api_tokens WHERE user_id = ?`, 1},
+		{`DELETE FROM login_tokens WHERE user_id = ?`, 1},
+		{`DELETE FROM email_verifications WHERE user_id = ?`, 1},
+		{`DELETE FROM username_history WHERE user_id = ?`, 1},
+		// Telegram ids are positive, so a negative chat id frees the real one
+		// for a fresh sign-up.
+		{`UPDATE users
+		  SET username = 'deleted_' || id,
+		      name = NULL,
+		      email = NULL,
+		      email_verified_at = NULL,
+		      avatar_url = NULL,
+		      birthday = NULL,
+		      timezone = NULL,
+		      chat_id = -rowid,
+		      deletion_requested_at = NULL,
+		      deleted_at = CURRENT_TIMESTAMP
+		  WHERE id = ?`, 1},
+	}
+
+	for _, stmt := range statements {
+		args := make([]interface{}, stmt.args)
+		for i := range args {
+			args[i] = uid
+		}
+
+		if _, err := tx.ExecContext(ctx, stmt.query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListBlobDeletions returns storage keys waiting to be deleted.
+func (s *Storage) ListBlobDeletions(ctx context.Context, limit int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key FROM blob_deletions ORDER BY created_at LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// CompleteBlobDeletion forgets a key once its file is gone.
+func (s *Storage) CompleteBlobDeletion(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM blob_deletions WHERE key = ?`, key)
+	return err
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	APITokenScopeRead  = "read"
+	APITokenScopeWrite = "write"
+)
+
+// APIToken is a personal token for scripts and browser extensions. Only the
+// hash of the token is stored; the token itself is shown once on creation.
+type APIToken struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     string     `db:"user_id" json:"user_id"`
+	Name       string     `db:"name" json:"name"`
+	Scope      string     `db:"scope" json:"scope"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+func (s *Storage) CreateAPIToken(ctx context.Context, token APIToken, tokenHash string) (APIToken, error) {
+	query := `INSERT INTO api_tokens (id, user_id, name, token_hash, scope) VALUES (?, ?, ?, ?, ?)`
+
+	if _, err := s.db.ExecContext(ctx, query, token.ID, token.UserID, token.Name, tokenHash, token.Scope); err != nil {
+		return APIToken{}, err
+	}
+
+	return s.getAPIToken(ctx, `id = ?`, token.ID)
+}
+
+func (s *Storage) getAPIToken(ctx context.Context, where string, args ...interface{}) (APIToken, error) {
+	query := `SELECT id, user_id, name, scope, last_used_at, created_at FROM api_tokens WHERE ` + where
+
+	var t APIToken
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Name,
+		&t.Scope,
+		&t.LastUsedAt,
+		&t.CreatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIToken{}, ErrNotFound
+	}
+
+	return t, err
+}
+
+// UseAPIToken looks up a token by its hash and records that it was used.
+func (s *Storage) UseAPIToken(ctx context.Context, tokenHash string) (APIToken, error) {
+	query := `UPDATE api_tokens SET last_used_at = ? WHERE token_hash = ?`
+
+	res, err := s.db.ExecContext(ctx, query, time.Now().UTC().Format(sqliteTimeLayout), tokenHash)
+	if err != nil {
+		return APIToken{}, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return APIToken{}, err
+	}
+
+	if affected == 0 {
+		return APIToken{}, ErrNotFound
+	}
+
+	return s.getAPIToken(ctx, `token_hash = ?`, tokenHash)
+}
+
+func (s *Storage) ListAPITokens(ctx context.Context, uid string) ([]APIToken, error) {
+	query := `
+		SELECT id, user_id, name, scope, last_used_at, created_at
+		FROM api_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]APIToken, 0)
+	for rows.Next() {
+		var t APIToken
+		if err := rows.Scan(
+			&t.ID,
+			&t.UserID,
+			&t.Name,
+			&t.Scope,
+			&t.LastUsedAt,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *Storage) CountAPITokens(ctx context.Context, uid string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM api_tokens WHERE user_id = ?`, uid).Scan(&count)
+
+	return count, err
+}
+
+func (s *Storage) DeleteAPIToken(ctx context.Context, uid, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM api_tokens WHERE id = ? AND user_id = ?`, id, uid)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package db
+
+import "context"
+
+// hiddenUsersSubquery selects users whose content the viewer should not see:
+// anyone the viewer blocked or was blocked by, and anyone the viewer muted.
+// It takes the viewer's id three times, see hiddenUsersArgs.
+const hiddenUsersSubquery = `
+	SELECT blocked_id FROM user_blocks WHERE user_id = ?
+	UNION SELECT user_id FROM user_blocks WHERE blocked_id = ?
+	UNION SELECT muted_id FROM user_mutes WHERE user_id = ?`
+
+func hiddenUsersArgs(viewerID string) []interface{} {
+	return []interface{}{viewerID, viewerID, viewerID}
+}
+
+// BlockUser blocks blockedID for uid, removes any follow or follow request
+// between them and releases uid's wishes blockedID reserved.
+func (s *Storage) BlockUser(ctx context.Context, uid, blockedID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO user_blocks (user_id, blocked_id) VALUES (?, ?)`, uid, blockedID); err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM followers
+		WHERE (follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)`
+
+	if _, err := tx.ExecContext(ctx, query, uid, blockedID, blockedID, uid); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM follow_requests
+		WHERE (user_id = ? AND target_id = ?) OR (user_id = ? AND target_id = ?)`
+
+	if _, err := tx.ExecContext(ctx, query, uid, blockedID, blockedID, uid); err != nil {
+		return err
+	}
+
+	query = `UPDATE wishes SET reserved_by = NULL, reserved_at = NULL WHERE user_id = ? AND reserved_by = ?`
+
+	if _, err := tx.ExecContext(ctx, query, uid, blockedID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) UnblockUser(ctx context.Context, uid, blockedID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM user_blocks WHERE user_id = ? AND blocked_id = ?`, uid, blockedID)
+
+	return err
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func (s *Storage) IsBlocked(ctx context.Context, uid, otherID string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM user_blocks
+			WHERE (user_id = ? AND blocked_id = ?) OR (user_id = ? AND blocked_id = ?)
+		)`
+
+	var blocked bool
+	err := s.db.QueryRowContext(ctx, query, uid, otherID, otherID, uid).Scan(&blocked)
+
+	return blocked, err
+}
+
+// HasBlocked reports whether uid blocked blockedID.
+func (s *Storage) HasBlocked(ctx context.Context, uid, blockedID string) (bool, error) {
+	var blocked bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM user_blocks WHERE user_id = ? AND blocked_id = ?)`, uid, blockedID).Scan(&blocked)
+
+	return blocked, err
+}
+
+// ListBlockedUsers returns the users uid blocked, most recent first.
+func (s *Storage) ListBlockedUsers(ctx context.Context, uid string) ([]User, error) {
+	query := `
+		SELECT u.id, u.username, u.name, u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers
+		FROM user_blocks b
+		JOIN users u ON u.id = b.blocked_id
+		WHERE b.user_id = ?
+		ORDER BY b.created_at DESC`
+
+	return s.listRelatedUsers(ctx, query, uid)
+}
+
+func (s *Storage) MuteUser(ctx context.Context, uid, mutedID string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO user_mutes (user_id, muted_id) VALUES (?, ?)`, uid, mutedID)
+
+	return err
+}
+
+func (s *Storage) UnmuteUser(ctx context.Context, uid, mutedID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM user_mutes WHERE user_id = ? AND muted_id = ?`, uid, mutedID)
+
+	return err
+}
+
+func (s *Storage) IsMuted(ctx context.Context, uid, mutedID string) (bool, error) {
+	var muted bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM user_mutes WHERE user_id = ? AND muted_id = ?)`, uid, mutedID).Scan(&muted)
+
+	return muted, err
+}
+
+// ListMutedUsers returns the users uid muted, most recent first.
+func (s *Storage) ListMutedUsers(ctx context.Context, uid string) ([]User, error) {
+	query := `
+		SELECT u.id, u.username, u.name, u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers
+		FROM user_mutes m
+		JOIN users u ON u.id = m.muted_id
+		WHERE m.user_id = ?
+		ORDER BY m.created_at DESC`
+
+	return s.listRelatedUsers(ctx, query, uid)
+}
+
+func (s *Storage) listRelatedUsers(ctx context.Context, query string, args ...interface{}) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Name, &user.AvatarURL, &user.Followers); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -32,14 +32,17 @@ func (s *Storage) RemoveWishFromBookmarks(ctx context.Context, uid, wishID strin
 func (s *Storage) ListBookmarkedWishes(ctx context.Context, uid string) ([]Wish, error) {
 	query := s.baseWishesQuery() + `
 			LEFT JOIN user_bookmarks ub ON w.id = ub.wish_id
-			WHERE ub.user_id = ?
+			WHERE ub.user_id = ? AND ` + listedWishCondition + ` AND ` + visibleWishCondition + `
 			GROUP BY w.id
 			ORDER BY w.created_at DESC
 			LIMIT 100`
-	return s.fetchWishes(ctx, query, uid, uid)
+	return s.fetchWishes(ctx, query, baseWishesArgs(uid, uid, uid, uid)...)
 }
 
-func (s *Storage) GetUsersWhoSavedWish(ctx context.Context, wishID string, limit int, offset int) ([]User, int, error) {
+// GetUsersWhoSavedWish lists the wish's creator and the users who copied it,
+// leaving out copies viewerID may not see and users hidden from them by a
+// block or mute. Wishes hidden from viewerID have no savers.
+func (s *Storage) GetUsersWhoSavedWish(ctx context.Context, viewerID, wishID string, limit int, offset int) ([]User, int, error) {
 	var users []User
 	var total int
 
@@ -72,13 +75,18 @@ func (s *Storage) GetUsersWhoSavedWish(ctx context.Context, wishID string, limit
 				1 as sort_order
 			FROM users u
 			INNER JOIN wishes w ON u.id = w.user_id AND w.source_id = ?
+			WHERE ` + visibleWishCondition + `
 		)
 		SELECT id, username, name, avatar_url, followers, saved_at
 		FROM all_savers
+		WHERE id NOT IN (` + hiddenUsersSubquery + `)
+		  AND EXISTS (SELECT 1 FROM wishes w WHERE w.id = ? AND ` + visibleWishCondition + `)
 		ORDER BY sort_order, saved_at DESC
 		LIMIT ? OFFSET ?`
 
-	rows, err := s.db.QueryContext(ctx, query, wishID, wishID, limit, offset)
+	args := append([]interface{}{wishID, wishID, viewerID, viewerID}, hiddenUsersArgs(viewerID)...)
+	args = append(args, wishID, viewerID, viewerID)
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -117,9 +125,12 @@ func (s *Storage) GetUsersWhoSavedWish(ctx context.Context, wishID string, limit
 			SELECT u.id as user_id
 			FROM users u
 			INNER JOIN wishes w ON u.id = w.user_id AND w.source_id = ?
-		) as all_users`
+			WHERE ` + visibleWishCondition + `
+		) as all_users
+		WHERE user_id NOT IN (` + hiddenUsersSubquery + `)
+		  AND EXISTS (SELECT 1 FROM wishes w WHERE w.id = ? AND ` + visibleWishCondition + `)`
 
-	err = s.db.QueryRowContext(ctx, countQuery, wishID, wishID).Scan(&total)
+	err = s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Comment is a comment on a wish. Replies point at the top-level comment of
+// their thread, so threads are one level deep. Questions, and every reply in
+// a question's thread, are only visible to the wish owner and the asker.
+type Comment struct {
+	ID         string    `db:"id" json:"id"`
+	WishID     string    `db:"wish_id" json:"wish_id"`
+	UserID     string    `db:"user_id" json:"user_id"`
+	ParentID   *string   `db:"parent_id" json:"parent_id,omitempty"`
+	Body       string    `db:"body" json:"body"`
+	IsQuestion bool      `db:"is_question" json:"is_question"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+
+	Author      User   `json:"-"`
+	WishOwnerID string `json:"-"`
+	// ThreadUserID is the author of the top-level comment of the thread.
+	ThreadUserID string `json:"-"`
+}
+
+// commentCountColumn counts the public comments on w that the viewer can
+// see, leaving out those of hidden users and replies to them like the
+// comment list does. It takes the viewer's id three times, see
+// hiddenUsersArgs.
+const commentCountColumn = `(SELECT COUNT(*) FROM wish_comments cm
+	LEFT JOIN wish_comments cp ON cp.id = cm.parent_id
+	WHERE cm.wish_id = w.id AND cm.is_question = 0
+	  AND NOT EXISTS (
+		SELECT 1 FROM (` + hiddenUsersSubquery + `) h
+		WHERE h.blocked_id IN (cm.user_id, cp.user_id))) AS comment_count`
+
+// visibleCommentCondition hides questions from everyone but the wish owner
+// and the asker. It takes the viewer's id three times.
+const visibleCommentCondition = `(c.is_question = 0 OR w.user_id = ? OR COALESCE(p.user_id, c.user_id) = ?)
+	AND c.user_id NOT IN (` + hiddenUsersSubquery + `)`
+
+const commentColumns = `
+		c.id,
+		c.wish_id,
+		c.user_id,
+		c.parent_id,
+		c.body,
+		c.is_question,
+		c.created_at,
+		u.username,
+		u.name,
+		u.avatar_url,
+		w.user_id,
+		COALESCE(p.user_id, c.user_id)
+	FROM wish_comments c
+	JOIN users u ON u.id = c.user_id
+	JOIN wishes w ON w.id = c.wish_id
+	LEFT JOIN wish_comments p ON p.id = c.parent_id`
+
+func scanComment(scan func(dest ...interface{}) error) (Comment, error) {
+	var c Comment
+	if err := scan(
+		&c.ID,
+		&c.WishID,
+		&c.UserID,
+		&c.ParentID,
+		&c.Body,
+		&c.IsQuestion,
+		&c.CreatedAt,
+		&c.Author.Username,
+		&c.Author.Name,
+		&c.Author.AvatarURL,
+		&c.WishOwnerID,
+		&c.ThreadUserID,
+	); err != nil {
+		return Comment{}, err
+	}
+	c.Author.ID = c.UserID
+
+	return c, nil
+}
+
+func (s *Storage) CreateComment(ctx context.Context, c Comment) error {
+	query := `INSERT INTO wish_comments (id, wish_id, user_id, parent_id, body, is_question, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, query, c.ID, c.WishID, c.UserID, c.ParentID, c.Body, c.IsQuestion, c.CreatedAt)
+
+	return err
+}
+
+// GetComment returns a comment regardless of who may see it.
+func (s *Storage) GetComment(ctx context.Context, id string) (Comment, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+commentColumns+` WHERE c.id = ?`, id)
+
+	c, err := scanComment(row.Scan)
+	if err != nil && IsNoRowsError(err) {
+		return Comment{}, ErrNotFound
+	}
+
+	return c, err
+}
+
+// ListComments returns the comments on a wish that viewerID may see, oldest
+// first.
+func (s *Storage) ListComments(ctx context.Context, viewerID, wishID string) ([]Comment, error) {
+	query := `SELECT ` + commentColumns + `
+		WHERE c.wish_id = ? AND ` + visibleCommentCondition + `
+		ORDER BY c.created_at, c.rowid`
+
+	args := append([]interface{}{wishID, viewerID, viewerID}, hiddenUsersArgs(viewerID)...)
+
+	return s.listComments(ctx, query, args...)
+}
+
+func (s *Storage) listComments(ctx context.Context, query string, args ...interface{}) ([]Comment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]Comment, 0)
+	for rows.Next() {
+		c, err := scanComment(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	return comments, rows.Err()
+}
+
+// DeleteComment removes a comment together with its replies.
+func (s *Storage) DeleteComment(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM wish_comments WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -71,6 +71,11 @@ func (s *Storage) InitSchema() error {
 			updated_at    TIMESTAMP  NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			deleted_at    TIMESTAMP,
 			avatar_url    TEXT,
+			birthday      TEXT,
+			timezone      TEXT,
+			is_private    BOOLEAN    NOT NULL DEFAULT 0,
+			deletion_requested_at TIMESTAMP,
+			email_verified_at TIMESTAMP,
 			CONSTRAINT chat_id_unique UNIQUE (chat_id)
 		)`,
 		`CREATE TABLE IF NOT EXISTS categories(
@@ -92,6 +97,7 @@ func (s *Storage) InitSchema() error {
 			name        TEXT NOT NULL,
 			description TEXT,
 			is_public   BOOLEAN  DEFAULT 1,
+			event_date  TEXT,
 			created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			deleted_at  TIMESTAMP
@@ -113,7 +119,12 @@ func (s *Storage) InitSchema() error {
 			updated_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			published_at TIMESTAMP,
 			deleted_at   TIMESTAMP,
-			source_id    TEXT
+			source_id    TEXT,
+			visibility   TEXT NOT NULL DEFAULT 'public',
+			share_token  TEXT,
+			priority     TEXT    NOT NULL DEFAULT 'nice_to_have',
+			quantity     INTEGER NOT NULL DEFAULT 1,
+			received     INTEGER NOT NULL DEFAULT 0
 		);`,
 		`CREATE TABLE IF NOT EXISTS wish_images
 		(
@@ -151,6 +162,166 @@ func (s *Storage) InitSchema() error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			PRIMARY KEY (user_id, wish_id)
 		);`,
+		`CREATE TABLE IF NOT EXISTS referrals
+		(
+			referrer_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			referred_id TEXT NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+			source      TEXT NOT NULL,
+			start_param TEXT,
+			created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (referrer_id, referred_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS notifications
+		(
+			id           TEXT PRIMARY KEY,
+			user_id      TEXT    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			type         TEXT    NOT NULL,
+			actor_id     TEXT REFERENCES users (id) ON DELETE CASCADE,
+			wish_id      TEXT REFERENCES wishes (id) ON DELETE CASCADE,
+			push_pending  BOOLEAN NOT NULL DEFAULT 0,
+			push_attempts INTEGER NOT NULL DEFAULT 0,
+			push_retry_at TIMESTAMP,
+			pushed_at     TIMESTAMP,
+			read_at      TIMESTAMP,
+			created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS notification_preferences
+		(
+			user_id  TEXT    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			type     TEXT    NOT NULL,
+			telegram BOOLEAN NOT NULL DEFAULT 1,
+			PRIMARY KEY (user_id, type)
+		);`,
+		`CREATE TABLE IF NOT EXISTS sent_reminders
+		(
+			user_id   TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			kind      TEXT NOT NULL,
+			event_id  TEXT NOT NULL,
+			occurs_on TEXT NOT NULL,
+			sent_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, kind, event_id, occurs_on)
+		);`,
+		`CREATE TABLE IF NOT EXISTS wish_price_drops
+		(
+			wish_id    TEXT NOT NULL REFERENCES wishes (id) ON DELETE CASCADE,
+			old_price  REAL NOT NULL,
+			new_price  REAL NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS digests
+		(
+			user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			period  TEXT NOT NULL,
+			sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, period)
+		);`,
+		`CREATE TABLE IF NOT EXISTS sessions
+		(
+			id                  TEXT PRIMARY KEY,
+			user_id             TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			refresh_token_hash  TEXT      NOT NULL UNIQUE,
+			previous_token_hash TEXT,
+			user_agent          TEXT,
+			ip                  TEXT,
+			created_at          TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used_at        TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at          TIMESTAMP NOT NULL,
+			revoked_at          TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS api_tokens
+		(
+			id           TEXT PRIMARY KEY,
+			user_id      TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			name         TEXT NOT NULL,
+			token_hash   TEXT NOT NULL UNIQUE,
+			scope        TEXT NOT NULL,
+			last_used_at TIMESTAMP,
+			created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS login_tokens
+		(
+			token_hash TEXT PRIMARY KEY,
+			user_id    TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			expires_at TIMESTAMP NOT NULL,
+			used_at    TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS email_verifications
+		(
+			token_hash TEXT PRIMARY KEY,
+			user_id    TEXT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			email      TEXT      NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at    TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_blocks
+		(
+			user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			blocked_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, blocked_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS follow_requests
+		(
+			user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			target_id  TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, target_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS username_history
+		(
+			username   TEXT NOT NULL PRIMARY KEY COLLATE NOCASE,
+			user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS wish_comments
+		(
+			id          TEXT PRIMARY KEY,
+			wish_id     TEXT    NOT NULL REFERENCES wishes (id) ON DELETE CASCADE,
+			user_id     TEXT    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			parent_id   TEXT REFERENCES wish_comments (id) ON DELETE CASCADE,
+			body        TEXT    NOT NULL,
+			is_question BOOLEAN NOT NULL DEFAULT 0,
+			created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS wish_attributes
+		(
+			wish_id  TEXT    NOT NULL REFERENCES wishes (id) ON DELETE CASCADE,
+			key      TEXT    NOT NULL COLLATE NOCASE,
+			value    TEXT    NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (wish_id, key)
+		);`,
+		`CREATE TABLE IF NOT EXISTS wish_fulfillments
+		(
+			id             TEXT PRIMARY KEY,
+			wish_id        TEXT    NOT NULL REFERENCES wishes (id) ON DELETE CASCADE,
+			gifter_id      TEXT REFERENCES users (id) ON DELETE SET NULL,
+			quantity       INTEGER NOT NULL DEFAULT 1,
+			thank_you_note TEXT,
+			created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS wish_reactions
+		(
+			user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			wish_id    TEXT NOT NULL REFERENCES wishes (id) ON DELETE CASCADE,
+			reaction   TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, wish_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS blob_deletions
+		(
+			key        TEXT PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS user_mutes
+		(
+			user_id    TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			muted_id   TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, muted_id)
+		);`,
 	}
 
 	// Create regular tables first
@@ -160,6 +331,30 @@ func (s *Storage) InitSchema() error {
 		}
 	}
 
+	// Columns added after a table was first created are missing from existing
+	// databases, since CREATE TABLE IF NOT EXISTS leaves those tables alone.
+	columns := []struct{ table, column, definition string }{
+		{"users", "birthday", "TEXT"},
+		{"users", "timezone", "TEXT"},
+		{"users", "is_private", "BOOLEAN NOT NULL DEFAULT 0"},
+		{"users", "deletion_requested_at", "TIMESTAMP"},
+		{"users", "email_verified_at", "TIMESTAMP"},
+		{"wishlists", "event_date", "TEXT"},
+		{"wishes", "visibility", "TEXT NOT NULL DEFAULT 'public'"},
+		{"wishes", "share_token", "TEXT"},
+		{"wishes", "priority", "TEXT NOT NULL DEFAULT 'nice_to_have'"},
+		{"wishes", "quantity", "INTEGER NOT NULL DEFAULT 1"},
+		{"wishes", "received", "INTEGER NOT NULL DEFAULT 0"},
+		{"notifications", "push_attempts", "INTEGER NOT NULL DEFAULT 0"},
+		{"notifications", "push_retry_at", "TIMESTAMP"},
+	}
+
+	for _, col := range columns {
+		if err := addColumnIfNotExists(ctx, tx, col.table, col.column, col.definition); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", col.table, col.column, err)
+		}
+	}
+
 	// Create FTS5 virtual table
 	fts5Table := `CREATE VIRTUAL TABLE IF NOT EXISTS wishes_fts USING fts5
 	(
@@ -232,9 +427,93 @@ func (s *Storage) InitSchema() error {
 		}
 	}
 
+	// User search matches prefixes of usernames and names
+	usersFTSTable := `CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts5
+	(
+		user_id UNINDEXED,
+		username,
+		name,
+		tokenize='unicode61'
+	);`
+	if _, err := tx.ExecContext(ctx, usersFTSTable); err != nil {
+		return fmt.Errorf("failed to create users FTS5 table: %w", err)
+	}
+
+	usersFTSTriggers := []string{
+		`CREATE TRIGGER IF NOT EXISTS users_ai
+		AFTER INSERT ON users
+		BEGIN
+			INSERT INTO users_fts (user_id, username, name)
+			VALUES (new.id, new.username, IFNULL(new.name, ''));
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS users_ad
+		AFTER DELETE ON users
+		BEGIN
+			DELETE FROM users_fts WHERE user_id = old.id;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS users_au
+		AFTER UPDATE OF username, name ON users
+		BEGIN
+			UPDATE users_fts
+			SET username = new.username,
+				name     = IFNULL(new.name, '')
+			WHERE user_id = new.id;
+		END;`,
+	}
+
+	for _, trigger := range usersFTSTriggers {
+		if _, err := tx.ExecContext(ctx, trigger); err != nil {
+			return fmt.Errorf("failed to create users FTS5 trigger: %w", err)
+		}
+	}
+
+	// Price drops feed the weekly digest
+	priceDropTrigger := `CREATE TRIGGER IF NOT EXISTS wishes_price_drop
+	AFTER UPDATE OF price ON wishes
+	WHEN old.price IS NOT NULL AND new.price IS NOT NULL AND new.price < old.price
+	BEGIN
+		INSERT INTO wish_price_drops (wish_id, old_price, new_price) VALUES (new.id, old.price, new.price);
+	END;`
+	if _, err := tx.ExecContext(ctx, priceDropTrigger); err != nil {
+		return fmt.Errorf("failed to create price drop trigger: %w", err)
+	}
+
+	// Usernames became unique ignoring case; older databases may hold
+	// duplicates, which fall back to a generated handle.
+	if err := dedupeUsernames(ctx, tx); err != nil {
+		return fmt.Errorf("failed to deduplicate usernames: %w", err)
+	}
+
+	// Wishes marked fulfilled before gifts were counted got everything they
+	// wanted; received is what decides whether a wish is fulfilled now.
+	backfillReceived := `UPDATE wishes SET received = quantity WHERE is_fulfilled = 1 AND received < quantity`
+	if _, err := tx.ExecContext(ctx, backfillReceived); err != nil {
+		return fmt.Errorf("failed to backfill received quantities: %w", err)
+	}
+
 	// Create indexes
 	indexes := []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS users_username_index ON users (username COLLATE NOCASE);`,
+		`CREATE INDEX IF NOT EXISTS username_history_user_id_index ON username_history (user_id);`,
 		`CREATE INDEX IF NOT EXISTS users_chat_id_index ON users (chat_id);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS users_verified_email_index ON users (email COLLATE NOCASE) WHERE email_verified_at IS NOT NULL;`,
+		`CREATE INDEX IF NOT EXISTS referrals_referrer_id_index ON referrals (referrer_id);`,
+		`CREATE INDEX IF NOT EXISTS notifications_user_id_index ON notifications (user_id, created_at);`,
+		`CREATE INDEX IF NOT EXISTS wish_price_drops_wish_id_index ON wish_price_drops (wish_id, created_at);`,
+		`CREATE INDEX IF NOT EXISTS notifications_push_pending_index ON notifications (push_pending) WHERE push_pending = 1;`,
+		`CREATE INDEX IF NOT EXISTS sessions_user_id_index ON sessions (user_id);`,
+		`CREATE INDEX IF NOT EXISTS sessions_previous_token_hash_index ON sessions (previous_token_hash);`,
+		`CREATE INDEX IF NOT EXISTS api_tokens_user_id_index ON api_tokens (user_id);`,
+		`CREATE INDEX IF NOT EXISTS user_blocks_blocked_id_index ON user_blocks (blocked_id);`,
+		`CREATE INDEX IF NOT EXISTS follow_requests_target_id_index ON follow_requests (target_id);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS wishes_share_token_index ON wishes (share_token);`,
+		`CREATE INDEX IF NOT EXISTS followers_following_id_index ON followers (following_id);`,
+		`CREATE INDEX IF NOT EXISTS wish_comments_wish_id_index ON wish_comments (wish_id, created_at);`,
+		`CREATE INDEX IF NOT EXISTS wish_comments_parent_id_index ON wish_comments (parent_id);`,
+		`CREATE INDEX IF NOT EXISTS wish_comments_user_id_index ON wish_comments (user_id);`,
+		`CREATE INDEX IF NOT EXISTS wish_reactions_wish_id_index ON wish_reactions (wish_id, reaction);`,
+		`CREATE INDEX IF NOT EXISTS wish_fulfillments_wish_id_index ON wish_fulfillments (wish_id);`,
+		`CREATE INDEX IF NOT EXISTS wish_fulfillments_gifter_id_index ON wish_fulfillments (gifter_id);`,
 	}
 
 	for _, stmt := range indexes {
@@ -315,6 +594,16 @@ func (s *Storage) InitSchema() error {
 		return fmt.Errorf("failed to populate FTS5 table: %w", err)
 	}
 
+	populateUsersFTS := `
+	INSERT INTO users_fts (user_id, username, name)
+	SELECT u.id, u.username, IFNULL(u.name, '')
+	FROM users u
+	WHERE NOT EXISTS (SELECT 1 FROM users_fts WHERE user_id = u.id);`
+
+	if _, err := tx.ExecContext(ctx, populateUsersFTS); err != nil {
+		return fmt.Errorf("failed to populate users FTS5 table: %w", err)
+	}
+
 	return tx.Commit()
 }
 
@@ -400,3 +689,73 @@ func (s *Storage) Health() (HealthStats, error) {
 
 	return stats, nil
 }
+
+// dedupeUsernames renames users without a username, or with one an older
+// account uses in another case, to user_<chat id>. The handle is numbered
+// when someone already has it.
+func dedupeUsernames(ctx context.Context, tx *sql.Tx) error {
+	query := `
+		SELECT id, IFNULL(CAST(chat_id AS TEXT), id)
+		FROM users
+		WHERE username IS NULL
+		   OR username = ''
+		   OR EXISTS (SELECT 1 FROM users o WHERE o.username = users.username COLLATE NOCASE AND o.rowid < users.rowid)
+		ORDER BY rowid`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	var renames [][2]string
+	for rows.Next() {
+		var id, suffix string
+		if err := rows.Scan(&id, &suffix); err != nil {
+			rows.Close()
+			return err
+		}
+		renames = append(renames, [2]string{id, suffix})
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range renames {
+		base := "user_" + r[1]
+		username := base
+		for n := 2; ; n++ {
+			var taken bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE username = ? COLLATE NOCASE)`, username).Scan(&taken)
+			if err != nil {
+				return err
+			}
+			if !taken {
+				break
+			}
+			username = fmt.Sprintf("%s_%d", base, n)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET username = ? WHERE id = ?`, username, r[0]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addColumnIfNotExists(ctx context.Context, tx *sql.Tx, table, column, definition string) error {
+	var exists bool
+	query := `SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?`
+	if err := tx.QueryRowContext(ctx, query, table, column).Scan(&exists); err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+type DigestRecipient struct {
+	ID           string
+	ChatID       int64
+	LanguageCode string
+	Timezone     *string
+}
+
+// DigestWish is a wish from a followed user shown in the weekly digest.
+// OldPrice is set for price drops.
+type DigestWish struct {
+	ID       string
+	Name     *string
+	Price    *float64
+	Currency *string
+	OldPrice *float64
+	Owner    User
+}
+
+// WishSaves counts how many people saved one of the user's wishes.
+type WishSaves struct {
+	WishID   string
+	WishName *string
+	Saves    int
+}
+
+// ListDigestRecipients returns users who get the weekly digest and have not
+// received one since the given time.
+func (s *Storage) ListDigestRecipients(ctx context.Context, notSince time.Time) ([]DigestRecipient, error) {
+	query := `
+		SELECT u.id, u.chat_id, u.language_code, u.timezone
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id AND np.type = ?
+		WHERE u.deleted_at IS NULL
+		  AND IFNULL(np.telegram, 1) = 1
+		  AND NOT EXISTS (
+			SELECT 1 FROM digests d WHERE d.user_id = u.id AND datetime(d.sent_at) >= datetime(?)
+		  )`
+
+	rows, err := s.db.QueryContext(ctx, query, NotificationTypeDigest, notSince.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := make([]DigestRecipient, 0)
+	for rows.Next() {
+		var (
+			r    DigestRecipient
+			lang *string
+		)
+		if err := rows.Scan(&r.ID, &r.ChatID, &lang, &r.Timezone); err != nil {
+			return nil, err
+		}
+		if lang != nil {
+			r.LanguageCode = *lang
+		}
+		recipients = append(recipients, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// MarkDigestSent claims the digest for a period. It reports false when the
+// digest was already sent, so a restarted job never sends it twice.
+func (s *Storage) MarkDigestSent(ctx context.Context, uid, period string, sentAt time.Time) (bool, error) {
+	query := `INSERT OR IGNORE INTO digests (user_id, period, sent_at) VALUES (?, ?, ?)`
+
+	res, err := s.db.ExecContext(ctx, query, uid, period, sentAt.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (s *Storage) scanDigestWishes(ctx context.Context, query string, args ...interface{}) ([]DigestWish, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wishes := make([]DigestWish, 0)
+	for rows.Next() {
+		var w DigestWish
+		if err := rows.Scan(
+			&w.ID,
+			&w.Name,
+			&w.Price,
+			&w.Currency,
+			&w.OldPrice,
+			&w.Owner.ID,
+			&w.Owner.Username,
+			&w.Owner.Name,
+		); err != nil {
+			return nil, err
+		}
+		wishes = append(wishes, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return wishes, nil
+}
+
+// ListNewFollowedWishes returns wishes published since the given time by users
+// the user follows, newest first, together with their total number. Like the
+// feed, it leaves out authors hidden from the user and unfinished wishes.
+func (s *Storage) ListNewFollowedWishes(ctx context.Context, uid string, since time.Time, limit int) ([]DigestWish, int, error) {
+	from := `
+		FROM wishes w
+		JOIN followers f ON f.following_id = w.user_id AND f.follower_id = ?
+		JOIN users u ON u.id = w.user_id
+		WHERE w.published_at IS NOT NULL
+		  AND datetime(w.published_at) >= datetime(?)
+		  AND w.deleted_at IS NULL
+		  AND w.user_id NOT IN (` + hiddenUsersSubquery + `)
+		  AND ` + listedWishCondition + `
+		  AND ` + followerWishCondition
+
+	args := append([]interface{}{uid, since.UTC().Format(sqliteTimeLayout)}, hiddenUsersArgs(uid)...)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)`+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT w.id, w.name, w.price, w.currency, NULL, u.id, u.username, u.name` + from + `
+		ORDER BY w.published_at DESC
+		LIMIT ?`
+
+	wishes, err := s.scanDigestWishes(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return wishes, total, nil
+}
+
+// ListFollowedPriceDrops returns wishes of followed users that are cheaper now
+// than at any point since the given time, filtered like ListNewFollowedWishes.
+func (s *Storage) ListFollowedPriceDrops(ctx context.Context, uid string, since time.Time, limit int) ([]DigestWish, error) {
+	query := `
+		SELECT w.id, w.name, w.price, w.currency, MAX(d.old_price), u.id, u.username, u.name
+		FROM wish_price_drops d
+		JOIN wishes w ON w.id = d.wish_id
+		JOIN followers f ON f.following_id = w.user_id AND f.follower_id = ?
+		JOIN users u ON u.id = w.user_id
+		WHERE datetime(d.created_at) >= datetime(?)
+		  AND w.published_at IS NOT NULL
+		  AND w.deleted_at IS NULL
+		  AND w.user_id NOT IN (` + hiddenUsersSubquery + `)
+		  AND ` + listedWishCondition + `
+		  AND ` + followerWishCondition + `
+		GROUP BY w.id
+		HAVING w.price < MAX(d.old_price)
+		ORDER BY MAX(d.created_at) DESC
+		LIMIT ?`
+
+	args := append([]interface{}{uid, since.UTC().Format(sqliteTimeLayout)}, hiddenUsersArgs(uid)...)
+
+	return s.scanDigestWishes(ctx, query, append(args, limit)...)
+}
+
+// ListNewSaves counts, per wish, how many other users copied or bookmarked the
+// user's wishes since the given time.
+func (s *Storage) ListNewSaves(ctx context.Context, uid string, since time.Time) ([]WishSaves, error) {
+	query := `
+		WITH saves AS (
+			SELECT c.source_id AS wish_id, c.user_id
+			FROM wishes c
+			WHERE c.source_id IS NOT NULL AND datetime(c.created_at) >= datetime(?)
+			UNION
+			SELECT b.wish_id, b.user_id
+			FROM user_bookmarks b
+			WHERE datetime(b.created_at) >= datetime(?)
+		)
+		SELECT w.id, w.name, COUNT(DISTINCT s.user_id) AS saves
+		FROM saves s
+		JOIN wishes w ON w.id = s.wish_id
+		WHERE w.user_id = ? AND s.user_id != ? AND w.deleted_at IS NULL
+		GROUP BY w.id
+		ORDER BY saves DESC, w.created_at DESC`
+
+	sinceArg := since.UTC().Format(sqliteTimeLayout)
+
+	rows, err := s.db.QueryContext(ctx, query, sinceArg, sinceArg, uid, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	saves := make([]WishSaves, 0)
+	for rows.Next() {
+		var ws WishSaves
+		if err := rows.Scan(&ws.WishID, &ws.WishName, &ws.Saves); err != nil {
+			return nil, err
+		}
+		saves = append(saves, ws)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return saves, nil
+}
@@ -0,0 +1,91 @@
+package db
+
+import "context"
+
+// CountFollows returns how many users follow uid and how many uid follows,
+// counting the same users ListFollowers and ListFollowing show to viewerID.
+func (s *Storage) CountFollows(ctx context.Context, viewerID, uid string) (followers int, following int, err error) {
+	query := `
+		SELECT (SELECT COUNT(*)` + followsFrom("follower_id", "following_id") + `),
+		       (SELECT COUNT(*)` + followsFrom("following_id", "follower_id") + `)`
+
+	args := append([]interface{}{uid}, hiddenUsersArgs(viewerID)...)
+	args = append(args, uid)
+	args = append(args, hiddenUsersArgs(viewerID)...)
+
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&followers, &following)
+
+	return followers, following, err
+}
+
+// ListFollowers returns a page of the users following uid, most recent first,
+// and their total number. IsFollowing tells whether viewerID follows each of
+// them; users hidden from viewerID are left out.
+func (s *Storage) ListFollowers(ctx context.Context, viewerID, uid string, limit, offset int) ([]User, int, error) {
+	return s.listFollows(ctx, "follower_id", "following_id", viewerID, uid, limit, offset)
+}
+
+// ListFollowing returns a page of the users uid follows, like ListFollowers.
+func (s *Storage) ListFollowing(ctx context.Context, viewerID, uid string, limit, offset int) ([]User, int, error) {
+	return s.listFollows(ctx, "following_id", "follower_id", viewerID, uid, limit, offset)
+}
+
+// listFollows lists the users in column userColumn of followers rows whose
+// ownerColumn is uid.
+func (s *Storage) listFollows(ctx context.Context, userColumn, ownerColumn, viewerID, uid string, limit, offset int) ([]User, int, error) {
+	from := followsFrom(userColumn, ownerColumn)
+
+	args := append([]interface{}{uid}, hiddenUsersArgs(viewerID)...)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)`+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT u.id, u.username, u.name, u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers,
+		       EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = u.id) AS is_following` + from + `
+		ORDER BY r.created_at DESC, r.rowid DESC
+		LIMIT ? OFFSET ?`
+
+	users, err := s.listUsersWithFollowing(ctx, query, append(append([]interface{}{viewerID}, args...), limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// followsFrom joins the users in column userColumn of followers rows whose
+// ownerColumn is the first argument, leaving out deleted users and those
+// hidden from the viewer. It takes the owner's id, then hiddenUsersArgs.
+func followsFrom(userColumn, ownerColumn string) string {
+	return `
+		FROM followers r
+		JOIN users u ON u.id = r.` + userColumn + `
+		WHERE r.` + ownerColumn + ` = ?
+		  AND u.deleted_at IS NULL
+		  AND u.id NOT IN (` + hiddenUsersSubquery + `)`
+}
+
+// listUsersWithFollowing runs a query selecting id, username, name,
+// avatar_url, followers and is_following.
+func (s *Storage) listUsersWithFollowing(ctx context.Context, query string, args ...interface{}) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Name, &user.AvatarURL, &user.Followers, &user.IsFollowing); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,117 @@
+package db
+
+import "context"
+
+// CreateFollowRequest asks targetID, a private account, to let uid follow
+// them. It returns ErrAlreadyExists for a pending request.
+func (s *Storage) CreateFollowRequest(ctx context.Context, uid, targetID string) error {
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO follow_requests (user_id, target_id) VALUES (?, ?)`, uid, targetID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *Storage) HasFollowRequest(ctx context.Context, uid, targetID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM follow_requests WHERE user_id = ? AND target_id = ?)`, uid, targetID).Scan(&exists)
+
+	return exists, err
+}
+
+// ListFollowRequests returns the users waiting for uid's approval, oldest
+// first.
+func (s *Storage) ListFollowRequests(ctx context.Context, uid string) ([]User, error) {
+	query := `
+		SELECT u.id, u.username, u.name, u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers
+		FROM follow_requests r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.target_id = ?
+		ORDER BY r.created_at`
+
+	return s.listRelatedUsers(ctx, query, uid)
+}
+
+// AcceptFollowRequest turns requesterID's pending request into a follow.
+func (s *Storage) AcceptFollowRequest(ctx context.Context, uid, requesterID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM follow_requests WHERE user_id = ? AND target_id = ?`, requesterID, uid)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO followers (following_id, follower_id) VALUES (?, ?)`, uid, requesterID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AcceptAllFollowRequests follows through on every pending request, used when
+// an account stops being private.
+func (s *Storage) AcceptAllFollowRequests(ctx context.Context, uid string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT OR IGNORE INTO followers (following_id, follower_id)
+		SELECT target_id, user_id FROM follow_requests WHERE target_id = ?`
+
+	if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM follow_requests WHERE target_id = ?`, uid); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFollowRequest removes a pending request, whether the target declines
+// it or the requester withdraws it.
+func (s *Storage) DeleteFollowRequest(ctx context.Context, uid, targetID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM follow_requests WHERE user_id = ? AND target_id = ?`, uid, targetID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Fulfillment records a gift the owner of a wish received.
+type Fulfillment struct {
+	ID           string    `db:"id" json:"id"`
+	WishID       string    `db:"wish_id" json:"wish_id"`
+	GifterID     *string   `db:"gifter_id" json:"gifter_id"`
+	Quantity     int       `db:"quantity" json:"quantity"`
+	ThankYouNote *string   `db:"thank_you_note" json:"thank_you_note,omitempty"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// ReceivedGift is a fulfillment as listed on the owner's profile.
+type ReceivedGift struct {
+	Fulfillment
+	WishName  *string `json:"wish_name"`
+	WishImage *string `json:"wish_image"`
+	Gifter    *User   `json:"gifter,omitempty"`
+}
+
+// FulfillWish records that uid received quantity items of their wish from
+// whoever reserved it, clears the reservation and marks the wish fulfilled
+// once everything wanted was received. Quantity is capped at what is still
+// missing. It returns ErrNotFound for wishes uid does not own and
+// ErrAlreadyExists for fulfilled ones or ones with nothing missing.
+func (s *Storage) FulfillWish(ctx context.Context, uid string, f Fulfillment) (Fulfillment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Fulfillment{}, err
+	}
+	defer tx.Rollback()
+
+	var (
+		wanted, received int
+		fulfilled        bool
+	)
+	query := `SELECT quantity, received, is_fulfilled, reserved_by FROM wishes WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+	err = tx.QueryRowContext(ctx, query, f.WishID, uid).Scan(&wanted, &received, &fulfilled, &f.GifterID)
+	if err != nil && IsNoRowsError(err) {
+		return Fulfillment{}, ErrNotFound
+	} else if err != nil {
+		return Fulfillment{}, err
+	}
+
+	missing := wanted - received
+	if fulfilled || missing <= 0 {
+		return Fulfillment{}, ErrAlreadyExists
+	}
+
+	if f.Quantity < 1 {
+		f.Quantity = 1
+	}
+	if f.Quantity > missing {
+		f.Quantity = missing
+	}
+
+	insert := `INSERT INTO wish_fulfillments (id, wish_id, gifter_id, quantity, thank_you_note, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, insert, f.ID, f.WishID, f.GifterID, f.Quantity, f.ThankYouNote, f.CreatedAt); err != nil {
+		return Fulfillment{}, err
+	}
+
+	update := `
+		UPDATE wishes
+		SET received     = received + ?,
+		    is_fulfilled = received + ? >= quantity,
+		    reserved_by  = NULL,
+		    reserved_at  = NULL,
+		    updated_at   = CURRENT_TIMESTAMP
+		WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, update, f.Quantity, f.Quantity, f.WishID); err != nil {
+		return Fulfillment{}, err
+	}
+
+	return f, tx.Commit()
+}
+
+// UnfulfillWish undoes the latest gift recorded for uid's wish and marks the
+// wish unfulfilled again if something is missing afterwards. Wishes fulfilled
+// before gifts were recorded have no history, so their received count is
+// reset instead. It returns ErrNotFound for wishes uid does not own.
+func (s *Storage) UnfulfillWish(ctx context.Context, uid, wishID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var received int
+	query := `SELECT received FROM wishes WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+	err = tx.QueryRowContext(ctx, query, wishID, uid).Scan(&received)
+	if err != nil && IsNoRowsError(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	var (
+		latestID string
+		quantity int
+	)
+	query = `SELECT id, quantity FROM wish_fulfillments WHERE wish_id = ? ORDER BY created_at DESC, rowid DESC LIMIT 1`
+	err = tx.QueryRowContext(ctx, query, wishID).Scan(&latestID, &quantity)
+	if err != nil && IsNoRowsError(err) {
+		quantity = received
+	} else if err != nil {
+		return err
+	} else if _, err := tx.ExecContext(ctx, `DELETE FROM wish_fulfillments WHERE id = ?`, latestID); err != nil {
+		return err
+	}
+
+	update := `
+		UPDATE wishes
+		SET received     = MAX(received - ?, 0),
+		    is_fulfilled = MAX(received - ?, 0) >= quantity,
+		    updated_at   = CURRENT_TIMESTAMP
+		WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, update, quantity, quantity, wishID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListReceivedGifts returns a page of the gifts uid received on wishes
+// viewerID may see, most recent first, and their total number. Gifters and
+// thank-you notes are only shown to the owner and the gifter; gifters hidden
+// from viewerID are left out as well.
+func (s *Storage) ListReceivedGifts(ctx context.Context, viewerID, uid string, limit, offset int) ([]ReceivedGift, int, error) {
+	from := `
+		FROM wish_fulfillments f
+		JOIN wishes w ON w.id = f.wish_id
+		LEFT JOIN users g ON g.id = f.gifter_id
+			AND (w.user_id = ? OR f.gifter_id = ?)
+			AND g.deleted_at IS NULL
+			AND g.id NOT IN (` + hiddenUsersSubquery + `)
+		WHERE w.user_id = ? AND w.deleted_at IS NULL AND ` + visibleWishCondition
+
+	args := append([]interface{}{viewerID, viewerID}, hiddenUsersArgs(viewerID)...)
+	args = append(args, uid, viewerID, viewerID)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)`+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT f.id, f.wish_id, f.quantity, f.created_at,
+		       CASE WHEN w.user_id = ? OR f.gifter_id = ? THEN f.thank_you_note END,
+		       w.name,
+		       (SELECT i.url FROM wish_images i WHERE i.wish_id = w.id ORDER BY i.position LIMIT 1),
+		       g.id, g.username, g.name, g.avatar_url` + from + `
+		ORDER BY f.created_at DESC, f.rowid DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, append(append([]interface{}{viewerID, viewerID}, args...), limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	gifts := make([]ReceivedGift, 0)
+	for rows.Next() {
+		var (
+			gift                     ReceivedGift
+			gifterID, gifterUsername *string
+			gifter                   User
+		)
+		if err := rows.Scan(
+			&gift.ID,
+			&gift.WishID,
+			&gift.Quantity,
+			&gift.CreatedAt,
+			&gift.ThankYouNote,
+			&gift.WishName,
+			&gift.WishImage,
+			&gifterID,
+			&gifterUsername,
+			&gifter.Name,
+			&gifter.AvatarURL,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		if gifterID != nil {
+			gifter.ID = *gifterID
+			gifter.Username = *gifterUsername
+			gift.GifterID = gifterID
+			gift.Gifter = &gifter
+		}
+		gifts = append(gifts, gift)
+	}
+
+	return gifts, total, rows.Err()
+}
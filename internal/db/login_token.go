@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// FindUserIDByEmail returns the user with the given confirmed email.
+// Unconfirmed addresses are ignored, so nobody can sign in with an email
+// they have only typed into their settings.
+func (s *Storage) FindUserIDByEmail(ctx context.Context, email string) (string, error) {
+	query := `
+		SELECT id FROM users
+		WHERE email = ? COLLATE NOCASE AND email_verified_at IS NOT NULL AND deleted_at IS NULL`
+
+	var id string
+	if err := s.db.QueryRowContext(ctx, query, email).Scan(&id); err != nil && IsNoRowsError(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) CreateLoginToken(ctx context.Context, uid, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO login_tokens (token_hash, user_id, expires_at) VALUES (?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, query, tokenHash, uid, expiresAt.UTC().Format(sqliteTimeLayout))
+
+	return err
+}
+
+func (s *Storage) CountLoginTokensSince(ctx context.Context, uid string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM login_tokens WHERE user_id = ? AND datetime(created_at) >= datetime(?)`
+
+	var count int
+	err := s.db.QueryRowContext(ctx, query, uid, since.UTC().Format(sqliteTimeLayout)).Scan(&count)
+
+	return count, err
+}
+
+// ConsumeLoginToken marks an unexpired login token as used and returns its
+// user. Each token works once.
+func (s *Storage) ConsumeLoginToken(ctx context.Context, tokenHash string) (string, error) {
+	query := `
+		UPDATE login_tokens
+		SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND datetime(expires_at) > datetime(?)
+		RETURNING user_id`
+
+	now := time.Now().UTC().Format(sqliteTimeLayout)
+
+	var uid string
+	if err := s.db.QueryRowContext(ctx, query, now, tokenHash, now).Scan(&uid); err != nil && IsNoRowsError(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	return uid, nil
+}
+
+func (s *Storage) CreateEmailVerification(ctx context.Context, uid, email, tokenHash string, expiresAt time.Time) error {
+	query := `INSERT INTO email_verifications (token_hash, user_id, email, expires_at) VALUES (?, ?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, query, tokenHash, uid, email, expiresAt.UTC().Format(sqliteTimeLayout))
+
+	return err
+}
+
+func (s *Storage) CountEmailVerificationsSince(ctx context.Context, uid string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM email_verifications WHERE user_id = ? AND datetime(created_at) >= datetime(?)`
+
+	var count int
+	err := s.db.QueryRowContext(ctx, query, uid, since.UTC().Format(sqliteTimeLayout)).Scan(&count)
+
+	return count, err
+}
+
+// ConfirmEmail consumes an email verification token and marks the address
+// as confirmed. The token is void once the user has switched to another
+// address, and ErrAlreadyExists means another account confirmed it first.
+func (s *Storage) ConfirmEmail(ctx context.Context, tokenHash string) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(sqliteTimeLayout)
+
+	var uid, email string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE email_verifications
+		SET used_at = ?
+		WHERE token_hash = ? AND used_at IS NULL AND datetime(expires_at) > datetime(?)
+		RETURNING user_id, email`, now, tokenHash, now).Scan(&uid, &email)
+	if err != nil && IsNoRowsError(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE users
+		SET email_verified_at = ?
+		WHERE id = ? AND email = ? COLLATE NOCASE AND deleted_at IS NULL`, now, uid, email)
+	if err != nil && IsUniqueViolationError(err) {
+		return "", ErrAlreadyExists
+	} else if err != nil {
+		return "", err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return "", err
+	} else if n == 0 {
+		return "", ErrNotFound
+	}
+
+	return uid, tx.Commit()
+}
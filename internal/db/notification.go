@@ -0,0 +1,323 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+const (
+	NotificationTypeFollow        = "follow"
+	NotificationTypeFollowRequest = "follow_request"
+	NotificationTypeBookmark      = "bookmark"
+	NotificationTypeCopy          = "copy"
+	NotificationTypeReminder      = "reminder"
+	NotificationTypeDigest        = "digest"
+	NotificationTypeComment       = "comment"
+)
+
+// NotificationTypes lists every notification type a user can configure.
+var NotificationTypes = []string{
+	NotificationTypeFollow,
+	NotificationTypeFollowRequest,
+	NotificationTypeBookmark,
+	NotificationTypeCopy,
+	NotificationTypeReminder,
+	NotificationTypeDigest,
+	NotificationTypeComment,
+}
+
+type Notification struct {
+	ID        string     `db:"id" json:"id"`
+	UserID    string     `db:"user_id" json:"user_id"`
+	Type      string     `db:"type" json:"type"`
+	ActorID   *string    `db:"actor_id" json:"actor_id"`
+	WishID    *string    `db:"wish_id" json:"wish_id"`
+	ReadAt    *time.Time `db:"read_at" json:"read_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+
+	Actor    *User   `json:"actor,omitempty"`
+	WishName *string `json:"wish_name,omitempty"`
+}
+
+// PendingNotification is a notification waiting for Telegram delivery
+// together with the recipient's chat. PushAttempts counts failed sends.
+type PendingNotification struct {
+	Notification
+	ChatID       int64
+	LanguageCode string
+	PushAttempts int
+}
+
+type NotificationPreference struct {
+	Type     string `db:"type" json:"type"`
+	Telegram bool   `db:"telegram" json:"telegram"`
+}
+
+// CreateNotification stores a notification unless the same unread one already
+// exists, so repeated follow/unfollow does not flood the inbox. Push marks it
+// for Telegram delivery.
+func (s *Storage) CreateNotification(ctx context.Context, n Notification, push bool) error {
+	query := `
+		INSERT INTO notifications (id, user_id, type, actor_id, wish_id, push_pending)
+		SELECT ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notifications
+			WHERE user_id = ? AND type = ? AND actor_id IS ? AND wish_id IS ? AND read_at IS NULL
+		)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		n.ID, n.UserID, n.Type, n.ActorID, n.WishID, push,
+		n.UserID, n.Type, n.ActorID, n.WishID,
+	)
+
+	return err
+}
+
+const notificationColumns = `
+		n.id,
+		n.user_id,
+		n.type,
+		n.actor_id,
+		n.wish_id,
+		n.read_at,
+		n.created_at,
+		a.username,
+		a.name,
+		a.avatar_url,
+		w.name`
+
+func scanNotification(scan func(dest ...interface{}) error, extra ...interface{}) (Notification, error) {
+	var (
+		n             Notification
+		actorUsername sql.NullString
+		actorName     *string
+		actorAvatar   *string
+	)
+
+	dest := []interface{}{
+		&n.ID,
+		&n.UserID,
+		&n.Type,
+		&n.ActorID,
+		&n.WishID,
+		&n.ReadAt,
+		&n.CreatedAt,
+		&actorUsername,
+		&actorName,
+		&actorAvatar,
+		&n.WishName,
+	}
+
+	if err := scan(append(dest, extra...)...); err != nil {
+		return Notification{}, err
+	}
+
+	if n.ActorID != nil && actorUsername.Valid {
+		n.Actor = &User{
+			ID:        *n.ActorID,
+			Username:  actorUsername.String,
+			Name:      actorName,
+			AvatarURL: actorAvatar,
+		}
+	}
+
+	return n, nil
+}
+
+// ListNotifications returns the user's inbox, newest first.
+func (s *Storage) ListNotifications(ctx context.Context, uid string, limit, offset int) ([]Notification, error) {
+	query := `SELECT` + notificationColumns + `
+		FROM notifications n
+		LEFT JOIN users a ON a.id = n.actor_id
+		LEFT JOIN wishes w ON w.id = n.wish_id
+		WHERE n.user_id = ?
+		ORDER BY n.created_at DESC, n.id
+		LIMIT ? OFFSET ?`
+
+	rows, err := s.db.QueryContext(ctx, query, uid, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		n, err := scanNotification(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (s *Storage) CountUnreadNotifications(ctx context.Context, uid string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, uid,
+	).Scan(&count)
+
+	return count, err
+}
+
+// MarkNotificationsRead marks the given notifications as read, or all of the
+// user's notifications when ids is empty. Read notifications are no longer
+// sent to Telegram.
+func (s *Storage) MarkNotificationsRead(ctx context.Context, uid string, ids []string) error {
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP, push_pending = 0 WHERE user_id = ? AND read_at IS NULL`
+	args := []interface{}{uid}
+
+	if len(ids) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	_, err := s.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ListPendingNotifications returns notifications waiting for Telegram
+// delivery, oldest first, grouped by recipient. Recipients whose last send
+// failed are skipped until their retry time has come.
+func (s *Storage) ListPendingNotifications(ctx context.Context, now time.Time, limit int) ([]PendingNotification, error) {
+	query := `SELECT` + notificationColumns + `,
+		u.chat_id,
+		u.language_code,
+		n.push_attempts
+		FROM notifications n
+		JOIN users u ON u.id = n.user_id
+		LEFT JOIN users a ON a.id = n.actor_id
+		LEFT JOIN wishes w ON w.id = n.wish_id
+		WHERE n.push_pending = 1
+		  AND NOT EXISTS (
+			SELECT 1 FROM notifications r
+			WHERE r.user_id = n.user_id AND r.push_pending = 1
+			  AND datetime(r.push_retry_at) > datetime(?))
+		ORDER BY n.user_id, n.created_at
+		LIMIT ?`
+
+	rows, err := s.db.QueryContext(ctx, query, now.UTC().Format(sqliteTimeLayout), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := make([]PendingNotification, 0)
+	for rows.Next() {
+		var (
+			p    PendingNotification
+			lang sql.NullString
+		)
+
+		n, err := scanNotification(rows.Scan, &p.ChatID, &lang, &p.PushAttempts)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Notification = n
+		p.LanguageCode = lang.String
+		pending = append(pending, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// MarkNotificationsPushed takes notifications off the Telegram delivery queue.
+func (s *Storage) MarkNotificationsPushed(ctx context.Context, ids []string) error {
+	return s.updateNotifications(ctx, `push_pending = 0, pushed_at = CURRENT_TIMESTAMP`, ids)
+}
+
+// RetryNotifications keeps notifications whose send failed on the Telegram
+// delivery queue, counting the attempt and holding them back until retryAt.
+func (s *Storage) RetryNotifications(ctx context.Context, ids []string, retryAt time.Time) error {
+	return s.updateNotifications(ctx, `push_attempts = push_attempts + 1, push_retry_at = ?`, ids, retryAt.UTC().Format(sqliteTimeLayout))
+}
+
+func (s *Storage) updateNotifications(ctx context.Context, set string, ids []string, setArgs ...interface{}) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `UPDATE notifications SET ` + set + ` WHERE id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+
+	args := append(make([]interface{}, 0, len(setArgs)+len(ids)), setArgs...)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	_, err := s.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// GetNotificationPreferences returns a preference for every notification type.
+// Types the user never configured are delivered to Telegram.
+func (s *Storage) GetNotificationPreferences(ctx context.Context, uid string) ([]NotificationPreference, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT type, telegram FROM notification_preferences WHERE user_id = ?`, uid,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	saved := make(map[string]bool)
+	for rows.Next() {
+		var (
+			typ      string
+			telegram bool
+		)
+		if err := rows.Scan(&typ, &telegram); err != nil {
+			return nil, err
+		}
+		saved[typ] = telegram
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prefs := make([]NotificationPreference, 0, len(NotificationTypes))
+	for _, typ := range NotificationTypes {
+		telegram, ok := saved[typ]
+		if !ok {
+			telegram = true
+		}
+		prefs = append(prefs, NotificationPreference{Type: typ, Telegram: telegram})
+	}
+
+	return prefs, nil
+}
+
+func (s *Storage) UpdateNotificationPreferences(ctx context.Context, uid string, prefs []NotificationPreference) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO notification_preferences (user_id, type, telegram)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id, type) DO UPDATE SET telegram = excluded.telegram`
+
+	for _, p := range prefs {
+		if _, err := tx.ExecContext(ctx, query, uid, p.Type, p.Telegram); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+const (
+	ReactionLike    = "like"
+	ReactionFire    = "fire"
+	ReactionWantToo = "want_too"
+)
+
+// Reactions lists every reaction a user can leave on a wish.
+var Reactions = []string{ReactionLike, ReactionFire, ReactionWantToo}
+
+func IsReaction(reaction string) bool {
+	return slices.Contains(Reactions, reaction)
+}
+
+// feedRankExpr ranks feed wishes by creation time boosted by their
+// reactions: each one makes a wish look six hours (0.25 days) fresher.
+const feedRankExpr = `(julianday(w.created_at) + 0.25 * (SELECT COUNT(*) FROM wish_reactions r WHERE r.wish_id = w.id))`
+
+// myReactionColumn selects the viewer's reaction to w. It takes the viewer's
+// id once.
+const myReactionColumn = `(SELECT reaction FROM wish_reactions WHERE wish_id = w.id AND user_id = ?) AS my_reaction`
+
+// reactionCountsColumn selects a JSON object of reaction counts for w.
+const reactionCountsColumn = `(SELECT json_group_object(reaction, n) FROM (
+					SELECT reaction, COUNT(*) AS n FROM wish_reactions WHERE wish_id = w.id GROUP BY reaction
+				)) AS reactions`
+
+func unmarshalReactionCounts(src interface{}) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	var source []byte
+	switch s := src.(type) {
+	case []byte:
+		source = s
+	case string:
+		source = []byte(s)
+	case nil:
+		return counts, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", s)
+	}
+
+	if err := json.Unmarshal(source, &counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// ToggleReaction sets the user's reaction to a wish, replacing any other one.
+// Reacting the same way again takes the reaction back. It returns the
+// reaction left in place, if any.
+func (s *Storage) ToggleReaction(ctx context.Context, uid, wishID, reaction string) (*string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(ctx, `SELECT reaction FROM wish_reactions WHERE user_id = ? AND wish_id = ?`, uid, wishID).Scan(&current)
+	if err != nil && !IsNoRowsError(err) {
+		return nil, err
+	}
+
+	var result *string
+	if current == reaction {
+		_, err = tx.ExecContext(ctx, `DELETE FROM wish_reactions WHERE user_id = ? AND wish_id = ?`, uid, wishID)
+	} else {
+		query := `
+			INSERT INTO wish_reactions (user_id, wish_id, reaction) VALUES (?, ?, ?)
+			ON CONFLICT (user_id, wish_id) DO UPDATE SET reaction = excluded.reaction, created_at = CURRENT_TIMESTAMP`
+		_, err = tx.ExecContext(ctx, query, uid, wishID, reaction)
+		result = &reaction
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, tx.Commit()
+}
+
+func (s *Storage) RemoveReaction(ctx context.Context, uid, wishID string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM wish_reactions WHERE user_id = ? AND wish_id = ?`, uid, wishID)
+	return err
+}
+
+// GetReactionCounts counts the reactions on a wish by type.
+func (s *Storage) GetReactionCounts(ctx context.Context, wishID string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT reaction, COUNT(*) FROM wish_reactions WHERE wish_id = ? GROUP BY reaction`, wishID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var reaction string
+		var n int
+		if err := rows.Scan(&reaction, &n); err != nil {
+			return nil, err
+		}
+		counts[reaction] = n
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ReferralSourceBot     = "bot"
+	ReferralSourceMiniApp = "mini_app"
+	ReferralSourceWeb     = "web"
+)
+
+type Referral struct {
+	ReferrerID string    `db:"referrer_id" json:"referrer_id"`
+	ReferredID string    `db:"referred_id" json:"referred_id"`
+	Source     string    `db:"source" json:"source"`
+	StartParam *string   `db:"start_param" json:"start_param"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// ReferredUser is an invited user together with when and how they joined.
+type ReferredUser struct {
+	User
+	Source   string    `json:"source"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+func (s *Storage) GetUserByReferralCode(code string) (User, error) {
+	query := `
+		SELECT 
+		    u.id,
+		    u.username, 
+		    u.language_code,
+		    u.chat_id, 
+		    u.created_at, 
+		    u.name,
+		    u.email,
+		    u.email_verified_at IS NOT NULL AS email_verified,
+		    u.referral_code, 
+		    u.referred_by,
+		    u.avatar_url,
+		    u.birthday,
+		    u.timezone,
+		    u.is_private,
+		    json_group_array(distinct json_object('id', c.id, 'name', c.name, 'image_url', c.image_url)) filter (where c.id is not null) as interests
+		FROM users u
+		LEFT JOIN user_interests ui ON u.id = ui.user_id
+		LEFT JOIN categories c ON ui.category_id = c.id
+		WHERE u.referral_code = ? AND u.deleted_at IS NULL
+		GROUP BY u.id`
+	return s.getUserBy(query, code)
+}
+
+func (s *Storage) CreateReferral(ctx context.Context, ref Referral) error {
+	query := `INSERT INTO referrals (referrer_id, referred_id, source, start_param) VALUES (?, ?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, query, ref.ReferrerID, ref.ReferredID, ref.Source, ref.StartParam)
+	if err != nil && IsUniqueViolationError(err) {
+		return ErrAlreadyExists
+	}
+
+	return err
+}
+
+func (s *Storage) ListReferrals(ctx context.Context, uid string) ([]ReferredUser, error) {
+	query := `
+		SELECT u.id,
+		       u.username,
+		       u.name,
+		       u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) as followers,
+		       r.source,
+		       r.created_at
+		FROM referrals r
+		JOIN users u ON u.id = r.referred_id
+		WHERE r.referrer_id = ?
+		ORDER BY r.created_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]ReferredUser, 0)
+	for rows.Next() {
+		var ru ReferredUser
+		if err := rows.Scan(
+			&ru.ID,
+			&ru.Username,
+			&ru.Name,
+			&ru.AvatarURL,
+			&ru.Followers,
+			&ru.Source,
+			&ru.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		users = append(users, ru)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	ReminderKindBirthday = "birthday"
+	ReminderKindEvent    = "event"
+)
+
+// followerHiddenCondition leaves out friends the follower blocked, was blocked
+// by or muted, like hiddenUsersSubquery keyed on each row's follower.
+const followerHiddenCondition = `u.id NOT IN (
+		SELECT blocked_id FROM user_blocks WHERE user_id = f.follower_id
+		UNION SELECT user_id FROM user_blocks WHERE blocked_id = f.follower_id
+		UNION SELECT muted_id FROM user_mutes WHERE user_id = f.follower_id)`
+
+// UpcomingEvent is a birthday or wishlist event of a followed user, addressed
+// to one of their followers.
+type UpcomingEvent struct {
+	FollowerID       string
+	FollowerChatID   int64
+	FollowerLanguage string
+	Friend           User
+	Kind             string
+	EventID          string
+	EventName        *string
+	// Date is the stored YYYY-MM-DD date; for birthdays the year is the birth year.
+	Date string
+}
+
+// ListUpcomingEvents returns birthdays falling on any of the given "MM-DD" days
+// and public wishlist events dated between from and to (YYYY-MM-DD, inclusive),
+// one row per follower who has not turned reminders off or hidden the friend.
+func (s *Storage) ListUpcomingEvents(ctx context.Context, monthDays []string, from, to string) ([]UpcomingEvent, error) {
+	if len(monthDays) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT f.follower_id,
+		       fu.chat_id,
+		       fu.language_code,
+		       u.id,
+		       u.username,
+		       u.name,
+		       'birthday',
+		       u.id,
+		       NULL,
+		       u.birthday
+		FROM followers f
+		JOIN users u ON u.id = f.following_id
+		JOIN users fu ON fu.id = f.follower_id
+		LEFT JOIN notification_preferences np ON np.user_id = f.follower_id AND np.type = ?
+		WHERE u.birthday IS NOT NULL
+		  AND substr(u.birthday, 6, 5) IN (?` + strings.Repeat(", ?", len(monthDays)-1) + `)
+		  AND u.deleted_at IS NULL
+		  AND u.deletion_requested_at IS NULL
+		  AND ` + followerHiddenCondition + `
+		  AND IFNULL(np.telegram, 1) = 1
+		UNION ALL
+		SELECT f.follower_id,
+		       fu.chat_id,
+		       fu.language_code,
+		       u.id,
+		       u.username,
+		       u.name,
+		       'event',
+		       wl.id,
+		       wl.name,
+		       wl.event_date
+		FROM followers f
+		JOIN wishlists wl ON wl.user_id = f.following_id
+		JOIN users u ON u.id = f.following_id
+		JOIN users fu ON fu.id = f.follower_id
+		LEFT JOIN notification_preferences np ON np.user_id = f.follower_id AND np.type = ?
+		WHERE wl.event_date BETWEEN ? AND ?
+		  AND wl.is_public = 1
+		  AND wl.deleted_at IS NULL
+		  AND u.deleted_at IS NULL
+		  AND u.deletion_requested_at IS NULL
+		  AND ` + followerHiddenCondition + `
+		  AND IFNULL(np.telegram, 1) = 1`
+
+	args := []interface{}{NotificationTypeReminder}
+	for _, md := range monthDays {
+		args = append(args, md)
+	}
+	args = append(args, NotificationTypeReminder, from, to)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]UpcomingEvent, 0)
+	for rows.Next() {
+		var (
+			ev   UpcomingEvent
+			lang *string
+		)
+		if err := rows.Scan(
+			&ev.FollowerID,
+			&ev.FollowerChatID,
+			&lang,
+			&ev.Friend.ID,
+			&ev.Friend.Username,
+			&ev.Friend.Name,
+			&ev.Kind,
+			&ev.EventID,
+			&ev.EventName,
+			&ev.Date,
+		); err != nil {
+			return nil, err
+		}
+		if lang != nil {
+			ev.FollowerLanguage = *lang
+		}
+		events = append(events, ev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkReminderSent records that the user was reminded about this occurrence of
+// an event. It reports false when the reminder had already been recorded, which
+// keeps the scheduler from sending it twice.
+func (s *Storage) MarkReminderSent(ctx context.Context, uid, kind, eventID, occursOn string) (bool, error) {
+	query := `INSERT OR IGNORE INTO sent_reminders (user_id, kind, event_id, occurs_on) VALUES (?, ?, ?, ?)`
+
+	res, err := s.db.ExecContext(ctx, query, uid, kind, eventID, occursOn)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// UnmarkReminderSent forgets a recorded reminder so the scheduler sends it
+// again, for when delivery failed.
+func (s *Storage) UnmarkReminderSent(ctx context.Context, uid, kind, eventID, occursOn string) error {
+	query := `DELETE FROM sent_reminders WHERE user_id = ? AND kind = ? AND event_id = ? AND occurs_on = ?`
+
+	_, err := s.db.ExecContext(ctx, query, uid, kind, eventID, occursOn)
+	return err
+}
+
+// ListGiftIdeas returns the user's published wishes nobody has reserved yet,
+// favorites first.
+func (s *Storage) ListGiftIdeas(ctx context.Context, viewerID, uid string, limit int) ([]Wish, error) {
+	query := s.baseWishesQuery() + `
+			WHERE w.user_id = ?
+			  AND w.published_at IS NOT NULL
+			  AND w.reserved_by IS NULL
+			  AND w.is_fulfilled = 0
+			  AND w.deleted_at IS NULL
+			  AND ` + visibleWishCondition + `
+			GROUP BY w.id
+			ORDER BY w.is_favorite DESC, w.created_at DESC
+			LIMIT ?`
+	return s.fetchWishes(ctx, query, baseWishesArgs(viewerID, uid, viewerID, viewerID, limit)...)
+}
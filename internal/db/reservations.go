@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+)
+
+func (s *Storage) ReserveWish(ctx context.Context, uid, wishID string) error {
+	query := `
+		UPDATE wishes
+		SET reserved_by = ?, reserved_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id != ? AND reserved_by IS NULL AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, uid, wishID, uid)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *Storage) UnreserveWish(ctx context.Context, uid, wishID string) error {
+	query := `
+		UPDATE wishes
+		SET reserved_by = NULL, reserved_at = NULL
+		WHERE id = ? AND reserved_by = ?`
+
+	res, err := s.db.ExecContext(ctx, query, wishID, uid)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListReservedWishes returns wishes the user has promised to gift, most recently reserved first.
+func (s *Storage) ListReservedWishes(ctx context.Context, uid string) ([]Wish, error) {
+	query := s.baseWishesQuery() + `
+			WHERE w.reserved_by = ? AND w.deleted_at IS NULL AND ` + visibleWishCondition + `
+			GROUP BY w.id
+			ORDER BY w.reserved_at DESC
+			LIMIT 100`
+	return s.fetchWishes(ctx, query, baseWishesArgs(uid, uid, uid, uid)...)
+}
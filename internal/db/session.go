@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrTokenReused is returned when a refresh token that was already rotated is
+// presented again. The session it belonged to is revoked, since either the
+// client or an attacker holds a stolen copy.
+var ErrTokenReused = errors.New("refresh token reused")
+
+// Session is a signed-in device. Only the hash of its refresh token is stored.
+type Session struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     string     `db:"user_id" json:"user_id"`
+	UserAgent  *string    `db:"user_agent" json:"user_agent"`
+	IP         *string    `db:"ip" json:"ip"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+}
+
+func (s *Storage) CreateSession(ctx context.Context, session Session, tokenHash string) error {
+	query := `
+		INSERT INTO sessions (id, user_id, refresh_token_hash, user_agent, ip, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		tokenHash,
+		session.UserAgent,
+		session.IP,
+		session.ExpiresAt.UTC().Format(sqliteTimeLayout),
+	)
+
+	return err
+}
+
+// RotateSession swaps the refresh token of an active session for a new one and
+// extends its expiry. Presenting the token that was replaced last revokes the
+// session and returns ErrTokenReused.
+func (s *Storage) RotateSession(ctx context.Context, tokenHash, newTokenHash string, userAgent, ip *string, expiresAt time.Time) (Session, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(sqliteTimeLayout)
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM sessions
+		WHERE refresh_token_hash = ? AND revoked_at IS NULL AND datetime(expires_at) > datetime(?)`,
+		tokenHash, now,
+	).Scan(&id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE sessions SET revoked_at = ?
+			WHERE previous_token_hash = ? AND revoked_at IS NULL`, now, tokenHash)
+		if err != nil {
+			return Session{}, err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return Session{}, err
+		}
+
+		if affected == 0 {
+			return Session{}, ErrNotFound
+		}
+
+		if err := tx.Commit(); err != nil {
+			return Session{}, err
+		}
+
+		return Session{}, ErrTokenReused
+	} else if err != nil {
+		return Session{}, err
+	}
+
+	query := `
+		UPDATE sessions
+		SET refresh_token_hash  = ?,
+			previous_token_hash = refresh_token_hash,
+			user_agent          = IFNULL(?, user_agent),
+			ip                  = IFNULL(?, ip),
+			last_used_at        = ?,
+			expires_at          = ?
+		WHERE id = ?`
+
+	if _, err := tx.ExecContext(ctx, query,
+		newTokenHash, userAgent, ip, now, expiresAt.UTC().Format(sqliteTimeLayout), id,
+	); err != nil {
+		return Session{}, err
+	}
+
+	var session Session
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions WHERE id = ?`, id,
+	).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Session{}, err
+	}
+
+	return session, nil
+}
+
+// IsSessionActive reports whether the session exists, has not expired and has
+// not been revoked.
+func (s *Storage) IsSessionActive(ctx context.Context, id string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE id = ? AND revoked_at IS NULL AND datetime(expires_at) > datetime(?)
+		)`
+
+	var active bool
+	err := s.db.QueryRowContext(ctx, query, id, time.Now().UTC().Format(sqliteTimeLayout)).Scan(&active)
+
+	return active, err
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+func (s *Storage) ListSessions(ctx context.Context, uid string) ([]Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND datetime(expires_at) > datetime(?)
+		ORDER BY last_used_at DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, uid, time.Now().UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+			&session.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of one of their sessions.
+func (s *Storage) RevokeSession(ctx context.Context, uid, id string) error {
+	query := `UPDATE sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, time.Now().UTC().Format(sqliteTimeLayout), id, uid)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RevokeSessionByToken revokes the session that currently owns the refresh token.
+func (s *Storage) RevokeSessionByToken(ctx context.Context, tokenHash string) error {
+	query := `UPDATE sessions SET revoked_at = ? WHERE refresh_token_hash = ? AND revoked_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, time.Now().UTC().Format(sqliteTimeLayout), tokenHash)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import "context"
+
+// shareableWishCondition matches wishes anyone with a link may see: published,
+// public, not deleted, not owned by a private account and not part of a
+// private wishlist.
+const shareableWishCondition = `
+	w.published_at IS NOT NULL
+	AND w.deleted_at IS NULL
+	AND ` + publicWishCondition + `
+	AND NOT EXISTS (
+		SELECT 1 FROM wishlist_items wli
+		JOIN wishlists wl ON wl.id = wli.wishlist_id
+		WHERE wli.wish_id = w.id AND wl.is_public = 0
+	)`
+
+// IsWishShareable reports whether the wish may be shown on a public share page.
+func (s *Storage) IsWishShareable(ctx context.Context, id string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM wishes w WHERE w.id = ? AND ` + shareableWishCondition + `)`
+
+	var ok bool
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&ok)
+
+	return ok, err
+}
+
+// ListShareableWishes returns the user's wishes that may be shown on their
+// public share page, newest first.
+func (s *Storage) ListShareableWishes(ctx context.Context, uid string, limit int) ([]Wish, error) {
+	query := s.baseWishesQuery() + `
+			WHERE w.user_id = ? AND ` + shareableWishCondition + `
+			GROUP BY w.id
+			ORDER BY w.published_at DESC
+			LIMIT ?`
+
+	return s.fetchWishes(ctx, query, baseWishesArgs("", uid, limit)...)
+}
+
+// ListShareableWishlistWishes returns the wishes of a wishlist that may be
+// shown on a public share page, newest first.
+func (s *Storage) ListShareableWishlistWishes(ctx context.Context, listID string, limit int) ([]Wish, error) {
+	query := s.baseWishesQuery() + `
+			WHERE w.id IN (SELECT wish_id FROM wishlist_items WHERE wishlist_id = ?) AND ` + shareableWishCondition + `
+			GROUP BY w.id
+			ORDER BY w.published_at DESC
+			LIMIT ?`
+
+	return s.fetchWishes(ctx, query, baseWishesArgs("", listID, limit)...)
+}
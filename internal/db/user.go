@@ -6,24 +6,30 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/mattn/go-sqlite3"
+	"strings"
 	"time"
 )
 
 // User represents a user in the system
 type User struct {
-	ID           string         `db:"id" json:"id"`
-	Username     string         `db:"username" json:"username"`
-	LanguageCode string         `db:"language_code" json:"language_code"`
-	ChatID       int64          `db:"chat_id" json:"chat_id"`
-	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
-	Name         *string        `db:"name" json:"name"`
-	Email        *string        `db:"email" json:"email"`
-	ReferralCode string         `db:"referral_code" json:"referral_code"`
-	ReferredBy   *string        `db:"referred_by" json:"referred_by"`
-	AvatarURL    *string        `db:"avatar_url" json:"avatar_url"`
-	Interests    InterestsArray `db:"interests" json:"interests"`
-	Followers    int            `db:"followers" json:"followers"`
-	IsFollowing  bool           `db:"is_following" json:"is_following"`
+	ID            string         `db:"id" json:"id"`
+	Username      string         `db:"username" json:"username"`
+	LanguageCode  string         `db:"language_code" json:"language_code"`
+	ChatID        int64          `db:"chat_id" json:"chat_id"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+	Name          *string        `db:"name" json:"name"`
+	Email         *string        `db:"email" json:"email"`
+	EmailVerified bool           `db:"email_verified" json:"email_verified"`
+	ReferralCode  string         `db:"referral_code" json:"referral_code"`
+	ReferredBy    *string        `db:"referred_by" json:"referred_by"`
+	AvatarURL     *string        `db:"avatar_url" json:"avatar_url"`
+	Birthday      *string        `db:"birthday" json:"birthday"`
+	Timezone      *string        `db:"timezone" json:"timezone"`
+	IsPrivate     bool           `db:"is_private" json:"is_private"`
+	Interests     InterestsArray `db:"interests" json:"interests"`
+	Followers     int            `db:"followers" json:"followers"`
+	Following     int            `db:"following" json:"following"`
+	IsFollowing   bool           `db:"is_following" json:"is_following"`
 }
 
 type InterestsArray []Interest
@@ -130,9 +136,13 @@ func (s *Storage) getUserBy(query string, arg interface{}) (User, error) {
 		&user.CreatedAt,
 		&user.Name,
 		&user.Email,
+		&user.EmailVerified,
 		&user.ReferralCode,
 		&user.ReferredBy,
 		&user.AvatarURL,
+		&user.Birthday,
+		&user.Timezone,
+		&user.IsPrivate,
 		&user.Interests,
 	); err != nil && IsNoRowsError(err) {
 		return User{}, ErrNotFound
@@ -153,9 +163,13 @@ func (s *Storage) GetUserByChatID(chatID int64) (User, error) {
 		    u.created_at, 
 		    u.name,
 		    u.email,
+		    u.email_verified_at IS NOT NULL AS email_verified,
 		    u.referral_code, 
 		    u.referred_by,
 		    u.avatar_url,
+		    u.birthday,
+		    u.timezone,
+		    u.is_private,
 		    json_group_array(distinct json_object('id', c.id, 'name', c.name, 'image_url', c.image_url)) filter (where c.id is not null) as interests
 		FROM users u
 		LEFT JOIN user_interests ui ON u.id = ui.user_id
@@ -176,30 +190,73 @@ func (s *Storage) GetUserByID(id string) (User, error) {
 		    u.created_at, 
 		    u.name,
 		    u.email,
+		    u.email_verified_at IS NOT NULL AS email_verified,
 		    u.referral_code, 
 		    u.referred_by,
 		    u.avatar_url,
+		    u.birthday,
+		    u.timezone,
+		    u.is_private,
 		    json_group_array(distinct json_object('id', c.id, 'name', c.name, 'image_url', c.image_url)) filter (where c.id is not null) as interests
 		FROM users u
 		LEFT JOIN user_interests ui ON u.id = ui.user_id
 		LEFT JOIN categories c ON ui.category_id = c.id
-		WHERE u.id = ?
+		WHERE u.id = ? AND u.deleted_at IS NULL
 		GROUP BY u.id`
 	return s.getUserBy(query, id)
 }
 
+func (s *Storage) GetUserByUsername(username string) (User, error) {
+	query := `
+		SELECT 
+		    u.id,
+		    u.username, 
+		    u.language_code,
+		    u.chat_id, 
+		    u.created_at, 
+		    u.name,
+		    u.email,
+		    u.email_verified_at IS NOT NULL AS email_verified,
+		    u.referral_code, 
+		    u.referred_by,
+		    u.avatar_url,
+		    u.birthday,
+		    u.timezone,
+		    u.is_private,
+		    json_group_array(distinct json_object('id', c.id, 'name', c.name, 'image_url', c.image_url)) filter (where c.id is not null) as interests
+		FROM users u
+		LEFT JOIN user_interests ui ON u.id = ui.user_id
+		LEFT JOIN categories c ON ui.category_id = c.id
+		WHERE u.username = ? COLLATE NOCASE AND u.deleted_at IS NULL
+		GROUP BY u.id`
+	return s.getUserBy(query, username)
+}
+
+// UpdateUser saves the user's settings and interests. A changed username is
+// kept in the history so old links keep working; ErrAlreadyExists means the
+// new username belongs to someone else.
 func (s *Storage) UpdateUser(ctx context.Context, user User, interests []string) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
+	var previousUsername string
+	if err := tx.QueryRowContext(ctx, `SELECT username FROM users WHERE id = ?`, user.ID).Scan(&previousUsername); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	query := `
 		UPDATE users
 		SET username = ?,
 			name = ?,
 			language_code = ?,
-			email = ?
+			email = ?,
+			email_verified_at = CASE WHEN email = ? COLLATE NOCASE THEN email_verified_at END,
+			birthday = ?,
+			timezone = ?,
+			is_private = ?
 		WHERE id = ?`
 
 	_, err = tx.ExecContext(
@@ -209,14 +266,28 @@ func (s *Storage) UpdateUser(ctx context.Context, user User, interests []string)
 		user.Name,
 		user.LanguageCode,
 		user.Email,
+		user.Email,
+		user.Birthday,
+		user.Timezone,
+		user.IsPrivate,
 		user.ID,
 	)
 
-	if err != nil {
+	if err != nil && IsUniqueViolationError(err) {
+		tx.Rollback()
+		return ErrAlreadyExists
+	} else if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	if !strings.EqualFold(previousUsername, user.Username) {
+		if err := recordUsernameChange(ctx, tx, user.ID, previousUsername, user.Username); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	// Delete all existing interests
 	_, err = tx.ExecContext(ctx, "DELETE FROM user_interests WHERE user_id = ?", user.ID)
 	if err != nil {
@@ -248,17 +319,22 @@ func (s *Storage) ListUsers(ctx context.Context, uid string) ([]User, error) {
 		    u.created_at, 
 		    u.name,
 		    u.email,
+		    u.email_verified_at IS NOT NULL AS email_verified,
 		    u.referral_code, 
 		    u.referred_by,
 		    u.avatar_url,
+		    u.birthday,
+		    u.timezone,
+		    u.is_private,
 		    json_group_array(distinct json_object('id', c.id, 'name', c.name, 'image_url', c.image_url)) filter (where c.id is not null) as interests,
 		    (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) as followers,
+		    (SELECT COUNT(*) FROM followers f WHERE f.follower_id = u.id) as following,
 		    EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = u.id) as is_following
 		FROM users u
 		LEFT JOIN user_interests ui ON u.id = ui.user_id
 		LEFT JOIN categories c ON ui.category_id = c.id
-		WHERE u.id != ?
-		GROUP BY u.id, u.username, u.language_code, u.chat_id, u.created_at, u.name, u.email, u.referral_code, u.referred_by, u.avatar_url
+		WHERE u.id != ? AND u.deleted_at IS NULL AND u.deletion_requested_at IS NULL
+		GROUP BY u.id, u.username, u.language_code, u.chat_id, u.created_at, u.name, u.email, u.referral_code, u.referred_by, u.avatar_url, u.birthday, u.timezone, u.is_private
 		ORDER BY u.created_at DESC
 		LIMIT 100`
 
@@ -279,11 +355,16 @@ func (s *Storage) ListUsers(ctx context.Context, uid string) ([]User, error) {
 			&user.CreatedAt,
 			&user.Name,
 			&user.Email,
+			&user.EmailVerified,
 			&user.ReferralCode,
 			&user.ReferredBy,
 			&user.AvatarURL,
+			&user.Birthday,
+			&user.Timezone,
+			&user.IsPrivate,
 			&user.Interests,
 			&user.Followers,
+			&user.Following,
 			&isFollowing,
 		); err != nil {
 			return nil, err
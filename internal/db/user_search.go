@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+// SearchUsers matches prefixes of usernames and names, best matches first,
+// leaving out the viewer and users hidden from them.
+func (s *Storage) SearchUsers(ctx context.Context, viewerID, search string, limit, offset int) ([]User, error) {
+	match := userSearchQuery(search)
+	if match == "" {
+		return []User{}, nil
+	}
+
+	query := `
+		SELECT u.id, u.username, u.name, u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers,
+		       EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = u.id) AS is_following
+		FROM users_fts fts
+		JOIN users u ON u.id = fts.user_id
+		WHERE users_fts MATCH ?
+		  AND u.id != ?
+		  AND u.deleted_at IS NULL
+		  AND u.deletion_requested_at IS NULL
+		  AND u.id NOT IN (` + hiddenUsersSubquery + `)
+		ORDER BY fts.rank, followers DESC
+		LIMIT ? OFFSET ?`
+
+	args := append([]interface{}{viewerID, match, viewerID}, hiddenUsersArgs(viewerID)...)
+
+	return s.listUsersWithFollowing(ctx, query, append(args, limit, offset)...)
+}
+
+// userSearchQuery turns each word of the search into a quoted prefix term, so
+// punctuation in usernames never reaches the FTS5 query parser.
+func userSearchQuery(search string) string {
+	var terms []string
+	for _, word := range strings.Fields(search) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// SuggestUsers ranks people uid does not follow yet by how many of uid's
+// followings follow them, how many interests they share with uid and how many
+// wishes both bookmarked. Users with none of these in common are left out.
+func (s *Storage) SuggestUsers(ctx context.Context, uid string, limit int) ([]User, error) {
+	query := `
+		WITH mutual AS (
+			SELECT f2.following_id AS user_id, COUNT(*) AS score
+			FROM followers f1
+			JOIN followers f2 ON f2.follower_id = f1.following_id
+			WHERE f1.follower_id = ?
+			GROUP BY f2.following_id
+		),
+		interests AS (
+			SELECT other.user_id, COUNT(*) AS score
+			FROM user_interests mine
+			JOIN user_interests other ON other.category_id = mine.category_id
+			WHERE mine.user_id = ?
+			GROUP BY other.user_id
+		),
+		bookmarks AS (
+			SELECT other.user_id, COUNT(*) AS score
+			FROM user_bookmarks mine
+			JOIN user_bookmarks other ON other.wish_id = mine.wish_id
+			WHERE mine.user_id = ?
+			GROUP BY other.user_id
+		),
+		candidates AS (
+			SELECT user_id, SUM(score) AS score
+			FROM (SELECT user_id, score * 3 AS score FROM mutual
+			      UNION ALL SELECT user_id, score * 2 FROM interests
+			      UNION ALL SELECT user_id, score FROM bookmarks)
+			GROUP BY user_id
+		)
+		SELECT u.id, u.username, u.name, u.avatar_url,
+		       (SELECT COUNT(*) FROM followers f WHERE f.following_id = u.id) AS followers,
+		       0 AS is_following
+		FROM candidates c
+		JOIN users u ON u.id = c.user_id
+		WHERE u.id != ?
+		  AND u.deleted_at IS NULL
+		  AND u.deletion_requested_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.following_id = u.id)
+		  AND NOT EXISTS (SELECT 1 FROM follow_requests r WHERE r.user_id = ? AND r.target_id = u.id)
+		  AND u.id NOT IN (` + hiddenUsersSubquery + `)
+		ORDER BY c.score DESC, followers DESC
+		LIMIT ?`
+
+	args := append([]interface{}{uid, uid, uid, uid, uid, uid}, hiddenUsersArgs(uid)...)
+
+	return s.listUsersWithFollowing(ctx, query, append(args, limit)...)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// GetUserByPreviousUsername returns the user who went by username before
+// changing it. Usernames are matched ignoring case.
+func (s *Storage) GetUserByPreviousUsername(username string) (User, error) {
+	var uid string
+	err := s.db.QueryRow(`SELECT user_id FROM username_history WHERE username = ?`, username).Scan(&uid)
+	if err != nil && IsNoRowsError(err) {
+		return User{}, ErrNotFound
+	} else if err != nil {
+		return User{}, err
+	}
+
+	return s.GetUserByID(uid)
+}
+
+// IsUsernameTaken tells whether someone other than uid uses username now.
+func (s *Storage) IsUsernameTaken(ctx context.Context, username, uid string) (bool, error) {
+	var taken bool
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE username = ? COLLATE NOCASE AND id != ?)`
+	err := s.db.QueryRowContext(ctx, query, username, uid).Scan(&taken)
+
+	return taken, err
+}
+
+// recordUsernameChange remembers the previous username for redirects. The new
+// one leaves the history, since a current username always wins a lookup.
+func recordUsernameChange(ctx context.Context, tx *sql.Tx, uid, previous, current string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM username_history WHERE username = ?`, current); err != nil {
+		return err
+	}
+
+	query := `INSERT OR REPLACE INTO username_history (username, user_id) VALUES (?, ?)`
+	_, err := tx.ExecContext(ctx, query, previous, uid)
+
+	return err
+}
@@ -0,0 +1,60 @@
+package db
+
+// Wish visibility levels. Link-only wishes are reachable solely through their
+// share token and never appear in listings.
+const (
+	WishVisibilityPublic    = "public"
+	WishVisibilityFollowers = "followers"
+	WishVisibilityPrivate   = "private"
+	WishVisibilityLink      = "link"
+)
+
+// WishVisibilities lists every accepted visibility level.
+var WishVisibilities = []string{
+	WishVisibilityPublic,
+	WishVisibilityFollowers,
+	WishVisibilityPrivate,
+	WishVisibilityLink,
+}
+
+func IsWishVisibility(v string) bool {
+	for _, known := range WishVisibilities {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleWishCondition limits wishes to those the viewer may see: their own,
+// public wishes of public accounts, and public or followers-only wishes of
+// accounts they follow. Accounts waiting to be deleted show their wishes to
+// no one else. It takes the viewer's id twice; guests pass "".
+const visibleWishCondition = `
+	(w.user_id = ?
+	 OR (NOT EXISTS (SELECT 1 FROM users du WHERE du.id = w.user_id AND du.deletion_requested_at IS NOT NULL)
+	     AND ((w.visibility = 'public'
+	           AND NOT EXISTS (SELECT 1 FROM users pu WHERE pu.id = w.user_id AND pu.is_private = 1))
+	          OR (w.visibility IN ('public', 'followers')
+	              AND EXISTS (SELECT 1 FROM followers pf WHERE pf.follower_id = ? AND pf.following_id = w.user_id)))))`
+
+// listedWishCondition keeps wishes without a photo or a category, such as
+// ones just added from the bot, out of feeds and other people's listings.
+// Owners still see them in their own list so they can complete them.
+const listedWishCondition = `
+	EXISTS (SELECT 1 FROM wish_images li WHERE li.wish_id = w.id)
+	AND EXISTS (SELECT 1 FROM wish_categories lc JOIN categories lcc ON lcc.id = lc.category_id WHERE lc.wish_id = w.id)`
+
+// publicWishCondition matches wishes anyone may see, for listings that are
+// not tied to a viewer.
+const publicWishCondition = `
+	w.visibility = 'public'
+	AND NOT EXISTS (
+		SELECT 1 FROM users pu
+		WHERE pu.id = w.user_id AND (pu.is_private = 1 OR pu.deletion_requested_at IS NOT NULL))`
+
+// followerWishCondition matches wishes shown to followers of their owner,
+// unless the owner is waiting for their account to be deleted.
+const followerWishCondition = `
+	w.visibility IN ('public', 'followers')
+	AND NOT EXISTS (SELECT 1 FROM users du WHERE du.id = w.user_id AND du.deletion_requested_at IS NOT NULL)`
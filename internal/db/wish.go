@@ -29,6 +29,20 @@ type Wish struct {
 	Categories   []Category  `json:"categories"`
 	IsBookmarked bool        `db:"is_bookmarked" json:"is_bookmarked"`
 	CopyID       *string     `db:"copy_id" json:"copy_id,omitempty"`
+	Visibility   string      `db:"visibility" json:"visibility"`
+	// CommentCount counts public comments the viewer can see; questions are
+	// left out.
+	CommentCount int `db:"comment_count" json:"comment_count"`
+	// Reactions counts reactions by type; MyReaction is the viewer's own.
+	Reactions  map[string]int `json:"reactions"`
+	MyReaction *string        `db:"my_reaction" json:"my_reaction,omitempty"`
+	Priority   string         `db:"priority" json:"priority"`
+	// Quantity is how many the user wants; Received how many they got.
+	Quantity   int             `db:"quantity" json:"quantity"`
+	Received   int             `db:"received" json:"received"`
+	Attributes []WishAttribute `json:"attributes"`
+	// ShareToken opens a link-only wish; it is only loaded for the owner.
+	ShareToken *string `db:"share_token" json:"share_token,omitempty"`
 }
 
 func UnmarshalJSONToSlice[T any](src interface{}) ([]T, error) {
@@ -63,7 +77,18 @@ type WishImage struct {
 	Height    int       `db:"height" json:"height"`
 }
 
+// GetWishByID returns the wish if viewerID may see it, and ErrNotFound
+// otherwise.
 func (s *Storage) GetWishByID(ctx context.Context, viewerID, id string) (Wish, error) {
+	return s.getWish(ctx, viewerID, `w.id = ? AND `+visibleWishCondition, id, viewerID, viewerID)
+}
+
+// GetWishByShareToken returns a link-only wish by its share token.
+func (s *Storage) GetWishByShareToken(ctx context.Context, viewerID, token string) (Wish, error) {
+	return s.getWish(ctx, viewerID, `w.share_token = ? AND w.visibility = 'link' AND w.deleted_at IS NULL`, token)
+}
+
+func (s *Storage) getWish(ctx context.Context, viewerID, condition string, args ...interface{}) (Wish, error) {
 	query := `SELECT 
     		w.id, 
     		w.user_id,
@@ -80,14 +105,25 @@ func (s *Storage) GetWishByID(ctx context.Context, viewerID, id string) (Wish, e
     		w.created_at, 
     		w.updated_at,
     		w.source_id,
+    		w.visibility,
+    		w.priority,
+    		w.quantity,
+    		w.received,
+    		CASE WHEN w.user_id = ? THEN w.share_token END AS share_token,
 			EXISTS (SELECT 1 FROM user_bookmarks ub WHERE ub.user_id = ? AND ub.wish_id = w.id) AS is_bookmarked,
-			(SELECT id FROM wishes WHERE user_id = ? AND source_id = w.id LIMIT 1) AS copy_id
+			(SELECT id FROM wishes WHERE user_id = ? AND source_id = w.id LIMIT 1) AS copy_id,
+			` + myReactionColumn + `,
+			` + commentCountColumn + `,
+			` + reactionCountsColumn + `,
+			` + wishAttributesColumn + `
 		FROM wishes w
-		WHERE w.id = ?`
+		WHERE ` + condition
 
 	var item Wish
+	var reactionsData, attributesData interface{}
 
-	if err := s.db.QueryRowContext(ctx, query, viewerID, viewerID, id).Scan(
+	args = append(append([]interface{}{viewerID, viewerID}, baseWishesArgs(viewerID)...), args...)
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&item.ID,
 		&item.UserID,
 		&item.Name,
@@ -103,16 +139,35 @@ func (s *Storage) GetWishByID(ctx context.Context, viewerID, id string) (Wish, e
 		&item.CreatedAt,
 		&item.UpdatedAt,
 		&item.SourceID,
+		&item.Visibility,
+		&item.Priority,
+		&item.Quantity,
+		&item.Received,
+		&item.ShareToken,
 		&item.IsBookmarked,
 		&item.CopyID,
+		&item.MyReaction,
+		&item.CommentCount,
+		&reactionsData,
+		&attributesData,
 	); err != nil && IsNoRowsError(err) {
 		return Wish{}, ErrNotFound
 	} else if err != nil {
 		return Wish{}, err
 	}
 
+	reactions, err := unmarshalReactionCounts(reactionsData)
+	if err != nil {
+		return Wish{}, err
+	}
+	item.Reactions = reactions
+
+	if item.Attributes, err = UnmarshalJSONToSlice[WishAttribute](attributesData); err != nil {
+		return Wish{}, err
+	}
+
 	// fetch images
-	imagesData, err := s.db.QueryContext(ctx, `SELECT id, wish_id, url, position, width, height FROM wish_images WHERE wish_id = ?`, id)
+	imagesData, err := s.db.QueryContext(ctx, `SELECT id, wish_id, url, position, width, height FROM wish_images WHERE wish_id = ?`, item.ID)
 	if err != nil {
 		return Wish{}, err
 	}
@@ -137,7 +192,7 @@ func (s *Storage) GetWishByID(ctx context.Context, viewerID, id string) (Wish, e
 		return Wish{}, err
 	}
 
-	categoriesData, err := s.db.QueryContext(ctx, `SELECT c.id, c.name, c.image_url FROM wish_categories wc JOIN categories c ON wc.category_id = c.id WHERE wc.wish_id = ?`, id)
+	categoriesData, err := s.db.QueryContext(ctx, `SELECT c.id, c.name, c.image_url FROM wish_categories wc JOIN categories c ON wc.category_id = c.id WHERE wc.wish_id = ?`, item.ID)
 	if err != nil {
 		return Wish{}, err
 	}
@@ -169,8 +224,14 @@ func (s *Storage) GetWishByID(ctx context.Context, viewerID, id string) (Wish, e
 func (s *Storage) CreateWish(ctx context.Context, item Wish, categories []string) error {
 	query := `INSERT INTO wishes (
          id, user_id, name, url, price, currency, notes, is_fulfilled, 
-    	 published_at, source_id, created_at, updated_at
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+    	 published_at, source_id, created_at, updated_at, visibility, share_token,
+    	 priority, quantity, received
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	if item.Visibility == "" {
+		item.Visibility = WishVisibilityPublic
+	}
+	setWishDetailDefaults(&item)
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -190,6 +251,11 @@ func (s *Storage) CreateWish(ctx context.Context, item Wish, categories []string
 		item.SourceID,
 		item.CreatedAt,
 		item.UpdatedAt,
+		item.Visibility,
+		item.ShareToken,
+		item.Priority,
+		item.Quantity,
+		item.Received,
 	)
 
 	if err != nil && IsUniqueViolationError(err) {
@@ -208,6 +274,11 @@ func (s *Storage) CreateWish(ctx context.Context, item Wish, categories []string
 		}
 	}
 
+	if err := insertWishAttributes(ctx, tx, item.ID, item.Attributes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return err
 	}
@@ -227,17 +298,27 @@ func (s *Storage) UpdateWish(ctx context.Context, item Wish, categories []string
                   url = ?,
                   price = ?, 
                   notes = ?, 
-                  is_fulfilled = ?, 
+                  visibility = COALESCE(NULLIF(?, ''), visibility),
+                  share_token = ?,
+                  priority = ?,
+                  quantity = ?,
+                  is_fulfilled = received >= ?,
                   updated_at = CURRENT_TIMESTAMP,
-                  published_at = CURRENT_TIMESTAMP 
+                  published_at = COALESCE(published_at, CURRENT_TIMESTAMP)
               WHERE id = ? AND user_id = ?`
 
+	setWishDetailDefaults(&item)
+
 	_, err = tx.ExecContext(ctx, query,
 		item.Name,
 		item.URL,
 		item.Price,
 		item.Notes,
-		item.IsFulfilled,
+		item.Visibility,
+		item.ShareToken,
+		item.Priority,
+		item.Quantity,
+		item.Quantity,
 		item.ID,
 		item.UserID,
 	)
@@ -258,6 +339,14 @@ func (s *Storage) UpdateWish(ctx context.Context, item Wish, categories []string
 		}
 	}
 
+	if _, err := tx.ExecContext(ctx, `DELETE FROM wish_attributes WHERE wish_id = ?`, item.ID); err != nil {
+		return err
+	}
+
+	if err := insertWishAttributes(ctx, tx, item.ID, item.Attributes); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return err
 	}
@@ -284,10 +373,13 @@ func escapeFTS5Query(query string) string {
 }
 
 func (s *Storage) GetPublicWishesFeed(ctx context.Context, viewerID *string, searchQuery string) ([]Wish, error) {
-	var baseQuery string
-	var args []interface{}
+	viewer := ""
+	if viewerID != nil {
+		viewer = *viewerID
+	}
 
-	args = append(args, viewerID)
+	var baseQuery string
+	args := baseWishesArgs(viewer)
 
 	if searchQuery != "" {
 		// Escape special characters in the search query
@@ -304,18 +396,62 @@ func (s *Storage) GetPublicWishesFeed(ctx context.Context, viewerID *string, sea
 	}
 
 	if viewerID != nil {
-		baseQuery += ` AND w.user_id != ?`
+		baseQuery += ` AND w.user_id != ? AND w.user_id NOT IN (` + hiddenUsersSubquery + `)`
 		args = append(args, viewerID)
+		args = append(args, hiddenUsersArgs(viewer)...)
 	}
 
+	baseQuery += ` AND w.is_fulfilled = 0 AND ` + listedWishCondition + ` AND ` + visibleWishCondition
+	args = append(args, viewer, viewer)
+
 	baseQuery += `
 			GROUP BY w.id
-			ORDER BY w.created_at DESC
+			ORDER BY ` + feedRankExpr + ` DESC, w.created_at DESC
 			LIMIT 100`
 
 	return s.fetchWishes(ctx, baseQuery, args...)
 }
 
+// SearchUserWishes matches the user's own wishes and, when includePublic is set,
+// published originals of other users. The user's own wishes are returned first.
+// Only wishes anyone may open are matched, since the results are shared into
+// chats whose members cannot see followers-only, private or link-only wishes.
+func (s *Storage) SearchUserWishes(ctx context.Context, uid, searchQuery string, includePublic bool, limit, offset int) ([]Wish, error) {
+	query := s.baseWishesQuery()
+	args := baseWishesArgs(uid)
+
+	if searchQuery != "" {
+		query += `
+			JOIN wishes_fts fts ON fts.wish_id = w.id
+			WHERE wishes_fts MATCH ? AND`
+		args = append(args, escapeFTS5Query(searchQuery)+"*")
+	} else {
+		query += ` WHERE`
+	}
+
+	query += ` w.deleted_at IS NULL AND ` + publicWishCondition + ` AND (w.user_id = ?`
+	args = append(args, uid)
+
+	if includePublic {
+		query += ` OR (w.published_at IS NOT NULL AND w.source_id IS NULL)`
+	}
+
+	query += `)
+			GROUP BY w.id
+			ORDER BY w.user_id = ? DESC, w.created_at DESC
+			LIMIT ? OFFSET ?`
+	args = append(args, uid, limit, offset)
+
+	return s.fetchWishes(ctx, query, args...)
+}
+
+// baseWishesArgs prepends the viewer's id for the columns of
+// baseWishesQuery to the arguments of its conditions.
+func baseWishesArgs(viewerID string, args ...interface{}) []interface{} {
+	columns := append([]interface{}{viewerID, viewerID}, hiddenUsersArgs(viewerID)...)
+	return append(columns, args...)
+}
+
 func (s *Storage) baseWishesQuery() string {
 	return `SELECT w.id,
 				   w.user_id,
@@ -332,6 +468,10 @@ func (s *Storage) baseWishesQuery() string {
 				   w.reserved_at,
 				   w.created_at,
 				   w.updated_at,
+				   w.visibility,
+				   w.priority,
+				   w.quantity,
+				   w.received,
 				   json_group_array(distinct json_object(
 						   'id', wi.id,
 						   'wish_id', wi.wish_id,
@@ -345,11 +485,15 @@ func (s *Storage) baseWishesQuery() string {
 						   'name', c.name,
 						   'image_url', c.image_url
 				   )) filter (where wc.category_id is not null) as categories,
-    			   (SELECT id FROM wishes WHERE user_id = ? AND source_id = w.id LIMIT 1) AS copy_id
+    			   (SELECT id FROM wishes WHERE user_id = ? AND source_id = w.id LIMIT 1) AS copy_id,
+				   ` + myReactionColumn + `,
+				   ` + commentCountColumn + `,
+				   ` + reactionCountsColumn + `,
+				   ` + wishAttributesColumn + `
 			FROM wishes w
-         JOIN wish_images wi ON w.id = wi.wish_id
-         JOIN wish_categories wc ON w.id = wc.wish_id
-         JOIN categories c ON wc.category_id = c.id`
+         LEFT JOIN wish_images wi ON w.id = wi.wish_id
+         LEFT JOIN wish_categories wc ON w.id = wc.wish_id
+         LEFT JOIN categories c ON wc.category_id = c.id`
 }
 
 func (s *Storage) fetchWishes(ctx context.Context, query string, args ...interface{}) ([]Wish, error) {
@@ -364,6 +508,8 @@ func (s *Storage) fetchWishes(ctx context.Context, query string, args ...interfa
 		var item Wish
 		var imagesData interface{}
 		var categoriesData interface{}
+		var reactionsData interface{}
+		var attributesData interface{}
 		if err := rows.Scan(
 			&item.ID,
 			&item.UserID,
@@ -380,13 +526,29 @@ func (s *Storage) fetchWishes(ctx context.Context, query string, args ...interfa
 			&item.ReservedAt,
 			&item.CreatedAt,
 			&item.UpdatedAt,
+			&item.Visibility,
+			&item.Priority,
+			&item.Quantity,
+			&item.Received,
 			&imagesData,
 			&categoriesData,
 			&item.CopyID,
+			&item.MyReaction,
+			&item.CommentCount,
+			&reactionsData,
+			&attributesData,
 		); err != nil {
 			return nil, err
 		}
 
+		if item.Reactions, err = unmarshalReactionCounts(reactionsData); err != nil {
+			return nil, err
+		}
+
+		if item.Attributes, err = UnmarshalJSONToSlice[WishAttribute](attributesData); err != nil {
+			return nil, err
+		}
+
 		images, err := UnmarshalJSONToSlice[WishImage](imagesData)
 		if err != nil {
 			return nil, err
@@ -407,13 +569,14 @@ func (s *Storage) fetchWishes(ctx context.Context, query string, args ...interfa
 	return items, nil
 }
 
-func (s *Storage) GetWishesByUserID(ctx context.Context, userID string) ([]Wish, error) {
+// GetWishesByUserID returns the user's wishes that viewerID may see.
+func (s *Storage) GetWishesByUserID(ctx context.Context, viewerID, userID string) ([]Wish, error) {
 	query := s.baseWishesQuery() + `
-			WHERE w.user_id = ?
+			WHERE w.user_id = ? AND (w.user_id = ? OR ` + listedWishCondition + `) AND ` + visibleWishCondition + `
         	GROUP BY w.id
 			ORDER BY w.created_at DESC
 			LIMIT 100`
-	return s.fetchWishes(ctx, query, userID, userID)
+	return s.fetchWishes(ctx, query, baseWishesArgs(viewerID, userID, viewerID, viewerID, viewerID)...)
 }
 
 func (s *Storage) CreateWishImage(ctx context.Context, image WishImage) (WishImage, error) {
@@ -528,7 +691,8 @@ func (s *Storage) GetWishAutocomplete(ctx context.Context, prefix string, limit
 				AND w.published_at IS NOT NULL
 				AND w.source_id IS NULL
 				AND w.deleted_at IS NULL
-				AND w.name IS NOT NULL) unique_matches
+				AND w.name IS NOT NULL
+				AND ` + publicWishCondition + `) unique_matches
 		GROUP BY suggestion
 		ORDER BY LENGTH(suggestion),
 				 suggestion
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+)
+
+const (
+	WishPriorityMustHave   = "must_have"
+	WishPriorityNiceToHave = "nice_to_have"
+)
+
+// WishPriorities lists every priority a wish can have.
+var WishPriorities = []string{WishPriorityMustHave, WishPriorityNiceToHave}
+
+func IsWishPriority(priority string) bool {
+	return slices.Contains(WishPriorities, priority)
+}
+
+// WishAttribute is a structured detail of a wish such as its size or color.
+// Keys are unique per wish, ignoring case.
+type WishAttribute struct {
+	Key   string `db:"key" json:"key"`
+	Value string `db:"value" json:"value"`
+}
+
+// wishAttributesColumn selects a JSON array of w's attributes in the order
+// they were given.
+const wishAttributesColumn = `(SELECT json_group_array(json_object('key', key, 'value', value)) FROM (
+					SELECT key, value FROM wish_attributes WHERE wish_id = w.id ORDER BY position
+				)) AS attributes`
+
+func setWishDetailDefaults(item *Wish) {
+	if item.Priority == "" {
+		item.Priority = WishPriorityNiceToHave
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+}
+
+func insertWishAttributes(ctx context.Context, tx *sql.Tx, wishID string, attributes []WishAttribute) error {
+	for i, attr := range attributes {
+		query := `INSERT INTO wish_attributes (wish_id, key, value, position) VALUES (?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, wishID, attr.Key, attr.Value, i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
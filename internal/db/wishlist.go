@@ -11,13 +11,14 @@ type Wishlist struct {
 	Name        string     `db:"name" json:"name"`
 	Description string     `db:"description" json:"description"`
 	IsPublic    bool       `db:"is_public" json:"is_public"`
+	EventDate   *string    `db:"event_date" json:"event_date"`
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at"`
 }
 
 func (s *Storage) CreateWishlist(ctx context.Context, list Wishlist) (Wishlist, error) {
-	query := `INSERT INTO wishlists (id, user_id, name, description, is_public) VALUES (?, ?, ?, ?, ?)`
+	query := `INSERT INTO wishlists (id, user_id, name, description, is_public, event_date) VALUES (?, ?, ?, ?, ?, ?)`
 
 	_, err := s.db.ExecContext(ctx, query,
 		list.ID,
@@ -25,6 +26,7 @@ func (s *Storage) CreateWishlist(ctx context.Context, list Wishlist) (Wishlist,
 		list.Name,
 		list.Description,
 		list.IsPublic,
+		list.EventDate,
 	)
 
 	if err != nil {
@@ -35,7 +37,7 @@ func (s *Storage) CreateWishlist(ctx context.Context, list Wishlist) (Wishlist,
 }
 
 func (s *Storage) GetWishlistByID(ctx context.Context, id string) (Wishlist, error) {
-	query := `SELECT id, user_id, name, description, is_public, created_at FROM wishlists WHERE id = ?`
+	query := `SELECT id, user_id, name, IFNULL(description, ''), is_public, event_date, created_at FROM wishlists WHERE id = ? AND deleted_at IS NULL`
 
 	var list Wishlist
 
@@ -45,6 +47,7 @@ func (s *Storage) GetWishlistByID(ctx context.Context, id string) (Wishlist, err
 		&list.Name,
 		&list.Description,
 		&list.IsPublic,
+		&list.EventDate,
 		&list.CreatedAt,
 	); err != nil && IsNoRowsError(err) {
 		return Wishlist{}, ErrNotFound
@@ -56,7 +59,7 @@ func (s *Storage) GetWishlistByID(ctx context.Context, id string) (Wishlist, err
 }
 
 func (s *Storage) GetWishlists(ctx context.Context) ([]Wishlist, error) {
-	query := `SELECT id, user_id, name, description, is_public, created_at FROM wishlists`
+	query := `SELECT id, user_id, name, description, is_public, event_date, created_at FROM wishlists`
 
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
@@ -74,6 +77,7 @@ func (s *Storage) GetWishlists(ctx context.Context) ([]Wishlist, error) {
 			&list.Name,
 			&list.Description,
 			&list.IsPublic,
+			&list.EventDate,
 			&list.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -84,3 +88,79 @@ func (s *Storage) GetWishlists(ctx context.Context) ([]Wishlist, error) {
 
 	return lists, nil
 }
+
+// ListUserWishlists returns the user's wishlists, newest first.
+func (s *Storage) ListUserWishlists(ctx context.Context, uid string) ([]Wishlist, error) {
+	query := `
+		SELECT id, user_id, name, IFNULL(description, ''), is_public, event_date, created_at
+		FROM wishlists
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY created_at DESC, rowid DESC`
+
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]Wishlist, 0)
+	for rows.Next() {
+		var list Wishlist
+		if err := rows.Scan(
+			&list.ID,
+			&list.UserID,
+			&list.Name,
+			&list.Description,
+			&list.IsPublic,
+			&list.EventDate,
+			&list.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+// UpdateWishlist saves the name, description, visibility and event date of
+// one of the user's wishlists. It returns ErrNotFound for lists they do not own.
+func (s *Storage) UpdateWishlist(ctx context.Context, list Wishlist) error {
+	query := `
+		UPDATE wishlists
+		SET name = ?, description = ?, is_public = ?, event_date = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, list.Name, list.Description, list.IsPublic, list.EventDate, list.ID, list.UserID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteWishlist removes one of the user's wishlists. It returns ErrNotFound
+// for lists they do not own.
+func (s *Storage) DeleteWishlist(ctx context.Context, uid, id string) error {
+	query := `UPDATE wishlists SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id, uid)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package i18n
+
+var en = map[Key]string{
+	BotWelcome:            "Welcome! Use /start to get started with the bot.",
+	BotRegistrationFailed: "Could not register you. Please try again later.",
+	BotUserFetchFailed:    "Could not load your account. Please try again later.",
+	BotStart:              "Hi\\!",
+	BotHelp: "Commands:\n" +
+		"/list — my wishes\n" +
+		"/add <name> [link] [price] — add a wish\n" +
+		"/share — link to my profile\n" +
+		"/reserved — gifts I promised",
+	BotUnknownCommand: "Unknown command. Use /help to see what I can do.",
+	BotFallback:       "I only understand commands. Use /help to see the list.",
+	BotMenuButton:     "Open App",
+	BotOpen:           "Open",
+	BotOpenNumbered:   "%d. Open",
+	BotOpenInApp:      "Open in app",
+	BotOpenApp:        "Open app",
+	BotUntitled:       "Untitled",
+
+	BotListEmpty:  "You have no wishes yet. Add the first one with /add or in the app.",
+	BotListHeader: "My wishes (%d/%d):",
+	BotListFailed: "Could not load your wishes. Please try again later.",
+
+	BotAddUsage:       "Usage: /add <name> [link] [price]",
+	BotAddNameTooLong: "Name cannot be longer than 200 characters.",
+	BotAddInvalidURL:  "Invalid link.",
+	BotAddFailed:      "Could not add the wish. Please try again later.",
+	BotAddSuccess:     "Wish “%s” added. Open it in the app to add a photo and a category.",
+
+	BotShareText:   "Link to your profile:\n%s",
+	BotShareButton: "Share",
+
+	BotReservedEmpty:  "You haven't promised to gift anything yet.",
+	BotReservedHeader: "You promised to gift:",
+	BotReservedFailed: "Could not load the list. Please try again later.",
+
+	NotificationFollow:        "%s started following you.",
+	NotificationFollowRequest: "%s wants to follow you.",
+	NotificationBookmark:      "%s saved your wish “%s”.",
+	NotificationCopy:          "%s added your wish “%s” to their list.",
+	NotificationComment:       "%s commented on “%s”.",
+	NotificationBatchHeader:   "You have %d new notifications:",
+
+	ReminderBirthdayToday:    "Today is %s's birthday! 🎂",
+	ReminderBirthdayTomorrow: "%s's birthday is tomorrow.",
+	ReminderBirthdayIn:       "%s's birthday is in %d days.",
+	ReminderEventToday:       "%s: “%s” is today.",
+	ReminderEventTomorrow:    "%s: “%s” is tomorrow.",
+	ReminderEventIn:          "%s: “%s” is in %d days.",
+	ReminderGiftIdeas:        "Gift ideas nobody has reserved yet:",
+	ReminderNoWishes:         "They have no open wishes yet — a good moment to ask what they'd like.",
+	ReminderOpenProfile:      "Open profile",
+
+	DigestHeader:     "Your week in wishes",
+	DigestNewWishes:  "New from people you follow:",
+	DigestMoreWishes: "…and %d more",
+	DigestPriceDrops: "Price drops:",
+	DigestSaves:      "Your wishes were saved:",
+	DigestSavesLine:  "%s — %d",
+	DigestOpenFeed:   "Open feed",
+
+	ThankYouNote: "%s received “%s” from you and says thank you:\n\n%s",
+
+	EmailLoginSubject: "Your Sacred login link",
+	EmailLoginBody:    "Follow this link to log in to Sacred:\n%s\n\nThe link works once and expires in 15 minutes. If you did not ask for it, just ignore this email.",
+
+	EmailConfirmSubject: "Confirm your email for Sacred",
+	EmailConfirmBody:    "Follow this link to confirm this email for your Sacred account:\n%s\n\nThe link expires in 24 hours. Until then the address cannot be used to log in. If you did not ask for it, just ignore this email.",
+
+	ShareWishOwner:     "Wishlist: %s",
+	ShareProfileWishes: "Wishes: %d",
+	ShareOpenWish:      "Open in Telegram",
+	ShareOpenProfile:   "View wishlist in Telegram",
+}
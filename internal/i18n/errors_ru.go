@@ -0,0 +1,205 @@
+package i18n
+
+var ruErrors = map[string]string{
+	"Internal Server Error": "Внутренняя ошибка сервера",
+	"Not Found":             "Не найдено",
+	"Method Not Allowed":    "Метод не поддерживается",
+	"Unauthorized":          "Требуется авторизация",
+
+	"invalid init data from telegram": "некорректные данные инициализации Telegram",
+	"failed to validate request":      "некорректный запрос",
+	"failed to bind request":          "не удалось прочитать запрос",
+	"invalid auth token":              "недействительный токен авторизации",
+	"auth is invalid":                 "недействительная авторизация",
+	"no authentication token":         "отсутствует токен авторизации",
+	"invalid token":                   "недействительный токен",
+	"invalid claims":                  "недействительные данные токена",
+	"user ID not found in token":      "в токене нет идентификатора пользователя",
+	"jwt library error":               "ошибка выпуска токена",
+	"invalid refresh token":           "недействительный токен обновления",
+	"session has been revoked":        "сеанс завершён",
+	"failed to create session":        "не удалось создать сеанс",
+	"failed to refresh session":       "не удалось обновить сеанс",
+	"failed to revoke session":        "не удалось завершить сеанс",
+	"failed to check session":         "не удалось проверить сеанс",
+	"could not list sessions":         "не удалось загрузить сеансы",
+	"session not found":               "сеанс не найден",
+	"invalid api token":               "недействительный API-токен",
+	"api token is read-only":          "API-токен только для чтения",
+	"failed to check api token":       "не удалось проверить API-токен",
+	"not allowed with an api token":   "недоступно с API-токеном",
+	"could not list api tokens":       "не удалось загрузить API-токены",
+	"could not create api token":      "не удалось создать API-токен",
+	"too many api tokens":             "слишком много API-токенов",
+	"api token not found":             "API-токен не найден",
+	"could not delete api token":      "не удалось удалить API-токен",
+	"invalid telegram login data":     "некорректные данные входа через Telegram",
+	"invalid or expired login link":   "ссылка для входа недействительна или устарела",
+	"failed to send login link":       "не удалось отправить ссылку для входа",
+
+	"invalid or expired confirmation link": "ссылка для подтверждения недействительна или устарела",
+	"email is already in use":              "эта почта уже используется",
+	"failed to confirm email":              "не удалось подтвердить почту",
+	"email is already confirmed":           "почта уже подтверждена",
+	"cannot send email confirmation":       "не удалось отправить письмо для подтверждения почты",
+	"page not found":                       "страница не найдена",
+	"failed to render page":                "не удалось отобразить страницу",
+	"failed to render image":               "не удалось нарисовать изображение",
+
+	"failed to create user":            "не удалось создать пользователя",
+	"failed to get user":               "не удалось получить пользователя",
+	"failed to get user's wishlists":   "не удалось получить желания пользователя",
+	"cannot get user":                  "не удалось получить пользователя",
+	"user not found":                   "пользователь не найден",
+	"cannot get updated user":          "не удалось получить обновлённого пользователя",
+	"cannot update user":               "не удалось обновить пользователя",
+	"user id cannot be empty":          "идентификатор пользователя не может быть пустым",
+	"interests cannot be empty":        "интересы не могут быть пустыми",
+	"cannot list profiles":             "не удалось загрузить профили",
+	"cannot check following status":    "не удалось проверить подписку",
+	"cannot count followers":           "не удалось посчитать подписчиков",
+	"could not list follows":           "не удалось получить список подписок",
+	"could not search users":           "не удалось найти пользователей",
+	"could not suggest users":          "не удалось подобрать пользователей",
+	"account is private":               "закрытый аккаунт",
+	"already following this user":      "вы уже подписаны на этого пользователя",
+	"could not follow user":            "не удалось подписаться на пользователя",
+	"could not unfollow user":          "не удалось отписаться от пользователя",
+	"cannot follow this user":          "нельзя подписаться на этого пользователя",
+	"follow request already sent":      "запрос на подписку уже отправлен",
+	"follow request not found":         "запрос на подписку не найден",
+	"could not list follow requests":   "не удалось загрузить запросы на подписку",
+	"could not accept follow request":  "не удалось принять запрос на подписку",
+	"could not decline follow request": "не удалось отклонить запрос на подписку",
+	"cannot check block status":        "не удалось проверить блокировку",
+	"cannot check mute status":         "не удалось проверить скрытие",
+	"cannot block yourself":            "нельзя заблокировать себя",
+	"could not block user":             "не удалось заблокировать пользователя",
+	"could not unblock user":           "не удалось разблокировать пользователя",
+	"could not list blocked users":     "не удалось загрузить заблокированных",
+	"cannot mute yourself":             "нельзя скрыть себя",
+	"could not mute user":              "не удалось скрыть пользователя",
+	"could not unmute user":            "не удалось вернуть пользователя в ленту",
+	"could not list muted users":       "не удалось загрузить скрытых",
+	"could not list referrals":         "не удалось загрузить приглашённых",
+
+	"username is already taken": "это имя пользователя уже занято",
+	"username is reserved":      "это имя пользователя зарезервировано",
+	"cannot check username":     "не удалось проверить имя пользователя",
+	"username must be 3 to 32 letters, digits or underscores and start with a letter": "имя пользователя должно содержать от 3 до 32 латинских букв, цифр или подчёркиваний и начинаться с буквы",
+
+	"could not list notifications":              "не удалось загрузить уведомления",
+	"could not mark notifications as read":      "не удалось отметить уведомления прочитанными",
+	"could not get notification preferences":    "не удалось загрузить настройки уведомлений",
+	"could not update notification preferences": "не удалось сохранить настройки уведомлений",
+
+	"cannot get categories":   "не удалось загрузить категории",
+	"cannot get health stats": "не удалось получить состояние сервиса",
+
+	"wish not found":                                           "желание не найдено",
+	"wish id cannot be empty":                                  "идентификатор желания не может быть пустым",
+	"wish ID is required":                                      "требуется идентификатор желания",
+	"Wish ID is required":                                      "требуется идентификатор желания",
+	"cannot get wish":                                          "не удалось получить желание",
+	"cannot get wishlist item":                                 "не удалось получить желание",
+	"cannot get wishlist items":                                "не удалось получить желания",
+	"wishlist item not found":                                  "желание не найдено",
+	"cannot create wishlist item in database":                  "не удалось сохранить желание",
+	"cannot update wishlist item in database":                  "не удалось обновить желание",
+	"failed to retrieve created wishlist item":                 "не удалось получить созданное желание",
+	"failed to retrieve updated wishlist item":                 "не удалось получить обновлённое желание",
+	"cannot delete other user's wish":                          "нельзя удалить чужое желание",
+	"cannot delete wishlist item":                              "не удалось удалить желание",
+	"failed to parse multipart form":                           "не удалось прочитать форму",
+	"failed to delete images":                                  "не удалось удалить изображения",
+	"at least photos or image_urls must be provided":           "нужно добавить хотя бы одно фото или ссылку на изображение",
+	"name is required and cannot be empty":                     "название обязательно",
+	"name cannot be longer than 200 characters":                "название не может быть длиннее 200 символов",
+	"notes cannot be longer than 1000 characters":              "заметка не может быть длиннее 1000 символов",
+	"invalid URL format":                                       "некорректная ссылка",
+	"invalid visibility":                                       "некорректная видимость",
+	"cannot generate share token":                              "не удалось создать ссылку для доступа",
+	"URL must include a scheme (e.g., http, https) and a host": "ссылка должна содержать схему (http, https) и домен",
+	"price cannot be negative":                                 "цена не может быть отрицательной",
+	"currency is required when price is provided":              "укажите валюту вместе с ценой",
+	"currency must be a 3-letter ISO code":                     "валюта должна быть трёхбуквенным кодом ISO",
+	"category_ids cannot be empty":                             "выберите хотя бы одну категорию",
+
+	"Invalid image URL":             "некорректная ссылка на изображение",
+	"invalid image format":          "неподдерживаемый формат изображения",
+	"Error creating request":        "ошибка при создании запроса",
+	"Error downloading image":       "не удалось скачать изображение",
+	"Error reading image data":      "не удалось прочитать изображение",
+	"Error decoding image":          "не удалось декодировать изображение",
+	"Error uploading image to S3":   "не удалось загрузить изображение",
+	"cannot save image to database": "не удалось сохранить изображение",
+	"cannot copy images":            "не удалось скопировать изображения",
+
+	"source wish not found":                "исходное желание не найдено",
+	"cannot fetch source wish":             "не удалось получить исходное желание",
+	"cannot fetch copied wish":             "не удалось получить скопированное желание",
+	"Wish was already copied":              "желание уже сохранено",
+	"Cannot create copied wish":            "не удалось сохранить желание",
+	"cannot bookmark own wish":             "нельзя добавить в закладки своё желание",
+	"could not remove wish from bookmarks": "не удалось убрать желание из закладок",
+	"could not list bookmarked wishes":     "не удалось загрузить закладки",
+
+	"cannot get wish savers":                      "не удалось получить список сохранивших",
+	"Failed to retrieve users who saved the wish": "не удалось получить список сохранивших",
+
+	"search query cannot be empty":          "поисковый запрос не может быть пустым",
+	"cannot fetch autocomplete suggestions": "не удалось получить подсказки",
+	"cannot fetch wishes feed":              "не удалось загрузить ленту",
+
+	"cannot reserve own wish":        "нельзя забронировать своё желание",
+	"wish is already reserved":       "желание уже забронировано",
+	"cannot reserve wish":            "не удалось забронировать желание",
+	"cannot reserve this wish":       "нельзя забронировать это желание",
+	"reservation not found":          "бронь не найдена",
+	"cannot remove reservation":      "не удалось снять бронь",
+	"could not list reserved wishes": "не удалось загрузить забронированные желания",
+
+	"could not get account deletion":     "не удалось получить статус удаления аккаунта",
+	"could not request account deletion": "не удалось запланировать удаление аккаунта",
+	"account deletion was not requested": "удаление аккаунта не запрашивалось",
+	"could not cancel account deletion":  "не удалось отменить удаление аккаунта",
+	"could not export account":           "не удалось выгрузить данные аккаунта",
+
+	"could not list comments":           "не удалось загрузить комментарии",
+	"could not create comment":          "не удалось добавить комментарий",
+	"could not delete comment":          "не удалось удалить комментарий",
+	"comment not found":                 "комментарий не найден",
+	"cannot comment on this wish":       "нельзя комментировать это желание",
+	"cannot ask a question on own wish": "нельзя задать вопрос к своему желанию",
+	"cannot delete this comment":        "нельзя удалить этот комментарий",
+
+	"invalid reaction":          "неизвестная реакция",
+	"cannot react to own wish":  "нельзя реагировать на своё желание",
+	"cannot react to this wish": "нельзя реагировать на это желание",
+	"could not save reaction":   "не удалось сохранить реакцию",
+	"could not remove reaction": "не удалось убрать реакцию",
+	"could not count reactions": "не удалось посчитать реакции",
+
+	"quantity must be between 1 and 99":   "количество должно быть от 1 до 99",
+	"cannot have more than 20 attributes": "нельзя указать больше 20 характеристик",
+
+	"invalid priority":                                 "неизвестный приоритет",
+	"quantity cannot be less than received":            "количество не может быть меньше полученного",
+	"is_fulfilled cannot be set directly":              "отметка об исполнении меняется только через подарки",
+	"invalid attributes":                               "неверный формат характеристик",
+	"attribute key must be 1 to 50 characters long":    "название характеристики должно быть от 1 до 50 символов",
+	"attribute value must be 1 to 200 characters long": "значение характеристики должно быть от 1 до 200 символов",
+	"duplicate attribute key":                          "характеристики не должны повторяться",
+
+	"wish is already fulfilled":     "желание уже исполнено",
+	"could not fulfill wish":        "не удалось отметить желание исполненным",
+	"could not unfulfill wish":      "не удалось снять отметку об исполнении",
+	"could not list received gifts": "не удалось загрузить полученные подарки",
+
+	"wishlist not found":        "список желаний не найден",
+	"could not list wishlists":  "не удалось загрузить списки желаний",
+	"could not create wishlist": "не удалось создать список желаний",
+	"could not update wishlist": "не удалось обновить список желаний",
+	"could not delete wishlist": "не удалось удалить список желаний",
+	"could not get wishlist":    "не удалось получить список желаний",
+}
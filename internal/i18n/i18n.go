@@ -0,0 +1,114 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Key identifies a user-facing message in the catalog.
+type Key string
+
+const DefaultLanguage = "en"
+
+var catalogs = map[string]map[Key]string{
+	"en": en,
+	"ru": ru,
+}
+
+// errorCatalogs translate API error messages. Handlers write errors in English,
+// so the English text is the key and English itself needs no catalog.
+var errorCatalogs = map[string]map[string]string{
+	"ru": ruErrors,
+}
+
+// Languages returns the supported language codes in a stable order.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Normalize maps a Telegram or HTTP language tag such as "ru-RU" to a supported
+// language, falling back to DefaultLanguage.
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+
+	if Supported(lang) {
+		return lang
+	}
+
+	return DefaultLanguage
+}
+
+// T returns the message for key in lang, formatting it with args when given.
+func T(lang string, key Key, args ...interface{}) string {
+	msg, ok := catalogs[Normalize(lang)][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+
+	return msg
+}
+
+// Error translates an English API error message, returning it unchanged when
+// there is no translation.
+func Error(lang, msg string) string {
+	if translated, ok := errorCatalogs[Normalize(lang)][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// FromAcceptLanguage picks the supported language with the highest weight from
+// an Accept-Language header, or an empty string when none is supported.
+func FromAcceptLanguage(header string) string {
+	best := ""
+	bestQ := 0.0
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		lang := strings.ToLower(tag)
+		if i := strings.IndexAny(lang, "-_"); i >= 0 {
+			lang = lang[:i]
+		}
+
+		if Supported(lang) && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,166 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// declaredKeys collects every Key constant from keys.go so that a key added
+// there without catalog entries fails the tests.
+func declaredKeys(t *testing.T) []Key {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "keys.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse keys.go: %v", err)
+	}
+
+	var keys []Key
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for _, v := range spec.Values {
+			lit, ok := v.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatalf("failed to unquote %s: %v", lit.Value, err)
+			}
+			keys = append(keys, Key(value))
+		}
+		return true
+	})
+
+	return keys
+}
+
+func TestCatalogsHaveAllKeys(t *testing.T) {
+	keys := declaredKeys(t)
+	if len(keys) == 0 {
+		t.Fatal("no keys declared")
+	}
+
+	for _, lang := range Languages() {
+		catalog := catalogs[lang]
+		for _, key := range keys {
+			if msg, ok := catalog[key]; !ok || msg == "" {
+				t.Errorf("locale %q is missing key %q", lang, key)
+			}
+		}
+		if len(catalog) != len(keys) {
+			t.Errorf("locale %q has %d messages, want %d declared keys", lang, len(catalog), len(keys))
+		}
+	}
+}
+
+var verbRe = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z]`)
+
+func TestCatalogsHaveMatchingFormatVerbs(t *testing.T) {
+	for _, key := range declaredKeys(t) {
+		want := verbRe.FindAllString(catalogs[DefaultLanguage][key], -1)
+		for _, lang := range Languages() {
+			got := verbRe.FindAllString(catalogs[lang][key], -1)
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("locale %q key %q has verbs %v, want %v", lang, key, got, want)
+			}
+		}
+	}
+}
+
+var httpErrorRe = regexp.MustCompile(`NewHTTPError\(http\.Status\w+, "((?:[^"\\]|\\.)*)"`)
+var errorConstRe = regexp.MustCompile(`\bErr\w+\s*=\s*"((?:[^"\\]|\\.)*)"`)
+
+// TestErrorMessagesTranslated makes sure every literal API error message has a
+// translation in every non-default locale.
+func TestErrorMessagesTranslated(t *testing.T) {
+	files, err := filepath.Glob("../*/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := map[string]string{}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, re := range []*regexp.Regexp{httpErrorRe, errorConstRe} {
+			for _, m := range re.FindAllStringSubmatch(string(src), -1) {
+				msg, err := strconv.Unquote(`"` + m[1] + `"`)
+				if err != nil {
+					t.Fatalf("failed to unquote %q in %s: %v", m[1], path, err)
+				}
+				messages[msg] = path
+			}
+		}
+	}
+
+	if len(messages) == 0 {
+		t.Fatal("no error messages found")
+	}
+
+	for lang, catalog := range errorCatalogs {
+		for msg, path := range messages {
+			if _, ok := catalog[msg]; !ok {
+				t.Errorf("locale %q is missing error %q (%s)", lang, msg, path)
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"ru":    "ru",
+		"ru-RU": "ru",
+		"RU":    "ru",
+		"en-US": "en",
+		"de":    "en",
+		"":      "en",
+	}
+
+	for input, want := range tests {
+		if got := Normalize(input); got != want {
+			t.Errorf("Normalize(%q) = %q; want %q", input, got, want)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := map[string]string{
+		"ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7": "ru",
+		"en-US,en;q=0.9,ru;q=0.8":             "en",
+		"de-DE,de;q=0.9,ru;q=0.5":             "ru",
+		"de-DE":                               "",
+		"":                                    "",
+		"*":                                   "",
+	}
+
+	for header, want := range tests {
+		if got := FromAcceptLanguage(header); got != want {
+			t.Errorf("FromAcceptLanguage(%q) = %q; want %q", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("ru-RU", BotListHeader, 1, 3); got != "Мои желания (1/3):" {
+		t.Errorf("unexpected message %q", got)
+	}
+	if got := T("fr", BotListHeader, 1, 3); got != "My wishes (1/3):" {
+		t.Errorf("unexpected fallback message %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package i18n
+
+const (
+	BotWelcome            Key = "bot.welcome"
+	BotRegistrationFailed Key = "bot.registration_failed"
+	BotUserFetchFailed    Key = "bot.user_fetch_failed"
+	BotStart              Key = "bot.start"
+	BotHelp               Key = "bot.help"
+	BotUnknownCommand     Key = "bot.unknown_command"
+	BotFallback           Key = "bot.fallback"
+	BotMenuButton         Key = "bot.menu_button"
+	BotOpen               Key = "bot.open"
+	BotOpenNumbered       Key = "bot.open_numbered"
+	BotOpenInApp          Key = "bot.open_in_app"
+	BotOpenApp            Key = "bot.open_app"
+	BotUntitled           Key = "bot.untitled"
+
+	BotListEmpty  Key = "bot.list.empty"
+	BotListHeader Key = "bot.list.header"
+	BotListFailed Key = "bot.list.failed"
+
+	BotAddUsage       Key = "bot.add.usage"
+	BotAddNameTooLong Key = "bot.add.name_too_long"
+	BotAddInvalidURL  Key = "bot.add.invalid_url"
+	BotAddFailed      Key = "bot.add.failed"
+	BotAddSuccess     Key = "bot.add.success"
+
+	BotShareText   Key = "bot.share.text"
+	BotShareButton Key = "bot.share.button"
+
+	BotReservedEmpty  Key = "bot.reserved.empty"
+	BotReservedHeader Key = "bot.reserved.header"
+	BotReservedFailed Key = "bot.reserved.failed"
+
+	NotificationFollow        Key = "notification.follow"
+	NotificationFollowRequest Key = "notification.follow_request"
+	NotificationBookmark      Key = "notification.bookmark"
+	NotificationCopy          Key = "notification.copy"
+	NotificationComment       Key = "notification.comment"
+	NotificationBatchHeader   Key = "notification.batch_header"
+
+	ReminderBirthdayToday    Key = "reminder.birthday.today"
+	ReminderBirthdayTomorrow Key = "reminder.birthday.tomorrow"
+	ReminderBirthdayIn       Key = "reminder.birthday.in"
+	ReminderEventToday       Key = "reminder.event.today"
+	ReminderEventTomorrow    Key = "reminder.event.tomorrow"
+	ReminderEventIn          Key = "reminder.event.in"
+	ReminderGiftIdeas        Key = "reminder.gift_ideas"
+	ReminderNoWishes         Key = "reminder.no_wishes"
+	ReminderOpenProfile      Key = "reminder.open_profile"
+
+	DigestHeader     Key = "digest.header"
+	DigestNewWishes  Key = "digest.new_wishes"
+	DigestMoreWishes Key = "digest.more_wishes"
+	DigestPriceDrops Key = "digest.price_drops"
+	DigestSaves      Key = "digest.saves"
+	DigestSavesLine  Key = "digest.saves_line"
+	DigestOpenFeed   Key = "digest.open_feed"
+
+	ThankYouNote Key = "thank_you.note"
+
+	EmailLoginSubject Key = "email.login.subject"
+	EmailLoginBody    Key = "email.login.body"
+
+	EmailConfirmSubject Key = "email.confirm.subject"
+	EmailConfirmBody    Key = "email.confirm.body"
+
+	ShareWishOwner     Key = "share.wish_owner"
+	ShareProfileWishes Key = "share.profile_wishes"
+	ShareOpenWish      Key = "share.open_wish"
+	ShareOpenProfile   Key = "share.open_profile"
+)
@@ -0,0 +1,76 @@
+package i18n
+
+var ru = map[Key]string{
+	BotWelcome:            "Добро пожаловать! Используй /start для начала работы с ботом.",
+	BotRegistrationFailed: "Ошибка при регистрации пользователя. Попробуй позже.",
+	BotUserFetchFailed:    "Ошибка при получении пользователя. Попробуй позже.",
+	BotStart:              "Привет\\!",
+	BotHelp: "Команды:\n" +
+		"/list — мои желания\n" +
+		"/add <название> [ссылка] [цена] — добавить желание\n" +
+		"/share — ссылка на мой профиль\n" +
+		"/reserved — что я обещал подарить",
+	BotUnknownCommand: "Неизвестная команда. Используй /help для получения справки.",
+	BotFallback:       "Я понимаю только команды. Используй /help, чтобы увидеть список.",
+	BotMenuButton:     "Открыть",
+	BotOpen:           "Открыть",
+	BotOpenNumbered:   "%d. Открыть",
+	BotOpenInApp:      "Открыть в приложении",
+	BotOpenApp:        "Открыть приложение",
+	BotUntitled:       "Без названия",
+
+	BotListEmpty:  "У тебя пока нет желаний. Добавь первое через /add или в приложении.",
+	BotListHeader: "Мои желания (%d/%d):",
+	BotListFailed: "Не удалось загрузить желания. Попробуй позже.",
+
+	BotAddUsage:       "Использование: /add <название> [ссылка] [цена]",
+	BotAddNameTooLong: "Название не может быть длиннее 200 символов.",
+	BotAddInvalidURL:  "Некорректная ссылка.",
+	BotAddFailed:      "Не удалось добавить желание. Попробуй позже.",
+	BotAddSuccess:     "Желание «%s» добавлено. Открой его в приложении, чтобы добавить фото и категорию.",
+
+	BotShareText:   "Ссылка на твой профиль:\n%s",
+	BotShareButton: "Поделиться",
+
+	BotReservedEmpty:  "Ты пока ничего не обещал подарить.",
+	BotReservedHeader: "Ты обещал подарить:",
+	BotReservedFailed: "Не удалось загрузить список. Попробуй позже.",
+
+	NotificationFollow:        "%s подписался на тебя.",
+	NotificationFollowRequest: "%s хочет подписаться на тебя.",
+	NotificationBookmark:      "%s сохранил твоё желание «%s».",
+	NotificationCopy:          "%s добавил твоё желание «%s» к себе.",
+	NotificationComment:       "%s оставил комментарий к «%s».",
+	NotificationBatchHeader:   "Новых уведомлений: %d",
+
+	ReminderBirthdayToday:    "Сегодня день рождения у %s! 🎂",
+	ReminderBirthdayTomorrow: "Завтра день рождения у %s.",
+	ReminderBirthdayIn:       "День рождения у %s через %d дн.",
+	ReminderEventToday:       "%s: «%s» сегодня.",
+	ReminderEventTomorrow:    "%s: «%s» завтра.",
+	ReminderEventIn:          "%s: «%s» через %d дн.",
+	ReminderGiftIdeas:        "Идеи для подарка, которые ещё никто не забронировал:",
+	ReminderNoWishes:         "Свободных желаний пока нет — самое время спросить, что подарить.",
+	ReminderOpenProfile:      "Открыть профиль",
+
+	DigestHeader:     "Твоя неделя в желаниях",
+	DigestNewWishes:  "Новое у тех, на кого ты подписан:",
+	DigestMoreWishes: "…и ещё %d",
+	DigestPriceDrops: "Подешевело:",
+	DigestSaves:      "Твои желания сохранили:",
+	DigestSavesLine:  "%s — %d",
+	DigestOpenFeed:   "Открыть ленту",
+
+	ThankYouNote: "%s получил от тебя «%s» и благодарит:\n\n%s",
+
+	EmailLoginSubject: "Ссылка для входа в Sacred",
+	EmailLoginBody:    "Перейди по ссылке, чтобы войти в Sacred:\n%s\n\nСсылка одноразовая и действует 15 минут. Если ты её не запрашивал, просто проигнорируй это письмо.",
+
+	EmailConfirmSubject: "Подтверди почту для Sacred",
+	EmailConfirmBody:    "Перейди по ссылке, чтобы подтвердить этот адрес для аккаунта Sacred:\n%s\n\nСсылка действует 24 часа. До подтверждения по этому адресу нельзя войти. Если ты её не запрашивал, просто проигнорируй это письмо.",
+
+	ShareWishOwner:     "Вишлист: %s",
+	ShareProfileWishes: "Желаний: %d",
+	ShareOpenWish:      "Открыть в Telegram",
+	ShareOpenProfile:   "Смотреть вишлист в Telegram",
+}
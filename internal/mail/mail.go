@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client sends plain-text emails through an SMTP server.
+type Client struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewClient creates an SMTP client. Username may be empty for servers that
+// do not require authentication.
+func NewClient(host string, port int, username, password, from string) *Client {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Client{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+// ErrNotConfigured is returned by a nil Client, which main uses when no SMTP
+// server is set up.
+var ErrNotConfigured = errors.New("mail is not configured")
+
+func (c *Client) Send(ctx context.Context, to, subject, body string) error {
+	if c == nil {
+		return ErrNotConfigured
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", to)
+	}
+
+	headers := []string{
+		"From: " + c.from,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(body, "\n", "\r\n")
+
+	if err := smtp.SendMail(c.addr, c.auth, c.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
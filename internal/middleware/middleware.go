@@ -11,6 +11,7 @@ import (
 	"log/slog"
 	"net/http"
 	"sacred/internal/contract"
+	"sacred/internal/i18n"
 	"time"
 )
 
@@ -48,7 +49,7 @@ func CustomHTTPErrorHandler(logger *slog.Logger) echo.HTTPErrorHandler {
 
 		if msgStr, ok := message.(string); ok {
 			logAttrs = append(logAttrs, slog.String("message", msgStr))
-			message = map[string]interface{}{"error": msgStr}
+			message = map[string]interface{}{"error": i18n.Error(requestLanguage(c), msgStr)}
 		}
 
 		if internalErr != nil {
@@ -70,6 +71,19 @@ func CustomHTTPErrorHandler(logger *slog.Logger) echo.HTTPErrorHandler {
 	}
 }
 
+// requestLanguage prefers the language of the authenticated user and falls back to Accept-Language.
+func requestLanguage(c echo.Context) string {
+	if p, ok := GetPrincipal(c); ok && p.Lang != "" {
+		return p.Lang
+	}
+
+	if lang := i18n.FromAcceptLanguage(c.Request().Header.Get("Accept-Language")); lang != "" {
+		return lang
+	}
+
+	return i18n.DefaultLanguage
+}
+
 func Setup(e *echo.Echo, logger *slog.Logger) {
 	// e.Use(echoMiddleware.Recover())
 	e.Use(echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
@@ -104,6 +118,9 @@ func Setup(e *echo.Echo, logger *slog.Logger) {
 	}))
 }
 
+// GetUserAuthConfig authenticates requests that carry a JWT and lets requests
+// without one through anonymously; RequireAuth guards the routes that need a
+// user. A present but invalid token is always rejected.
 func GetUserAuthConfig(secret string) echojwt.Config {
 	return echojwt.Config{
 		NewClaimsFunc: func(_ echo.Context) jwt.Claims {
@@ -111,15 +128,20 @@ func GetUserAuthConfig(secret string) echojwt.Config {
 		},
 		SigningKey:             []byte(secret),
 		ContinueOnIgnoredError: true,
+		SuccessHandler: func(c echo.Context) {
+			if token, ok := c.Get("user").(*jwt.Token); ok {
+				if claims, ok := token.Claims.(*contract.JWTClaims); ok && claims.UID != "" {
+					SetPrincipal(c, principalFromClaims(claims))
+				}
+			}
+		},
 		ErrorHandler: func(c echo.Context, err error) error {
 			var extErr *echojwt.TokenExtractionError
 			if !errors.As(err, &extErr) {
-				// For non-extraction errors, return unauthorized
 				return echo.NewHTTPError(http.StatusUnauthorized, "auth is invalid")
 			}
 
-			// For token extraction errors (missing token), just continue without setting user
-			// This makes authentication optional
+			// A missing token is not an error here.
 			return nil
 		},
 	}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"sacred/internal/contract"
+)
+
+const principalKey = "principal"
+
+// Principal is the authenticated caller of a request, however they signed in.
+type Principal struct {
+	UserID string
+	ChatID int64
+	Lang   string
+
+	// SessionID is set for access tokens issued to a login session.
+	SessionID string
+
+	// APITokenID and Scope are set for personal API tokens.
+	APITokenID string
+	Scope      string
+}
+
+func SetPrincipal(c echo.Context, p *Principal) {
+	c.Set(principalKey, p)
+}
+
+// GetPrincipal returns the caller, or false for anonymous requests.
+func GetPrincipal(c echo.Context) (*Principal, bool) {
+	p, ok := c.Get(principalKey).(*Principal)
+	return p, ok && p != nil
+}
+
+func principalFromClaims(claims *contract.JWTClaims) *Principal {
+	return &Principal{
+		UserID:    claims.UID,
+		ChatID:    claims.ChatID,
+		Lang:      claims.Lang,
+		SessionID: claims.SessionID,
+	}
+}
+
+// RequireAuth rejects requests that reach it without a principal. It goes after
+// the authentication middleware on routes that need a signed-in user.
+func RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := GetPrincipal(c); !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "no authentication token")
+		}
+
+		return next(c)
+	}
+}
@@ -3,13 +3,19 @@ package s3
 import (
 	"bytes"
 	"context"
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"io"
 	"strings"
 )
 
+// ErrNotFound is returned when the requested object does not exist.
+var ErrNotFound = errors.New("object not found")
+
 type Client struct {
 	S3Client *s3.Client
 	Bucket   string
@@ -59,11 +65,18 @@ func resolveContentType(fileName string) string {
 }
 
 func (s *Client) UploadFile(file []byte, fileName string) (string, error) {
+	return s.UploadFileWithMetadata(file, fileName, nil)
+}
+
+// UploadFileWithMetadata stores the file along with user-defined metadata,
+// replacing any object with the same name.
+func (s *Client) UploadFileWithMetadata(file []byte, fileName string, metadata map[string]string) (string, error) {
 	_, err := s.S3Client.PutObject(context.TODO(), &s3.PutObjectInput{
 		Bucket:      aws.String(s.Bucket),
 		Key:         aws.String(fileName),
 		Body:        bytes.NewReader(file),
 		ContentType: aws.String(resolveContentType(fileName)),
+		Metadata:    metadata,
 	})
 
 	if err != nil {
@@ -72,3 +85,43 @@ func (s *Client) UploadFile(file []byte, fileName string) (string, error) {
 
 	return fileName, nil
 }
+
+// DownloadFile returns the object's contents, or ErrNotFound.
+func (s *Client) DownloadFile(ctx context.Context, fileName string) ([]byte, error) {
+	data, _, err := s.DownloadFileWithMetadata(ctx, fileName)
+	return data, err
+}
+
+// DownloadFileWithMetadata returns the object's contents and user-defined
+// metadata, or ErrNotFound.
+func (s *Client) DownloadFileWithMetadata(ctx context.Context, fileName string) ([]byte, map[string]string, error) {
+	out, err := s.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(fileName),
+	})
+
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, out.Metadata, nil
+}
+
+// DeleteFile removes the object. Deleting a missing key succeeds.
+func (s *Client) DeleteFile(ctx context.Context, fileName string) error {
+	_, err := s.S3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(fileName),
+	})
+
+	return err
+}
@@ -79,17 +79,24 @@ type testSetup struct {
 	API      *api.API
 	MockS3   *MockPhotoUploader
 	MockBot  *MockTelegramBot
+	Telegram *FakeTelegram
+	Mailer   *FakeMailer
+	Config   api.Config
 	Teardown func()
 }
 
 func SetupTestEnvironment(t *testing.T) *testSetup {
 	t.Helper()
 
+	fakeTelegram := NewFakeTelegram(t)
+
 	hConfig := api.Config{
 		JWTSecret:        "test-jwt-secret",
 		AssetsURL:        "http://localhost/assets",
 		WebAppURL:        "http://localhost/webapp",
+		MiniAppURL:       "https://t.me/test_bot/app",
 		TelegramBotToken: TestBotToken,
+		TelegramAPIURL:   fakeTelegram.URL(),
 	}
 
 	// Use a shared in-memory database for tests to avoid connection issues
@@ -107,7 +114,8 @@ func SetupTestEnvironment(t *testing.T) *testSetup {
 
 	// 4. Mocks: TODO
 
-	a := api.New(storage, hConfig, nil, nil)
+	mailer := &FakeMailer{}
+	a := api.New(storage, hConfig, nil, fakeTelegram.NewBot(t, telegram.WithSkipGetMe()), mailer)
 
 	e := echo.New()
 	middleware.Setup(e, logger)
@@ -123,10 +131,42 @@ func SetupTestEnvironment(t *testing.T) *testSetup {
 		Echo:     e,
 		Storage:  storage,
 		API:      a,
+		Telegram: fakeTelegram,
+		Mailer:   mailer,
+		Config:   hConfig,
 		Teardown: teardown,
 	}
 }
 
+// StartPolling runs a second API instance in long-polling mode against the same
+// storage and fake Telegram server. The returned func stops it and must be
+// called before Teardown.
+func (ts *testSetup) StartPolling(t *testing.T) (stop func()) {
+	t.Helper()
+
+	var a *api.API
+	bot := ts.Telegram.NewBot(t, telegram.WithSkipGetMe(), telegram.WithDefaultHandler(
+		func(ctx context.Context, _ *telegram.Bot, update *models.Update) {
+			a.ProcessUpdate(ctx, update)
+		},
+	))
+	a = api.New(ts.Storage, ts.Config, nil, bot, ts.Mailer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := a.StartPolling(ctx); err != nil {
+			t.Errorf("polling failed: %v", err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 func PerformRequest(t *testing.T, e *echo.Echo, method, path, body, token string, expectedStatus int) *httptest.ResponseRecorder {
 	req := httptest.NewRequest(method, path, strings.NewReader(body))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -151,6 +191,11 @@ func ParseResponse[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
 }
 
 func AuthHelper(t *testing.T, e *echo.Echo, telegramID int64, username, firstName string) (contract.AuthResponse, error) {
+	return AuthHelperWithStartParam(t, e, telegramID, username, firstName, "")
+}
+
+// AuthHelperWithStartParam authenticates like AuthHelper, as if the mini app was opened with ?startapp=<startParam>.
+func AuthHelperWithStartParam(t *testing.T, e *echo.Echo, telegramID int64, username, firstName, startParam string) (contract.AuthResponse, error) {
 	userJSON := fmt.Sprintf(
 		`{"id":%d,"first_name":"%s","last_name":"","username":"%s","language_code":"ru","is_premium":true,"allows_write_to_pm":true,"photo_url":"https://t.me/i/userpic/320/test.svg"}`,
 		telegramID, firstName, username,
@@ -163,6 +208,10 @@ func AuthHelper(t *testing.T, e *echo.Echo, telegramID int64, username, firstNam
 		"signature": "W_7-jDZLl7iwW8Qr2IZARpIsseV6jJDU_6eQ3ti-XY5Nm58N1_9dkXuFf9xidDZ0aoY_Pv0kq2-clrbHeLMQBA",
 	}
 
+	if startParam != "" {
+		initData["start_param"] = startParam
+	}
+
 	sign := initdata.Sign(initData, TestBotToken, time.Now())
 	initData["hash"] = sign
 
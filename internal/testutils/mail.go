@@ -0,0 +1,42 @@
+package testutils
+
+import (
+	"context"
+	"sync"
+)
+
+type SentMail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// FakeMailer records emails instead of sending them.
+type FakeMailer struct {
+	mu   sync.Mutex
+	sent []SentMail
+}
+
+func (m *FakeMailer) Send(_ context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, SentMail{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+// Sent returns the emails sent so far.
+func (m *FakeMailer) Sent() []SentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SentMail(nil), m.sent...)
+}
+
+// Reset forgets the emails sent so far.
+func (m *FakeMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = nil
+}
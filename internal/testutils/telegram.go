@@ -0,0 +1,286 @@
+package testutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	telegram "github.com/go-telegram/bot"
+)
+
+// TelegramCall is a single Bot API request received by FakeTelegram.
+type TelegramCall struct {
+	Method string
+	Params map[string]string
+}
+
+// JSON decodes a JSON-encoded parameter such as reply_markup or results.
+func (c TelegramCall) JSON(t *testing.T, key string, dest interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(c.Params[key]), dest); err != nil {
+		t.Fatalf("failed to decode %s.%s: %v", c.Method, key, err)
+	}
+}
+
+// FakeTelegram is an in-process stand-in for the Telegram Bot API. It records
+// every call and serves queued updates to getUpdates, so bot flows can be
+// tested offline in both webhook and polling mode.
+type FakeTelegram struct {
+	Server *httptest.Server
+
+	mu            sync.Mutex
+	calls         []TelegramCall
+	updates       []json.RawMessage
+	nextUpdateID  int64
+	nextMessageID int
+	failures      map[string][]telegramFailure
+	changed       chan struct{}
+}
+
+type telegramFailure struct {
+	code        int
+	description string
+}
+
+func NewFakeTelegram(t *testing.T) *FakeTelegram {
+	t.Helper()
+
+	f := &FakeTelegram{
+		nextUpdateID:  1,
+		nextMessageID: 1,
+		changed:       make(chan struct{}),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Server.Close)
+
+	return f
+}
+
+func (f *FakeTelegram) URL() string {
+	return f.Server.URL
+}
+
+// NewBot creates a bot client that talks to the fake server.
+func (f *FakeTelegram) NewBot(t *testing.T, opts ...telegram.Option) *telegram.Bot {
+	t.Helper()
+
+	opts = append([]telegram.Option{telegram.WithServerURL(f.URL())}, opts...)
+	bot, err := telegram.New(TestBotToken, opts...)
+	if err != nil {
+		t.Fatalf("failed to create telegram bot: %v", err)
+	}
+
+	return bot
+}
+
+// PushUpdate queues an update for getUpdates and returns its update_id. The
+// update is given as any JSON-encodable value without the update_id field.
+func (f *FakeTelegram) PushUpdate(t *testing.T, update interface{}) int64 {
+	t.Helper()
+
+	raw, err := json.Marshal(update)
+	if err != nil {
+		t.Fatalf("failed to encode update: %v", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("update must be a JSON object: %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.nextUpdateID
+	f.nextUpdateID++
+	fields["update_id"] = json.RawMessage(strconv.FormatInt(id, 10))
+
+	withID, _ := json.Marshal(fields)
+	f.updates = append(f.updates, withID)
+	f.notifyLocked()
+
+	return id
+}
+
+// Calls returns the recorded calls of the given method, or all calls when method is empty.
+func (f *FakeTelegram) Calls(method string) []TelegramCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []TelegramCall
+	for _, c := range f.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// FailNext makes the next call of method fail with the given error code, as
+// Telegram does when a chat is gone (403) or the server has trouble (5xx).
+// The failed call is still recorded.
+func (f *FakeTelegram) FailNext(method string, code int, description string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures == nil {
+		f.failures = make(map[string][]telegramFailure)
+	}
+	f.failures[method] = append(f.failures[method], telegramFailure{code: code, description: description})
+}
+
+// Reset forgets all recorded calls.
+func (f *FakeTelegram) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// WaitForCall blocks until the method has been called at least n times and returns the n-th call.
+func (f *FakeTelegram) WaitForCall(t *testing.T, method string, n int, timeout time.Duration) TelegramCall {
+	t.Helper()
+
+	deadline := time.After(timeout)
+	for {
+		f.mu.Lock()
+		var matched []TelegramCall
+		for _, c := range f.calls {
+			if c.Method == method {
+				matched = append(matched, c)
+			}
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		if len(matched) >= n {
+			return matched[n-1]
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s call #%d, got %d", method, n, len(matched))
+		}
+	}
+}
+
+func (f *FakeTelegram) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *FakeTelegram) handle(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+
+	if strings.HasPrefix(path, "file/") {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte{})
+		return
+	}
+
+	token, method, ok := strings.Cut(strings.TrimPrefix(path, "bot"), "/")
+	if !ok || token != TestBotToken {
+		writeTelegramError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	params := map[string]string{}
+	if err := r.ParseMultipartForm(10 << 20); err == nil {
+		for key, values := range r.MultipartForm.Value {
+			if len(values) > 0 {
+				params[key] = values[0]
+			}
+		}
+	}
+
+	if method == "getUpdates" {
+		f.serveUpdates(w, r, params)
+		return
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, TelegramCall{Method: method, Params: params})
+	messageID := f.nextMessageID
+	f.nextMessageID++
+	var failure *telegramFailure
+	if queued := f.failures[method]; len(queued) > 0 {
+		failure = &queued[0]
+		f.failures[method] = queued[1:]
+	}
+	f.notifyLocked()
+	f.mu.Unlock()
+
+	if failure != nil {
+		writeTelegramError(w, failure.code, failure.description)
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeTelegramResult(w, map[string]interface{}{
+			"id": 1, "is_bot": true, "first_name": "Test Bot", "username": "test_bot",
+		})
+	case "sendMessage", "editMessageText", "sendPhoto":
+		chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+		writeTelegramResult(w, map[string]interface{}{
+			"message_id": messageID,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+			"text":       params["text"],
+		})
+	case "getUserProfilePhotos":
+		writeTelegramResult(w, map[string]interface{}{"total_count": 0, "photos": []interface{}{}})
+	case "getFile":
+		writeTelegramResult(w, map[string]interface{}{"file_id": params["file_id"], "file_path": "photos/file.jpg"})
+	default:
+		writeTelegramResult(w, true)
+	}
+}
+
+func (f *FakeTelegram) serveUpdates(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	offset, _ := strconv.ParseInt(params["offset"], 10, 64)
+	timeout, _ := strconv.Atoi(params["timeout"])
+
+	deadline := time.After(time.Duration(timeout) * time.Second)
+	for {
+		f.mu.Lock()
+		pending := make([]json.RawMessage, 0)
+		for i, u := range f.updates {
+			if int64(i)+1 >= offset {
+				pending = append(pending, u)
+			}
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		if len(pending) > 0 || timeout == 0 {
+			writeTelegramResult(w, pending)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			writeTelegramResult(w, pending)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeTelegramResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func writeTelegramError(w http.ResponseWriter, code int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": code, "description": description})
+}
@@ -0,0 +1,26 @@
+package testutils
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"sacred/internal/db"
+	"testing"
+)
+
+// CreateListedWish stores a wish with a photo and the given categories, which
+// it needs to show up in feeds and on other people's profiles.
+func CreateListedWish(t *testing.T, s *db.Storage, wish db.Wish, categoryIDs ...string) {
+	t.Helper()
+
+	ctx := context.Background()
+	require.NoError(t, s.CreateWish(ctx, wish, categoryIDs))
+	_, err := s.CreateWishImage(ctx, db.WishImage{
+		ID:        wish.ID + "_photo",
+		WishID:    wish.ID,
+		URL:       "http://localhost/assets/" + wish.ID + ".jpg",
+		CreatedAt: wish.CreatedAt,
+		Width:     100,
+		Height:    100,
+	})
+	require.NoError(t, err)
+}
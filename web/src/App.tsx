@@ -4,7 +4,15 @@ import { createEffect, createSignal, Match, Switch } from 'solid-js'
 import { API_BASE_URL } from '~/lib/api'
 import { NavigationProvider } from '~/lib/useNavigation'
 import Toast from '~/components/toast'
-import { setOnboarding, setToken, setUser, setWishes, store, loadSavedSearches } from '~/store'
+import {
+    setOnboarding,
+    setToken,
+    setRefreshToken,
+    setUser,
+    setWishes,
+    store,
+    loadSavedSearches,
+} from '~/store'
 
 export const queryClient = new QueryClient({
     defaultOptions: {
@@ -58,6 +66,7 @@ export default function App(props: any) {
 
             setUser(data.user)
             setToken(data.token)
+            setRefreshToken(data.refresh_token)
             setWishes(data.wishes)
 
             window.Telegram.WebApp.CloudStorage.removeItem('onboarding')
@@ -72,6 +81,12 @@ export default function App(props: any) {
                 return
             }
 
+            if (startParam && startParam.startsWith('u_')) {
+                const userId = startParam.substring(2)
+                navigate(`/profiles/${userId}`)
+                return
+            }
+
             if (!store.user?.email || !store.user?.interests.length) {
                 navigate('/setup')
             }
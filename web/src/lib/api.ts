@@ -1,14 +1,64 @@
-import { store } from '~/store'
+import { setRefreshToken, setToken, store } from '~/store'
 
 export const API_BASE_URL = import.meta.env.VITE_API_BASE_URL as string
 
+let refreshing: Promise<boolean> | null = null
+
+// Refresh tokens rotate on every use, so concurrent 401s share one refresh.
+function refreshSession(): Promise<boolean> {
+    if (!refreshing) {
+        refreshing = (async () => {
+            try {
+                const resp = await fetch(`${API_BASE_URL}/auth/refresh`, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ refresh_token: store.refreshToken }),
+                })
+                if (!resp.ok) return false
+
+                const data = await resp.json()
+                setToken(data.token)
+                setRefreshToken(data.refresh_token)
+                return true
+            } catch {
+                return false
+            } finally {
+                refreshing = null
+            }
+        })()
+    }
+    return refreshing
+}
+
+// authFetch sends the access token and, when it has expired, retries once
+// with a fresh one.
+async function authFetch(url: string, options: RequestInit = {}) {
+    const send = () =>
+        fetch(url, {
+            ...options,
+            headers: {
+                ...(options.headers || {}),
+                Authorization: `Bearer ${store.token}`,
+            },
+        })
+
+    const response = await send()
+    if (
+        response.status === 401 &&
+        store.refreshToken &&
+        (await refreshSession())
+    ) {
+        return send()
+    }
+    return response
+}
+
 export async function apiRequest(endpoint: string, options: RequestInit = {}) {
     try {
-        const response = await fetch(`${API_BASE_URL}/v1${endpoint}`, {
+        const response = await authFetch(`${API_BASE_URL}/v1${endpoint}`, {
             ...options,
             headers: {
                 'Content-Type': 'application/json',
-                Authorization: `Bearer ${store.token}`,
                 ...(options.headers || {}),
             },
         })
@@ -167,12 +217,9 @@ export const fetchUpdateWish = async (
         })
     }
 
-    const response = await fetch(`${API_BASE_URL}/v1/wishes/${id}`, {
+    const response = await authFetch(`${API_BASE_URL}/v1/wishes/${id}`, {
         method: 'PUT',
         body: formData,
-        headers: {
-            Authorization: `Bearer ${store.token}`,
-        },
     })
 
     let data
@@ -231,12 +278,9 @@ export const createWish = async (wishData: CreateWishData) => {
         })
     }
 
-    const response = await fetch(`${API_BASE_URL}/v1/wishes`, {
+    const response = await authFetch(`${API_BASE_URL}/v1/wishes`, {
         method: 'POST',
         body: formData,
-        headers: {
-            Authorization: `Bearer ${store.token}`,
-        },
     })
 
     let data
@@ -22,6 +22,7 @@ export const [store, setStore] = createStore<{
     onboarding: boolean
     user: User | null
     token: string
+    refreshToken: string
     following: number[]
     wishes: Wish[]
     search: string
@@ -30,6 +31,7 @@ export const [store, setStore] = createStore<{
     onboarding: false,
     user: null,
     token: '',
+    refreshToken: '',
     following: [],
     wishes: [],
     search: '',
@@ -52,5 +54,7 @@ export const loadSavedSearches = async () => {
 }
 
 export const setToken = (token: string) => setStore('token', token)
+export const setRefreshToken = (token: string) =>
+    setStore('refreshToken', token)
 export const setUser = (user: User | null) => setStore('user', user)
 export const setWishes = (wishes: Wish[]) => setStore('wishes', wishes)